@@ -1,6 +1,12 @@
 package main
 
 import (
+	"time"
+
+	"git.containerum.net/ch/kube-api/pkg/kubernetes"
+	"git.containerum.net/ch/kube-api/pkg/model"
+	"git.containerum.net/ch/kube-api/pkg/reconciler"
+	"git.containerum.net/ch/kube-api/pkg/router/handlers"
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli"
@@ -33,6 +39,38 @@ var flags = []cli.Flag{
 		Name:   "cors",
 		Usage:  "enable CORS",
 	},
+	cli.StringFlag{
+		EnvVar: "QUOTA_POLICY",
+		Name:   "quota-policy",
+		Usage:  "YAML or JSON file with namespace quota bounds",
+	},
+	cli.BoolFlag{
+		EnvVar: "RECONCILER",
+		Name:   "reconciler",
+		Usage:  "check namespace quota and limit range in background",
+	},
+	cli.BoolFlag{
+		EnvVar: "RECONCILER_REPORT_ONLY",
+		Name:   "reconciler-report-only",
+		Usage:  "only log and count namespace drifts, don't correct them",
+	},
+	cli.DurationFlag{
+		EnvVar: "RECONCILER_RESYNC",
+		Name:   "reconciler-resync",
+		Value:  10 * time.Minute,
+		Usage:  "interval of checking all namespaces",
+	},
+	cli.BoolFlag{
+		EnvVar: "CERTIFICATE_METRICS",
+		Name:   "certificate-metrics",
+		Usage:  "export expiry time of certificates found by the last admin certificate report",
+	},
+	cli.StringFlag{
+		EnvVar: "INGRESS_CONTROLLER",
+		Name:   "ingress-controller",
+		Value:  "nginx",
+		Usage:  "ingress controller flavour for ingress class and options annotations: nginx or traefik",
+	},
 }
 
 func setupLogs(c *cli.Context) {
@@ -50,3 +88,32 @@ func setupLogs(c *cli.Context) {
 		logrus.SetFormatter(&logrus.JSONFormatter{})
 	}
 }
+
+func setupQuotaPolicy(c *cli.Context) error {
+	if c.String("quota-policy") == "" {
+		return nil
+	}
+	policy, err := model.LoadQuotaPolicy(c.String("quota-policy"))
+	if err != nil {
+		return err
+	}
+	model.SetQuotaPolicy(policy)
+	return nil
+}
+
+func setupReconciler(c *cli.Context, kube *kubernetes.Kube, stop <-chan struct{}) {
+	if !c.Bool("reconciler") {
+		return
+	}
+	go reconciler.NewReconciler(kube, c.Bool("reconciler-report-only"), c.Duration("reconciler-resync")).Run(stop)
+}
+
+func setupIngressController(c *cli.Context) error {
+	return model.SetIngressController(c.String("ingress-controller"))
+}
+
+func setupCertificateMetrics(c *cli.Context) {
+	if c.Bool("certificate-metrics") {
+		handlers.EnableCertificateMetrics()
+	}
+}
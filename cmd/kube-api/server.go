@@ -24,10 +24,21 @@ func initServer(c *cli.Context) error {
 	w.Flush()
 
 	setupLogs(c)
+	if err := setupQuotaPolicy(c); err != nil {
+		return err
+	}
+	if err := setupIngressController(c); err != nil {
+		return err
+	}
+	setupCertificateMetrics(c)
 
 	kube := kubernetes.Kube{}
 	go exitOnErr(kube.RegisterClient(c.String("kubeconf")))
 
+	stopReconciler := make(chan struct{})
+	defer close(stopReconciler)
+	setupReconciler(c, &kube, stopReconciler)
+
 	status := model.ServiceStatus{
 		Name:     c.App.Name,
 		Version:  c.App.Version,
@@ -218,6 +218,72 @@ func ErrUnableDownsizeVolume(params ...func(*cherry.Err)) *cherry.Err {
 	}
 	return err
 }
+
+// ErrExecTimeout error
+// Command was still running when exec timeout expired
+func ErrExecTimeout(params ...func(*cherry.Err)) *cherry.Err {
+	err := &cherry.Err{Message: "Exec call timed out", StatusHTTP: 504, ID: cherry.ErrID{SID: "Kube-API", Kind: 0x12}, Details: []string(nil), Fields: cherry.Fields(nil)}
+	for _, param := range params {
+		param(err)
+	}
+	for i, detail := range err.Details {
+		det := renderTemplate(detail)
+		err.Details[i] = det
+	}
+	return err
+}
+
+// ErrUnablePortForward error
+func ErrUnablePortForward(params ...func(*cherry.Err)) *cherry.Err {
+	err := &cherry.Err{Message: "Unable to forward port", StatusHTTP: 500, ID: cherry.ErrID{SID: "Kube-API", Kind: 0x13}, Details: []string(nil), Fields: cherry.Fields(nil)}
+	for _, param := range params {
+		param(err)
+	}
+	for i, detail := range err.Details {
+		det := renderTemplate(detail)
+		err.Details[i] = det
+	}
+	return err
+}
+
+// ErrUnableCopyFiles error
+func ErrUnableCopyFiles(params ...func(*cherry.Err)) *cherry.Err {
+	err := &cherry.Err{Message: "Unable to copy files", StatusHTTP: 500, ID: cherry.ErrID{SID: "Kube-API", Kind: 0x14}, Details: []string(nil), Fields: cherry.Fields(nil)}
+	for _, param := range params {
+		param(err)
+	}
+	for i, detail := range err.Details {
+		det := renderTemplate(detail)
+		err.Details[i] = det
+	}
+	return err
+}
+
+// ErrFilesTooLarge error
+func ErrFilesTooLarge(params ...func(*cherry.Err)) *cherry.Err {
+	err := &cherry.Err{Message: "Files size exceeds limit", StatusHTTP: 413, ID: cherry.ErrID{SID: "Kube-API", Kind: 0x15}, Details: []string(nil), Fields: cherry.Fields(nil)}
+	for _, param := range params {
+		param(err)
+	}
+	for i, detail := range err.Details {
+		det := renderTemplate(detail)
+		err.Details[i] = det
+	}
+	return err
+}
+
+// ErrMetricsUnavailable error
+func ErrMetricsUnavailable(params ...func(*cherry.Err)) *cherry.Err {
+	err := &cherry.Err{Message: "Metrics API is unavailable", StatusHTTP: 503, ID: cherry.ErrID{SID: "Kube-API", Kind: 0x16}, Details: []string(nil), Fields: cherry.Fields(nil)}
+	for _, param := range params {
+		param(err)
+	}
+	for i, detail := range err.Details {
+		det := renderTemplate(detail)
+		err.Details[i] = det
+	}
+	return err
+}
 func renderTemplate(templText string) string {
 	buf := &bytes.Buffer{}
 	templ, err := template.New("").Parse(templText)
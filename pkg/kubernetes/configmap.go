@@ -33,7 +33,8 @@ func (k *Kube) GetConfigMap(namespace, cm string) (*api_core.ConfigMap, error) {
 
 //CreateConfigMap creates config map
 func (k *Kube) CreateConfigMap(cm *api_core.ConfigMap) (*api_core.ConfigMap, error) {
-	cmAfter, err := k.CoreV1().ConfigMaps(cm.Namespace).Create(cm)
+	cmAfter := &api_core.ConfigMap{}
+	err := k.create(k.CoreV1().RESTClient(), cm.Namespace, "configmaps", cm, cmAfter)
 	if err != nil {
 		log.WithFields(log.Fields{
 			"Namespace": cm.Namespace,
@@ -46,7 +47,8 @@ func (k *Kube) CreateConfigMap(cm *api_core.ConfigMap) (*api_core.ConfigMap, err
 
 //UpdateConfigMap updates config map
 func (k *Kube) UpdateConfigMap(cm *api_core.ConfigMap) (*api_core.ConfigMap, error) {
-	cmAfter, err := k.CoreV1().ConfigMaps(cm.Namespace).Update(cm)
+	cmAfter := &api_core.ConfigMap{}
+	err := k.update(k.CoreV1().RESTClient(), cm.Namespace, "configmaps", cm, cmAfter)
 	if err != nil {
 		log.WithFields(log.Fields{
 			"Namespace": cm.Namespace,
@@ -59,7 +61,7 @@ func (k *Kube) UpdateConfigMap(cm *api_core.ConfigMap) (*api_core.ConfigMap, err
 
 //DeleteConfigMap deletes config map
 func (k *Kube) DeleteConfigMap(namespace, cm string) error {
-	err := k.CoreV1().ConfigMaps(namespace).Delete(cm, &api_meta.DeleteOptions{})
+	err := k.delete(k.CoreV1().RESTClient(), namespace, "configmaps", cm)
 	if err != nil {
 		log.WithFields(log.Fields{
 			"Namespace": namespace,
@@ -50,7 +50,8 @@ func (k *Kube) GetDeployment(ns string, deploy string) (*api_apps.Deployment, er
 
 //CreateDeployment creates deployment
 func (k *Kube) CreateDeployment(depl *api_apps.Deployment) (*api_apps.Deployment, error) {
-	deployment, err := k.AppsV1().Deployments(depl.Namespace).Create(depl)
+	deployment := &api_apps.Deployment{}
+	err := k.create(k.AppsV1().RESTClient(), depl.Namespace, "deployments", depl, deployment)
 	if err != nil {
 		log.WithFields(log.Fields{
 			"Namespace":  depl.Namespace,
@@ -63,7 +64,7 @@ func (k *Kube) CreateDeployment(depl *api_apps.Deployment) (*api_apps.Deployment
 
 //DeleteDeployment deletes deployment
 func (k *Kube) DeleteDeployment(ns string, deployName string) error {
-	err := k.AppsV1().Deployments(ns).Delete(deployName, &api_meta.DeleteOptions{})
+	err := k.delete(k.AppsV1().RESTClient(), ns, "deployments", deployName)
 	if err != nil {
 		log.WithFields(log.Fields{
 			"Namespace":  ns,
@@ -76,9 +77,7 @@ func (k *Kube) DeleteDeployment(ns string, deployName string) error {
 
 //DeleteDeployment deletes deployments
 func (k *Kube) DeleteDeploymentSolution(ns string, solutionID string) error {
-	err := k.AppsV1().Deployments(ns).DeleteCollection(&api_meta.DeleteOptions{}, api_meta.ListOptions{
-		LabelSelector: getSolutionLabel(solutionID),
-	})
+	err := k.deleteCollection(k.AppsV1().RESTClient(), ns, "deployments", getSolutionLabel(solutionID))
 	if err != nil {
 		log.WithFields(log.Fields{
 			"Namespace": ns,
@@ -91,7 +90,8 @@ func (k *Kube) DeleteDeploymentSolution(ns string, solutionID string) error {
 
 //UpdateDeployment updates deployment
 func (k *Kube) UpdateDeployment(depl *api_apps.Deployment) (*api_apps.Deployment, error) {
-	deployment, err := k.AppsV1().Deployments(depl.Namespace).Update(depl)
+	deployment := &api_apps.Deployment{}
+	err := k.update(k.AppsV1().RESTClient(), depl.Namespace, "deployments", depl, deployment)
 	if err != nil {
 		log.WithFields(log.Fields{
 			"Namespace":  depl.Namespace,
@@ -0,0 +1,154 @@
+package kubernetes
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+	api_errors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	api_meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+)
+
+// server-side dry run is enabled by default since this version
+const dryRunMinMajor, dryRunMinMinor = 1, 13
+
+// dryRunSupport caches result of apiserver version check
+type dryRunSupport struct {
+	once      sync.Once
+	supported bool
+}
+
+// DryRun returns client which doesn't persist created and updated objects.
+// Objects are submitted with server-side dry run if apiserver supports it,
+// otherwise only existence of objects is checked.
+func (k *Kube) DryRun() *Kube {
+	dryRunKube := *k
+	dryRunKube.dryRun = true
+	return &dryRunKube
+}
+
+// IsDryRun reports if client doesn't persist changes
+func (k *Kube) IsDryRun() bool {
+	return k.dryRun
+}
+
+func (k *Kube) dryRunSupported() bool {
+	check := func() bool {
+		info, err := k.Discovery().ServerVersion()
+		if err != nil {
+			log.WithError(err).Warn("Unable to get apiserver version")
+			return false
+		}
+		major, _ := strconv.Atoi(info.Major)
+		minor, _ := strconv.Atoi(strings.TrimSuffix(info.Minor, "+"))
+		return major > dryRunMinMajor || (major == dryRunMinMajor && minor >= dryRunMinMinor)
+	}
+	if k.dryRunSupport == nil {
+		return check()
+	}
+	k.dryRunSupport.once.Do(func() {
+		k.dryRunSupport.supported = check()
+	})
+	return k.dryRunSupport.supported
+}
+
+// create posts object to resource collection and decodes result to into
+func (k *Kube) create(client rest.Interface, ns string, resource string, obj runtime.Object, into runtime.Object) error {
+	req := client.Post().
+		Namespace(ns).
+		Resource(resource).
+		Body(obj)
+	if k.dryRun {
+		if !k.dryRunSupported() {
+			return k.emulateDryRun(client, ns, resource, obj, into, false)
+		}
+		req.Param("dryRun", api_meta.DryRunAll)
+	}
+	return req.Do().Into(into)
+}
+
+// update puts object to resource and decodes result to into
+func (k *Kube) update(client rest.Interface, ns string, resource string, obj runtime.Object, into runtime.Object) error {
+	objMeta, err := meta.Accessor(obj)
+	if err != nil {
+		return err
+	}
+	req := client.Put().
+		Namespace(ns).
+		Resource(resource).
+		Name(objMeta.GetName()).
+		Body(obj)
+	if k.dryRun {
+		if !k.dryRunSupported() {
+			return k.emulateDryRun(client, ns, resource, obj, into, true)
+		}
+		req.Param("dryRun", api_meta.DryRunAll)
+	}
+	return req.Do().Into(into)
+}
+
+// emulateDryRun checks that object exists (or doesn't exist for creation) and returns it unchanged
+func (k *Kube) emulateDryRun(client rest.Interface, ns string, resource string, obj runtime.Object, into runtime.Object, shouldExist bool) error {
+	objMeta, err := meta.Accessor(obj)
+	if err != nil {
+		return err
+	}
+	err = client.Get().
+		Namespace(ns).
+		Resource(resource).
+		Name(objMeta.GetName()).
+		Do().
+		Error()
+	switch {
+	case err == nil && !shouldExist:
+		return api_errors.NewAlreadyExists(schema.GroupResource{Resource: resource}, objMeta.GetName())
+	case err != nil && (shouldExist || !api_errors.IsNotFound(err)):
+		return err
+	}
+	reflect.ValueOf(into).Elem().Set(reflect.ValueOf(obj.DeepCopyObject()).Elem())
+	return nil
+}
+
+// delete deletes resource object, in dry run mode it only checks that object can be deleted
+func (k *Kube) delete(client rest.Interface, ns string, resource string, name string) error {
+	if k.dryRun && !k.dryRunSupported() {
+		return client.Get().
+			Namespace(ns).
+			Resource(resource).
+			Name(name).
+			Do().
+			Error()
+	}
+	req := client.Delete().
+		Namespace(ns).
+		Resource(resource).
+		Name(name).
+		Body(&api_meta.DeleteOptions{})
+	if k.dryRun {
+		req.Param("dryRun", api_meta.DryRunAll)
+	}
+	return req.Do().Error()
+}
+
+// deleteCollection deletes resource objects matching label selector.
+// In dry run mode without server support nothing is done, as deletion of any set of objects is allowed.
+func (k *Kube) deleteCollection(client rest.Interface, ns string, resource string, labelSelector string) error {
+	if k.dryRun && !k.dryRunSupported() {
+		return nil
+	}
+	req := client.Delete().
+		Namespace(ns).
+		Resource(resource).
+		Param("labelSelector", labelSelector).
+		Body(&api_meta.DeleteOptions{})
+	if k.dryRun {
+		req.Param("dryRun", api_meta.DryRunAll)
+	}
+	return req.Do().Error()
+}
@@ -33,7 +33,8 @@ func (k *Kube) GetEndpoint(namespace, endpoint string) (*api_core.Endpoints, err
 
 //CreateEndpoint creates endpoint
 func (k *Kube) CreateEndpoint(endpoint *api_core.Endpoints) (*api_core.Endpoints, error) {
-	endpointAfter, err := k.CoreV1().Endpoints(endpoint.Namespace).Create(endpoint)
+	endpointAfter := &api_core.Endpoints{}
+	err := k.create(k.CoreV1().RESTClient(), endpoint.Namespace, "endpoints", endpoint, endpointAfter)
 	if err != nil {
 		log.WithFields(log.Fields{
 			"Namespace": endpoint.Namespace,
@@ -46,7 +47,8 @@ func (k *Kube) CreateEndpoint(endpoint *api_core.Endpoints) (*api_core.Endpoints
 
 //UpdateEndpoint updates endpoint
 func (k *Kube) UpdateEndpoint(endpoint *api_core.Endpoints) (*api_core.Endpoints, error) {
-	endpointAfter, err := k.CoreV1().Endpoints(endpoint.Namespace).Update(endpoint)
+	endpointAfter := &api_core.Endpoints{}
+	err := k.update(k.CoreV1().RESTClient(), endpoint.Namespace, "endpoints", endpoint, endpointAfter)
 	if err != nil {
 		log.WithFields(log.Fields{
 			"Namespace": endpoint.Namespace,
@@ -59,7 +61,7 @@ func (k *Kube) UpdateEndpoint(endpoint *api_core.Endpoints) (*api_core.Endpoints
 
 //DeleteEndpoint deletes endpoint
 func (k *Kube) DeleteEndpoint(namespace, endpoint string) error {
-	err := k.CoreV1().Endpoints(namespace).Delete(endpoint, &api_meta.DeleteOptions{})
+	err := k.delete(k.CoreV1().RESTClient(), namespace, "endpoints", endpoint)
 	if err != nil {
 		log.WithFields(log.Fields{
 			"Namespace": namespace,
@@ -0,0 +1,68 @@
+package kubernetes
+
+import (
+	log "github.com/sirupsen/logrus"
+	api_apps "k8s.io/api/apps/v1"
+	api_core "k8s.io/api/core/v1"
+	api_extensions "k8s.io/api/extensions/v1beta1"
+	api_meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// NamespaceObjects -- user objects of namespace
+type NamespaceObjects struct {
+	Deployments            *api_apps.DeploymentList
+	Services               *api_core.ServiceList
+	Ingresses              *api_extensions.IngressList
+	ConfigMaps             *api_core.ConfigMapList
+	PersistentVolumeClaims *api_core.PersistentVolumeClaimList
+	// nil if secrets are not requested
+	Secrets *api_core.SecretList
+	// Ingress API version served by cluster, ingresses are exported in it
+	IngressGroupVersion string
+}
+
+//GetNamespaceObjects returns deployments, services, ingresses, configmaps, volumes and optionally secrets of namespace.
+//If solution is not empty, only objects of this solution are returned.
+func (k *Kube) GetNamespaceObjects(ns string, solution string, withSecrets bool) (*NamespaceObjects, error) {
+	opts := api_meta.ListOptions{
+		LabelSelector: getSolutionLabel(solution),
+	}
+	var objects NamespaceObjects
+	var err error
+	logErr := func(kind string) {
+		log.WithFields(log.Fields{
+			"Namespace": ns,
+			"Solution":  solution,
+			"Kind":      kind,
+		}).Error(err)
+	}
+
+	if objects.Deployments, err = k.AppsV1().Deployments(ns).List(opts); err != nil {
+		logErr("Deployment")
+		return nil, err
+	}
+	if objects.Services, err = k.CoreV1().Services(ns).List(opts); err != nil {
+		logErr("Service")
+		return nil, err
+	}
+	objects.IngressGroupVersion = k.IngressGroupVersion()
+	if objects.Ingresses, err = k.listIngresses(objects.IngressGroupVersion, ns, opts.LabelSelector); err != nil {
+		logErr("Ingress")
+		return nil, err
+	}
+	if objects.ConfigMaps, err = k.CoreV1().ConfigMaps(ns).List(opts); err != nil {
+		logErr("ConfigMap")
+		return nil, err
+	}
+	if objects.PersistentVolumeClaims, err = k.CoreV1().PersistentVolumeClaims(ns).List(opts); err != nil {
+		logErr("PersistentVolumeClaim")
+		return nil, err
+	}
+	if withSecrets {
+		if objects.Secrets, err = k.CoreV1().Secrets(ns).List(opts); err != nil {
+			logErr("Secret")
+			return nil, err
+		}
+	}
+	return &objects, nil
+}
@@ -0,0 +1,36 @@
+package kubernetes
+
+import (
+	"io"
+	"path"
+	"time"
+)
+
+//DownloadFiles writes tar archive with file or directory from pod container to out.
+//Command is terminated after timeout or when stop is closed.
+func (k *Kube) DownloadFiles(ns string, po string, container string, srcPath string, out io.Writer, errOut io.Writer, timeout time.Duration, stop <-chan struct{}) error {
+	dir, base := path.Split(path.Clean(srcPath))
+	if base == "" || base == "/" {
+		dir, base = "/", "."
+	}
+	return k.Exec(ns, po, &ExecOptions{
+		Container: container,
+		Command:   []string{"tar", "cf", "-", "-C", dir, base},
+		Stdout:    out,
+		Stderr:    errOut,
+		Timeout:   timeout,
+		Stop:      stop,
+	})
+}
+
+//UploadFiles extracts tar archive from in to directory in pod container.
+//Command is terminated after timeout.
+func (k *Kube) UploadFiles(ns string, po string, container string, destDir string, in io.Reader, errOut io.Writer, timeout time.Duration) error {
+	return k.Exec(ns, po, &ExecOptions{
+		Container: container,
+		Command:   []string{"tar", "xmf", "-", "-C", path.Clean(destDir)},
+		Stdin:     in,
+		Stderr:    errOut,
+		Timeout:   timeout,
+	})
+}
@@ -1,14 +1,135 @@
 package kubernetes
 
 import (
+	"sync"
+
+	"git.containerum.net/ch/kube-api/pkg/model/networking"
 	log "github.com/sirupsen/logrus"
 	api_extensions "k8s.io/api/extensions/v1beta1"
+	api_errors "k8s.io/apimachinery/pkg/api/errors"
 	api_meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
 )
 
+const ingressResource = "ingresses"
+
+// ingressAPI caches Ingress API version chosen by successful discovery
+type ingressAPI struct {
+	mu           sync.Mutex
+	groupVersion string
+}
+
+// IngressGroupVersion returns the most preferred Ingress API version served by apiserver.
+// Ingresses are converted to and from extensions/v1beta1 used by kube-api.
+// If discovery fails extensions/v1beta1 is used until next call retries discovery.
+func (k *Kube) IngressGroupVersion() string {
+	discover := func() (string, bool) {
+		discovered := true
+		for _, groupVersion := range networking.PreferredVersions {
+			resources, err := k.Discovery().ServerResourcesForGroupVersion(groupVersion)
+			if err != nil {
+				if !api_errors.IsNotFound(err) {
+					log.WithError(err).WithField("GroupVersion", groupVersion).Warn("Unable to discover Ingress API")
+					discovered = false
+				}
+				continue
+			}
+			for _, resource := range resources.APIResources {
+				if resource.Name == ingressResource {
+					return groupVersion, discovered
+				}
+			}
+		}
+		return networking.ExtensionsV1beta1, discovered
+	}
+	if k.ingressAPI == nil {
+		groupVersion, _ := discover()
+		return groupVersion
+	}
+	k.ingressAPI.mu.Lock()
+	defer k.ingressAPI.mu.Unlock()
+	if k.ingressAPI.groupVersion != "" {
+		return k.ingressAPI.groupVersion
+	}
+	groupVersion, discovered := discover()
+	if discovered {
+		k.ingressAPI.groupVersion = groupVersion
+		log.WithField("GroupVersion", groupVersion).Info("Using Ingress API")
+	}
+	return groupVersion
+}
+
+// ingressRequest prepares request to ingresses of namespace (all namespaces if ns is empty) or to named ingress
+func (k *Kube) ingressRequest(req *rest.Request, groupVersion, ns, name string) *rest.Request {
+	segments := []string{"/apis", groupVersion}
+	if ns != "" {
+		segments = append(segments, "namespaces", ns)
+	}
+	segments = append(segments, ingressResource)
+	if name != "" {
+		segments = append(segments, name)
+	}
+	return req.AbsPath(segments...)
+}
+
+func (k *Kube) listIngresses(groupVersion, ns string, labelSelector string) (*api_extensions.IngressList, error) {
+	req := k.ingressRequest(k.CoreV1().RESTClient().Get(), groupVersion, ns, "")
+	if labelSelector != "" {
+		req.Param("labelSelector", labelSelector)
+	}
+	raw, err := req.DoRaw()
+	if err != nil {
+		return nil, err
+	}
+	return networking.DecodeIngressList(raw, groupVersion)
+}
+
+func (k *Kube) getIngress(groupVersion, ns string, ingress string) (*api_extensions.Ingress, error) {
+	raw, err := k.ingressRequest(k.CoreV1().RESTClient().Get(), groupVersion, ns, ingress).DoRaw()
+	if err != nil {
+		return nil, err
+	}
+	return networking.DecodeIngress(raw, groupVersion)
+}
+
+// writeIngress posts new ingress or puts existing one, respecting dry run mode like create and update
+func (k *Kube) writeIngress(ingress *api_extensions.Ingress, exists bool) (*api_extensions.Ingress, error) {
+	groupVersion := k.IngressGroupVersion()
+	if k.dryRun && !k.dryRunSupported() {
+		_, err := k.getIngress(groupVersion, ingress.Namespace, ingress.Name)
+		switch {
+		case err == nil && !exists:
+			return nil, api_errors.NewAlreadyExists(schema.GroupResource{Resource: ingressResource}, ingress.Name)
+		case err != nil && (exists || !api_errors.IsNotFound(err)):
+			return nil, err
+		}
+		return ingress.DeepCopy(), nil
+	}
+
+	body, err := networking.EncodeIngress(ingress, groupVersion)
+	if err != nil {
+		return nil, err
+	}
+	var req *rest.Request
+	if exists {
+		req = k.ingressRequest(k.CoreV1().RESTClient().Put(), groupVersion, ingress.Namespace, ingress.Name)
+	} else {
+		req = k.ingressRequest(k.CoreV1().RESTClient().Post(), groupVersion, ingress.Namespace, "")
+	}
+	if k.dryRun {
+		req.Param("dryRun", api_meta.DryRunAll)
+	}
+	raw, err := req.SetHeader("Content-Type", "application/json").Body(body).DoRaw()
+	if err != nil {
+		return nil, err
+	}
+	return networking.DecodeIngress(raw, groupVersion)
+}
+
 //GetIngressList returns ingresses list
 func (k *Kube) GetIngressList(ns string) (*api_extensions.IngressList, error) {
-	ingressList, err := k.ExtensionsV1beta1().Ingresses(ns).List(api_meta.ListOptions{})
+	ingressList, err := k.listIngresses(k.IngressGroupVersion(), ns, "")
 	if err != nil {
 		log.WithFields(log.Fields{
 			"Namespace": ns,
@@ -20,7 +141,7 @@ func (k *Kube) GetIngressList(ns string) (*api_extensions.IngressList, error) {
 
 //GetIngress returns ingress
 func (k *Kube) GetIngress(ns string, ingress string) (*api_extensions.Ingress, error) {
-	ingressAfter, err := k.ExtensionsV1beta1().Ingresses(ns).Get(ingress, api_meta.GetOptions{})
+	ingressAfter, err := k.getIngress(k.IngressGroupVersion(), ns, ingress)
 	if err != nil {
 		log.WithFields(log.Fields{
 			"Namespace": ns,
@@ -33,7 +154,7 @@ func (k *Kube) GetIngress(ns string, ingress string) (*api_extensions.Ingress, e
 
 //CreateIngress creates ingress
 func (k *Kube) CreateIngress(ingress *api_extensions.Ingress) (*api_extensions.Ingress, error) {
-	ingressAfter, err := k.ExtensionsV1beta1().Ingresses(ingress.Namespace).Create(ingress)
+	ingressAfter, err := k.writeIngress(ingress, false)
 	if err != nil {
 		log.WithFields(log.Fields{
 			"Namespace": ingress.Namespace,
@@ -46,7 +167,7 @@ func (k *Kube) CreateIngress(ingress *api_extensions.Ingress) (*api_extensions.I
 
 //UpdateIngress updates ingress
 func (k *Kube) UpdateIngress(ingress *api_extensions.Ingress) (*api_extensions.Ingress, error) {
-	ingressAfter, err := k.ExtensionsV1beta1().Ingresses(ingress.Namespace).Update(ingress)
+	ingressAfter, err := k.writeIngress(ingress, true)
 	if err != nil {
 		log.WithFields(log.Fields{
 			"Namespace": ingress.Namespace,
@@ -59,7 +180,17 @@ func (k *Kube) UpdateIngress(ingress *api_extensions.Ingress) (*api_extensions.I
 
 //DeleteIngress deletes ingress
 func (k *Kube) DeleteIngress(ns string, ingress string) error {
-	err := k.ExtensionsV1beta1().Ingresses(ns).Delete(ingress, &api_meta.DeleteOptions{})
+	groupVersion := k.IngressGroupVersion()
+	var err error
+	if k.dryRun && !k.dryRunSupported() {
+		_, err = k.getIngress(groupVersion, ns, ingress)
+	} else {
+		req := k.ingressRequest(k.CoreV1().RESTClient().Delete(), groupVersion, ns, ingress)
+		if k.dryRun {
+			req.Param("dryRun", api_meta.DryRunAll)
+		}
+		err = req.Do().Error()
+	}
 	if err != nil {
 		log.WithFields(log.Fields{
 			"Namespace": ns,
@@ -0,0 +1,66 @@
+package kubernetes
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"git.containerum.net/ch/kube-api/pkg/model/networking"
+	api_extensions "k8s.io/api/extensions/v1beta1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// newIngressStandIn starts server which serves ingresses only with groupVersion and stores the last written body
+func newIngressStandIn(t *testing.T, groupVersion string, written *string) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/apis/"+groupVersion, func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(meta_v1.APIResourceList{
+			GroupVersion: groupVersion,
+			APIResources: []meta_v1.APIResource{{Name: "ingresses", Namespaced: true, Kind: "Ingress"}},
+		})
+	})
+	mux.HandleFunc("/apis/"+groupVersion+"/namespaces/test/ingresses", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		*written = string(body)
+		w.WriteHeader(http.StatusCreated)
+		w.Write(body)
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestIngressVersionDiscovery(t *testing.T) {
+	ingress := &api_extensions.Ingress{
+		ObjectMeta: meta_v1.ObjectMeta{Name: "web", Namespace: "test"},
+		Spec: api_extensions.IngressSpec{Rules: []api_extensions.IngressRule{{
+			Host: "web.example.com",
+			IngressRuleValue: api_extensions.IngressRuleValue{HTTP: &api_extensions.HTTPIngressRuleValue{
+				Paths: []api_extensions.HTTPIngressPath{{Path: "/", Backend: api_extensions.IngressBackend{ServiceName: "web", ServicePort: intstr.FromInt(80)}}},
+			}},
+		}}},
+	}
+
+	for _, groupVersion := range networking.PreferredVersions {
+		var written string
+		srv := newIngressStandIn(t, groupVersion, &written)
+		kube := newTestKube(t, srv.URL)
+
+		if discovered := kube.IngressGroupVersion(); discovered != groupVersion {
+			t.Errorf("expected %v, discovered %v", groupVersion, discovered)
+		}
+		created, err := kube.CreateIngress(ingress)
+		if err != nil {
+			t.Fatalf("%v: %v", groupVersion, err)
+		}
+		if !strings.Contains(written, `"apiVersion":"`+groupVersion+`"`) {
+			t.Errorf("%v: unexpected request body %v", groupVersion, written)
+		}
+		if created.Name != "web" || created.Spec.Rules[0].HTTP.Paths[0].Backend.ServiceName != "web" {
+			t.Errorf("%v: unexpected ingress %+v", groupVersion, created)
+		}
+		srv.Close()
+	}
+}
@@ -12,6 +12,10 @@ import (
 type Kube struct {
 	*kubernetes.Clientset
 	config *rest.Config
+
+	dryRun        bool
+	dryRunSupport *dryRunSupport
+	ingressAPI    *ingressAPI
 }
 
 //RegisterClient creates kubernetes client
@@ -33,6 +37,8 @@ func (k *Kube) RegisterClient(cfgpath string) error {
 	}
 	k.Clientset = kubecli
 	k.config = config
+	k.dryRunSupport = &dryRunSupport{}
+	k.ingressAPI = &ingressAPI{}
 	return nil
 }
 
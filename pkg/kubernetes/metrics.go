@@ -0,0 +1,90 @@
+package kubernetes
+
+import (
+	"encoding/json"
+
+	"git.containerum.net/ch/kube-api/pkg/kubeerrors"
+	"git.containerum.net/ch/kube-api/pkg/model/metrics"
+	log "github.com/sirupsen/logrus"
+	api_errors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+//GetPodMetrics returns current usage of pod
+func (k *Kube) GetPodMetrics(ns string, po string) (*metrics.PodMetrics, error) {
+	if err := k.checkMetricsAPI(); err != nil {
+		return nil, err
+	}
+	raw, err := k.CoreV1().RESTClient().Get().
+		AbsPath("/apis", metrics.GroupVersion, "namespaces", ns, "pods", po).
+		DoRaw()
+	if err != nil {
+		log.WithFields(log.Fields{
+			"Namespace": ns,
+			"Pod":       po,
+		}).Error(err)
+		return nil, parseMetricsError(err)
+	}
+	var pod metrics.PodMetrics
+	if err := json.Unmarshal(raw, &pod); err != nil {
+		return nil, err
+	}
+	return &pod, nil
+}
+
+//GetPodMetricsList returns current usage of all pods in namespace
+func (k *Kube) GetPodMetricsList(ns string) (*metrics.PodMetricsList, error) {
+	return k.getPodMetricsList(ns, "")
+}
+
+//GetDeploymentPodMetricsList returns current usage of deployment pods
+func (k *Kube) GetDeploymentPodMetricsList(ns string, deploy string) (*metrics.PodMetricsList, error) {
+	return k.getPodMetricsList(ns, getDeploymentLabel(deploy))
+}
+
+func (k *Kube) getPodMetricsList(ns string, labelSelector string) (*metrics.PodMetricsList, error) {
+	if err := k.checkMetricsAPI(); err != nil {
+		return nil, err
+	}
+	req := k.CoreV1().RESTClient().Get().
+		AbsPath("/apis", metrics.GroupVersion, "namespaces", ns, "pods")
+	if labelSelector != "" {
+		req.Param("labelSelector", labelSelector)
+	}
+	raw, err := req.DoRaw()
+	if err != nil {
+		log.WithFields(log.Fields{
+			"Namespace": ns,
+			"Selector":  labelSelector,
+		}).Error(err)
+		return nil, parseMetricsError(err)
+	}
+	var list metrics.PodMetricsList
+	if err := json.Unmarshal(raw, &list); err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+// checkMetricsAPI checks that metrics API is registered in cluster,
+// so "not found" errors can be distinguished from missing metrics server
+func (k *Kube) checkMetricsAPI() error {
+	_, err := k.Discovery().ServerResourcesForGroupVersion(metrics.GroupVersion)
+	switch {
+	case err == nil:
+		return nil
+	case api_errors.IsNotFound(err), api_errors.IsServiceUnavailable(err):
+		log.WithError(err).Warn("Metrics API is unavailable")
+		return kubeerrors.ErrMetricsUnavailable().AddDetailF("%v API is not available in cluster", metrics.GroupVersion)
+	default:
+		log.WithError(err).Error("Metrics API discovery failed")
+		return err
+	}
+}
+
+// parseMetricsError reports unavailable metrics API if registered metrics server doesn't respond
+func parseMetricsError(err error) error {
+	if api_errors.IsServiceUnavailable(err) {
+		return kubeerrors.ErrMetricsUnavailable().AddDetailsErr(err)
+	}
+	return err
+}
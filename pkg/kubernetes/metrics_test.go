@@ -0,0 +1,127 @@
+package kubernetes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"git.containerum.net/ch/kube-api/pkg/kubeerrors"
+	"git.containerum.net/ch/kube-api/pkg/model/metrics"
+	"github.com/containerum/cherry"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+const testPodMetrics = `{
+	"kind": "PodMetrics",
+	"apiVersion": "metrics.k8s.io/v1beta1",
+	"metadata": {"name": "web-1", "namespace": "test", "labels": {"app": "web"}},
+	"timestamp": "2018-06-01T10:00:00Z",
+	"window": "1m0s",
+	"containers": [
+		{"name": "nginx", "usage": {"cpu": "250m", "memory": "64Mi"}},
+		{"name": "sidecar", "usage": {"cpu": "5m", "memory": "16Mi"}}
+	]
+}`
+
+// newMetricsStandIn starts server which emulates metrics.k8s.io API
+func newMetricsStandIn(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/apis/metrics.k8s.io/v1beta1", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(meta_v1.APIResourceList{
+			GroupVersion: metrics.GroupVersion,
+			APIResources: []meta_v1.APIResource{{Name: "pods", Namespaced: true, Kind: "PodMetrics"}},
+		})
+	})
+	mux.HandleFunc("/apis/metrics.k8s.io/v1beta1/namespaces/test/pods/web-1", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(testPodMetrics))
+	})
+	mux.HandleFunc("/apis/metrics.k8s.io/v1beta1/namespaces/test/pods", func(w http.ResponseWriter, r *http.Request) {
+		if selector := r.URL.Query().Get("labelSelector"); selector != "" && selector != "app=web" {
+			w.Write([]byte(`{"kind": "PodMetricsList", "apiVersion": "metrics.k8s.io/v1beta1", "items": []}`))
+			return
+		}
+		w.Write([]byte(`{"kind": "PodMetricsList", "apiVersion": "metrics.k8s.io/v1beta1", "items": [` + testPodMetrics + `]}`))
+	})
+	return httptest.NewServer(mux)
+}
+
+func newTestKube(t *testing.T, host string) *Kube {
+	config := &rest.Config{Host: host}
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Kube{Clientset: clientset, config: config}
+}
+
+func TestGetPodMetrics(t *testing.T) {
+	srv := newMetricsStandIn(t)
+	defer srv.Close()
+	kube := newTestKube(t, srv.URL)
+
+	metrics, err := kube.GetPodMetrics("test", "web-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if metrics.Name != "web-1" || len(metrics.Containers) != 2 {
+		t.Fatalf("unexpected metrics: %+v", metrics)
+	}
+	if cpu := metrics.Containers[0].Usage["cpu"]; cpu.MilliValue() != 250 {
+		t.Errorf("expected 250m CPU, got %v", cpu.String())
+	}
+}
+
+func TestGetDeploymentPodMetricsList(t *testing.T) {
+	srv := newMetricsStandIn(t)
+	defer srv.Close()
+	kube := newTestKube(t, srv.URL)
+
+	metrics, err := kube.GetDeploymentPodMetricsList("test", "web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(metrics.Items) != 1 {
+		t.Fatalf("expected 1 pod, got %v", len(metrics.Items))
+	}
+
+	metrics, err = kube.GetDeploymentPodMetricsList("test", "db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(metrics.Items) != 0 {
+		t.Fatalf("expected no pods, got %v", len(metrics.Items))
+	}
+}
+
+func TestMetricsAPIMissing(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+	kube := newTestKube(t, srv.URL)
+
+	_, err := kube.GetPodMetricsList("test")
+	if cherryErr, ok := err.(*cherry.Err); !ok || !cherryErr.Equals(kubeerrors.ErrMetricsUnavailable()) {
+		t.Fatalf("expected metrics unavailable error, got %v", err)
+	}
+}
+
+func TestMetricsAPIUnavailable(t *testing.T) {
+	srv := newMetricsStandIn(t)
+	defer srv.Close()
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/apis/metrics.k8s.io/v1beta1" {
+			srv.Config.Handler.ServeHTTP(w, r)
+			return
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer down.Close()
+	kube := newTestKube(t, down.URL)
+
+	_, err := kube.GetPodMetrics("test", "web-1")
+	if cherryErr, ok := err.(*cherry.Err); !ok || !cherryErr.Equals(kubeerrors.ErrMetricsUnavailable()) {
+		t.Fatalf("expected metrics unavailable error, got %v", err)
+	}
+}
@@ -5,10 +5,13 @@ import (
 	api_core "k8s.io/api/core/v1"
 	api_resource "k8s.io/apimachinery/pkg/api/resource"
 	api_meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
 )
 
 const (
-	quotaName = "quota"
+	quotaName      = "quota"
+	limitRangeName = "limitrange"
+	ownerLabel     = "owner"
 )
 
 //GetNamespaceList returns namespaces list
@@ -57,7 +60,19 @@ func (k *Kube) GetNamespaceQuota(ns string) (*api_core.ResourceQuota, error) {
 
 //CreateNamespace creates namespace
 func (k *Kube) CreateNamespace(ns *api_core.Namespace) (*api_core.Namespace, error) {
-	nsAfter, err := k.CoreV1().Namespaces().Create(ns)
+	nsAfter := &api_core.Namespace{}
+	err := k.create(k.CoreV1().RESTClient(), "", "namespaces", ns, nsAfter)
+	if err != nil {
+		log.WithField("Namespace", ns.Name).Error(err)
+		return nil, err
+	}
+	return nsAfter, nil
+}
+
+//UpdateNamespace updates namespace
+func (k *Kube) UpdateNamespace(ns *api_core.Namespace) (*api_core.Namespace, error) {
+	nsAfter := &api_core.Namespace{}
+	err := k.update(k.CoreV1().RESTClient(), "", "namespaces", ns, nsAfter)
 	if err != nil {
 		log.WithField("Namespace", ns.Name).Error(err)
 		return nil, err
@@ -67,7 +82,8 @@ func (k *Kube) CreateNamespace(ns *api_core.Namespace) (*api_core.Namespace, err
 
 //CreateNamespaceQuota creates namespace quota
 func (k *Kube) CreateNamespaceQuota(nsName string, quota *api_core.ResourceQuota) (*api_core.ResourceQuota, error) {
-	quotaAfter, err := k.CoreV1().ResourceQuotas(nsName).Create(quota)
+	quotaAfter := &api_core.ResourceQuota{}
+	err := k.create(k.CoreV1().RESTClient(), nsName, "resourcequotas", quota, quotaAfter)
 	if err != nil {
 		log.WithField("Namespace", nsName).Error(err)
 		return nil, err
@@ -75,15 +91,25 @@ func (k *Kube) CreateNamespaceQuota(nsName string, quota *api_core.ResourceQuota
 	return quotaAfter, nil
 }
 
-//CreateNamespaceQuota creates namespace quota
-func (k *Kube) CreateLimitRange(nsName string) error {
-	_, err := k.CoreV1().LimitRanges(nsName).Create(&api_core.LimitRange{
+//GetLimitRange returns namespace default container limits
+func (k *Kube) GetLimitRange(nsName string) (*api_core.LimitRange, error) {
+	limitRange, err := k.CoreV1().LimitRanges(nsName).Get(limitRangeName, api_meta.GetOptions{})
+	if err != nil {
+		log.WithField("Namespace", nsName).Error(err)
+		return nil, err
+	}
+	return limitRange, nil
+}
+
+//MakeLimitRange returns default container limits which are created in every namespace
+func MakeLimitRange(nsName string) *api_core.LimitRange {
+	return &api_core.LimitRange{
 		TypeMeta: api_meta.TypeMeta{
 			Kind:       "LimitRange",
 			APIVersion: "v1",
 		},
 		ObjectMeta: api_meta.ObjectMeta{
-			Name:      "limitrange",
+			Name:      limitRangeName,
 			Namespace: nsName,
 		},
 		Spec: api_core.LimitRangeSpec{
@@ -101,7 +127,12 @@ func (k *Kube) CreateLimitRange(nsName string) error {
 				},
 			},
 		},
-	})
+	}
+}
+
+//CreateLimitRange creates namespace default container limits
+func (k *Kube) CreateLimitRange(nsName string) error {
+	err := k.create(k.CoreV1().RESTClient(), nsName, "limitranges", MakeLimitRange(nsName), &api_core.LimitRange{})
 	if err != nil {
 		log.WithField("Namespace", nsName).Error(err)
 		return err
@@ -109,9 +140,20 @@ func (k *Kube) CreateLimitRange(nsName string) error {
 	return nil
 }
 
+//UpdateLimitRange updates namespace default container limits
+func (k *Kube) UpdateLimitRange(limitRange *api_core.LimitRange) error {
+	err := k.update(k.CoreV1().RESTClient(), limitRange.Namespace, "limitranges", limitRange, &api_core.LimitRange{})
+	if err != nil {
+		log.WithField("Namespace", limitRange.Namespace).Error(err)
+		return err
+	}
+	return nil
+}
+
 //UpdateNamespaceQuota updates namespace quota
 func (k *Kube) UpdateNamespaceQuota(nsName string, quota *api_core.ResourceQuota) (*api_core.ResourceQuota, error) {
-	quotaAfter, err := k.CoreV1().ResourceQuotas(nsName).Update(quota)
+	quotaAfter := &api_core.ResourceQuota{}
+	err := k.update(k.CoreV1().RESTClient(), nsName, "resourcequotas", quota, quotaAfter)
 	if err != nil {
 		log.WithField("Namespace", nsName).Error(err)
 		return nil, err
@@ -119,12 +161,44 @@ func (k *Kube) UpdateNamespaceQuota(nsName string, quota *api_core.ResourceQuota
 	return quotaAfter, nil
 }
 
+//DeleteNamespaceQuota deletes namespace quota
+func (k *Kube) DeleteNamespaceQuota(nsName string) error {
+	err := k.delete(k.CoreV1().RESTClient(), nsName, "resourcequotas", quotaName)
+	if err != nil {
+		log.WithField("Namespace", nsName).Error(err)
+		return err
+	}
+	return nil
+}
+
 //DeleteNamespace deletes namespace
 func (k *Kube) DeleteNamespace(nsName string) error {
-	err := k.CoreV1().Namespaces().Delete(nsName, &api_meta.DeleteOptions{})
+	err := k.delete(k.CoreV1().RESTClient(), "", "namespaces", nsName)
 	if err != nil {
 		log.WithField("Namespace", nsName).Error(err)
 		return err
 	}
 	return nil
 }
+
+//WatchNamespaces watches namespaces created by kube-api
+func (k *Kube) WatchNamespaces(resourceVersion string) (watch.Interface, error) {
+	return k.CoreV1().Namespaces().Watch(api_meta.ListOptions{
+		LabelSelector:   ownerLabel,
+		ResourceVersion: resourceVersion,
+	})
+}
+
+//WatchNamespaceQuotas watches resource quotas of all namespaces
+func (k *Kube) WatchNamespaceQuotas(resourceVersion string) (watch.Interface, error) {
+	return k.CoreV1().ResourceQuotas("").Watch(api_meta.ListOptions{
+		ResourceVersion: resourceVersion,
+	})
+}
+
+//WatchLimitRanges watches limit ranges of all namespaces
+func (k *Kube) WatchLimitRanges(resourceVersion string) (watch.Interface, error) {
+	return k.CoreV1().LimitRanges("").Watch(api_meta.ListOptions{
+		ResourceVersion: resourceVersion,
+	})
+}
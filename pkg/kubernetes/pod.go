@@ -2,13 +2,18 @@ package kubernetes
 
 import (
 	"io"
+	"io/ioutil"
 	"net/http"
+	"time"
 
 	"git.containerum.net/ch/kube-api/pkg/kubeerrors"
 	log "github.com/sirupsen/logrus"
 	"k8s.io/api/core/v1"
 	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/remotecommand"
+	"k8s.io/client-go/transport/spdy"
+	utilexec "k8s.io/client-go/util/exec"
 	"k8s.io/kubernetes/pkg/api/legacyscheme"
 )
 
@@ -27,6 +32,11 @@ type ExecOptions struct {
 	Stdout, Stderr    io.Writer
 	TTY               bool
 	TerminalSizeQueue remotecommand.TerminalSizeQueue
+	// Timeout closes exec stream if command is still running. Zero means no timeout.
+	// Command itself is not killed, it keeps running in container after stream is closed.
+	Timeout time.Duration
+	// Stop closes stream when closed. Nil means stream is closed only by remote side or timeout.
+	Stop <-chan struct{}
 }
 
 //GetPodList returns pods list
@@ -73,7 +83,7 @@ func (k *Kube) GetPodListByDeployment(ns string, deploy string) (interface{}, er
 
 //DeletePod deletes pod
 func (k *Kube) DeletePod(ns string, po string) error {
-	err := k.CoreV1().Pods(ns).Delete(po, &meta_v1.DeleteOptions{})
+	err := k.delete(k.CoreV1().RESTClient(), ns, "pods", po)
 	if err != nil {
 		log.WithFields(log.Fields{
 			"Namespace": ns,
@@ -129,15 +139,105 @@ func (k *Kube) Exec(ns string, po string, opt *ExecOptions) error {
 		TTY:       opt.TTY,
 	}, legacyscheme.ParameterCodec)
 
-	executor, err := remotecommand.NewSPDYExecutor(k.config, http.MethodPost, req.URL())
+	return k.stream(req, opt)
+}
+
+//Attach attaches to main process of pod container
+func (k *Kube) Attach(ns string, po string, opt *ExecOptions) error {
+	// logic taken from "kubectl attach" command
+	pod, err := k.CoreV1().Pods(ns).Get(po, meta_v1.GetOptions{})
 	if err != nil {
 		return err
 	}
-	return executor.Stream(remotecommand.StreamOptions{
+
+	if pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed {
+		return kubeerrors.ErrRequestValidationFailed().
+			AddDetailF("cannot attach a container in a completed pod; current phase is %s", pod.Status.Phase)
+	}
+
+	var container *v1.Container
+	for i := range pod.Spec.Containers {
+		if opt.Container == "" || pod.Spec.Containers[i].Name == opt.Container {
+			container = &pod.Spec.Containers[i]
+			break
+		}
+	}
+	if container == nil {
+		return kubeerrors.ErrResourceNotExist().AddDetailF("container %s is not found in pod %s", opt.Container, po)
+	}
+
+	streamOpt := *opt
+	if !container.Stdin {
+		// container process doesn't read stdin, but caller may still write to it,
+		// so discard input until caller closes it to not block writer
+		if opt.Stdin != nil {
+			go io.Copy(ioutil.Discard, opt.Stdin)
+		}
+		streamOpt.Stdin = nil
+	}
+	if !container.TTY {
+		streamOpt.TTY = false
+		streamOpt.TerminalSizeQueue = nil
+	}
+
+	req := k.RESTClient().
+		Post().
+		Resource("pods").
+		Name(pod.Name).
+		Namespace(pod.Namespace).
+		SubResource("attach")
+	req.VersionedParams(&v1.PodAttachOptions{
+		Container: container.Name,
+		Stdin:     streamOpt.Stdin != nil,
+		Stdout:    streamOpt.Stdout != nil,
+		Stderr:    streamOpt.Stderr != nil,
+		TTY:       streamOpt.TTY,
+	}, legacyscheme.ParameterCodec)
+
+	return k.stream(req, &streamOpt)
+}
+
+// stream runs remote command stream (exec or attach) over SPDY connection
+func (k *Kube) stream(req *rest.Request, opt *ExecOptions) error {
+	transport, upgrader, err := spdy.RoundTripperFor(k.config)
+	if err != nil {
+		return err
+	}
+	connUpgrader := &closableUpgrader{Upgrader: upgrader}
+
+	executor, err := remotecommand.NewSPDYExecutorForTransports(transport, connUpgrader, http.MethodPost, req.URL())
+	if err != nil {
+		return err
+	}
+
+	var timer *time.Timer
+	if opt.Timeout > 0 {
+		timer = time.AfterFunc(opt.Timeout, connUpgrader.Close)
+	}
+	if opt.Stop != nil {
+		finished := make(chan struct{})
+		defer close(finished)
+		go func() {
+			select {
+			case <-opt.Stop:
+				connUpgrader.Close()
+			case <-finished:
+			}
+		}()
+	}
+
+	err = executor.Stream(remotecommand.StreamOptions{
 		Stdin:             opt.Stdin,
 		Stdout:            opt.Stdout,
 		Stderr:            opt.Stderr,
 		Tty:               opt.TTY,
 		TerminalSizeQueue: opt.TerminalSizeQueue,
 	})
+	if timer != nil && !timer.Stop() {
+		// command could exit before timer closed connection
+		if _, isExitErr := err.(utilexec.ExitError); err != nil && !isExitErr {
+			return kubeerrors.ErrExecTimeout().AddDetailF("command was running longer than %v", opt.Timeout)
+		}
+	}
+	return err
 }
@@ -0,0 +1,137 @@
+package kubernetes
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"git.containerum.net/ch/kube-api/pkg/kubeerrors"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	"k8s.io/client-go/transport/spdy"
+)
+
+const portForwardProtocolV1 = "portforward.k8s.io"
+
+// PortForwardStream -- pair of streams opened for one forwarded port
+type PortForwardStream struct {
+	// Data is used to exchange data with pod port
+	Data httpstream.Stream
+	// Error returns error message from kubelet, if any. It is closed with Data stream.
+	Error io.Reader
+}
+
+// PortForwardConn -- SPDY connection to pod with opened port streams
+type PortForwardConn struct {
+	conn      httpstream.Connection
+	closeOnce sync.Once
+
+	// Streams contains opened streams by pod port
+	Streams map[int]*PortForwardStream
+}
+
+// Close closes all port streams and connection
+func (fwd *PortForwardConn) Close() error {
+	var err error
+	fwd.closeOnce.Do(func() {
+		for _, stream := range fwd.Streams {
+			stream.Data.Reset()
+		}
+		err = fwd.conn.Close()
+	})
+	return err
+}
+
+// CloseNotify returns channel which is closed when connection to pod is closed
+func (fwd *PortForwardConn) CloseNotify() <-chan bool {
+	return fwd.conn.CloseChan()
+}
+
+//PortForward opens streams to specified pod ports
+func (k *Kube) PortForward(ns string, po string, ports []int) (*PortForwardConn, error) {
+	// logic taken from "kubectl port-forward" command
+	pod, err := k.CoreV1().Pods(ns).Get(po, meta_v1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	if pod.Status.Phase != v1.PodRunning {
+		return nil, kubeerrors.ErrRequestValidationFailed().
+			AddDetailF("unable to forward port because pod is not running; current phase is %s", pod.Status.Phase)
+	}
+
+	req := k.RESTClient().
+		Post().
+		Resource("pods").
+		Name(pod.Name).
+		Namespace(pod.Namespace).
+		SubResource("portforward")
+
+	transport, upgrader, err := spdy.RoundTripperFor(k.config)
+	if err != nil {
+		return nil, err
+	}
+
+	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: transport}, http.MethodPost, req.URL())
+	conn, protocol, err := dialer.Dial(portForwardProtocolV1)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"Namespace": ns,
+			"Pod":       po,
+		}).Error(err)
+		return nil, err
+	}
+	if protocol != portForwardProtocolV1 {
+		conn.Close()
+		return nil, kubeerrors.ErrRequestValidationFailed().
+			AddDetailF("unable to negotiate protocol: server returned %q", protocol)
+	}
+
+	fwd := &PortForwardConn{
+		conn:    conn,
+		Streams: make(map[int]*PortForwardStream, len(ports)),
+	}
+	for requestID, port := range ports {
+		stream, err := fwd.openStream(port, requestID)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"Namespace": ns,
+				"Pod":       po,
+				"Port":      port,
+			}).Error(err)
+			fwd.Close()
+			return nil, err
+		}
+		fwd.Streams[port] = stream
+	}
+
+	return fwd, nil
+}
+
+func (fwd *PortForwardConn) openStream(port, requestID int) (*PortForwardStream, error) {
+	headers := http.Header{}
+	headers.Set(v1.StreamType, v1.StreamTypeError)
+	headers.Set(v1.PortHeader, strconv.Itoa(port))
+	headers.Set(v1.PortForwardRequestIDHeader, strconv.Itoa(requestID))
+	errorStream, err := fwd.conn.CreateStream(headers)
+	if err != nil {
+		return nil, err
+	}
+	// we're not writing to this stream
+	errorStream.Close()
+
+	headers.Set(v1.StreamType, v1.StreamTypeData)
+	dataStream, err := fwd.conn.CreateStream(headers)
+	if err != nil {
+		errorStream.Reset()
+		return nil, err
+	}
+
+	return &PortForwardStream{
+		Data:  dataStream,
+		Error: errorStream,
+	}, nil
+}
@@ -6,7 +6,8 @@ import (
 	api_meta "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
-//GetTLSSecretList returns TLS secrets list
+//GetTLSSecretList returns TLS secrets list.
+//Secrets created before TLS validation have Opaque type, so they are returned too.
 func (k *Kube) GetTLSSecretList(nsName string) (*api_core.SecretList, error) {
 	secrets, err := k.CoreV1().Secrets(nsName).List(api_meta.ListOptions{FieldSelector: "type=Opaque"})
 	if err != nil {
@@ -15,6 +16,14 @@ func (k *Kube) GetTLSSecretList(nsName string) (*api_core.SecretList, error) {
 		}).Error(err)
 		return nil, err
 	}
+	tlsSecrets, err := k.CoreV1().Secrets(nsName).List(api_meta.ListOptions{FieldSelector: "type=" + string(api_core.SecretTypeTLS)})
+	if err != nil {
+		log.WithFields(log.Fields{
+			"Namespace": nsName,
+		}).Error(err)
+		return nil, err
+	}
+	secrets.Items = append(secrets.Items, tlsSecrets.Items...)
 	return secrets, nil
 }
 
@@ -30,6 +39,23 @@ func (k *Kube) GetDockerSecretList(nsName string) (*api_core.SecretList, error)
 	return secrets, nil
 }
 
+//GetSecretList returns secrets list of type, secrets of all types are returned if type is empty
+func (k *Kube) GetSecretList(nsName string, secretType api_core.SecretType) (*api_core.SecretList, error) {
+	opts := api_meta.ListOptions{}
+	if secretType != "" {
+		opts.FieldSelector = "type=" + string(secretType)
+	}
+	secrets, err := k.CoreV1().Secrets(nsName).List(opts)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"Namespace": nsName,
+			"Type":      secretType,
+		}).Error(err)
+		return nil, err
+	}
+	return secrets, nil
+}
+
 //GetSecret returns secret
 func (k *Kube) GetSecret(nsName string, secretName string) (*api_core.Secret, error) {
 	secret, err := k.CoreV1().Secrets(nsName).Get(secretName, api_meta.GetOptions{})
@@ -45,7 +71,8 @@ func (k *Kube) GetSecret(nsName string, secretName string) (*api_core.Secret, er
 
 //CreateSecret creates secret
 func (k *Kube) CreateSecret(secret *api_core.Secret) (*api_core.Secret, error) {
-	newSecret, err := k.CoreV1().Secrets(secret.Namespace).Create(secret)
+	newSecret := &api_core.Secret{}
+	err := k.create(k.CoreV1().RESTClient(), secret.Namespace, "secrets", secret, newSecret)
 	if err != nil {
 		log.WithFields(log.Fields{
 			"Namespace": secret.Namespace,
@@ -58,7 +85,8 @@ func (k *Kube) CreateSecret(secret *api_core.Secret) (*api_core.Secret, error) {
 
 //UpdateSecret updates secret
 func (k *Kube) UpdateSecret(secret *api_core.Secret) (*api_core.Secret, error) {
-	newSecret, err := k.CoreV1().Secrets(secret.Namespace).Update(secret)
+	newSecret := &api_core.Secret{}
+	err := k.update(k.CoreV1().RESTClient(), secret.Namespace, "secrets", secret, newSecret)
 	if err != nil {
 		log.WithFields(log.Fields{
 			"Namespace": secret.Namespace,
@@ -71,7 +99,7 @@ func (k *Kube) UpdateSecret(secret *api_core.Secret) (*api_core.Secret, error) {
 
 //DeleteSecret deletes secret
 func (k *Kube) DeleteSecret(nsName string, secretName string) error {
-	err := k.CoreV1().Secrets(nsName).Delete(secretName, &api_meta.DeleteOptions{})
+	err := k.delete(k.CoreV1().RESTClient(), nsName, "secrets", secretName)
 	if err != nil {
 		log.WithFields(log.Fields{
 			"Namespace": nsName,
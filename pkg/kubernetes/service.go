@@ -45,7 +45,8 @@ func (k *Kube) GetService(namespace, serviceName string) (*api_core.Service, err
 
 //CreateService creates service
 func (k *Kube) CreateService(svc *api_core.Service) (*api_core.Service, error) {
-	svcAfter, err := k.CoreV1().Services(svc.ObjectMeta.Namespace).Create(svc)
+	svcAfter := &api_core.Service{}
+	err := k.create(k.CoreV1().RESTClient(), svc.Namespace, "services", svc, svcAfter)
 	if err != nil {
 		log.WithFields(log.Fields{
 			"Namespace": svc.Namespace,
@@ -58,9 +59,8 @@ func (k *Kube) CreateService(svc *api_core.Service) (*api_core.Service, error) {
 
 //UpdateService updates service
 func (k *Kube) UpdateService(service *api_core.Service) (*api_core.Service, error) {
-	newService, err := k.CoreV1().
-		Services(service.ObjectMeta.Namespace).
-		Update(service)
+	newService := &api_core.Service{}
+	err := k.update(k.CoreV1().RESTClient(), service.Namespace, "services", service, newService)
 	if err != nil {
 		log.WithFields(log.Fields{
 			"Namespace": service.Namespace,
@@ -73,8 +73,7 @@ func (k *Kube) UpdateService(service *api_core.Service) (*api_core.Service, erro
 
 //DeleteService deletes service
 func (k *Kube) DeleteService(namespace, serviceName string) error {
-	err := k.CoreV1().Services(namespace).
-		Delete(serviceName, &api_meta.DeleteOptions{})
+	err := k.delete(k.CoreV1().RESTClient(), namespace, "services", serviceName)
 	if err != nil {
 		log.WithFields(log.Fields{
 			"Namespace": namespace,
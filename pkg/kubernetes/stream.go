@@ -0,0 +1,44 @@
+package kubernetes
+
+import (
+	"net/http"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	"k8s.io/client-go/transport/spdy"
+)
+
+// closableUpgrader remembers SPDY connection created by wrapped upgrader,
+// so streaming calls without own cancellation (exec, attach) can be interrupted
+type closableUpgrader struct {
+	spdy.Upgrader
+
+	mutex  sync.Mutex
+	conn   httpstream.Connection
+	closed bool
+}
+
+func (u *closableUpgrader) NewConnection(resp *http.Response) (httpstream.Connection, error) {
+	conn, err := u.Upgrader.NewConnection(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+	u.conn = conn
+	if u.closed {
+		conn.Close()
+	}
+	return conn, nil
+}
+
+// Close closes current connection. Connection created after this call will be closed immediately.
+func (u *closableUpgrader) Close() {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+	u.closed = true
+	if u.conn != nil {
+		u.conn.Close()
+	}
+}
@@ -36,7 +36,8 @@ func (k *Kube) GetPersistentVolumeClaim(ns string, pvcName string) (*api_core.Pe
 
 //CreatePersistentVolumeClaim creates pvc
 func (k *Kube) CreatePersistentVolumeClaim(pvc *api_core.PersistentVolumeClaim) (*api_core.PersistentVolumeClaim, error) {
-	newpvc, err := k.CoreV1().PersistentVolumeClaims(pvc.Namespace).Create(pvc)
+	newpvc := &api_core.PersistentVolumeClaim{}
+	err := k.create(k.CoreV1().RESTClient(), pvc.Namespace, "persistentvolumeclaims", pvc, newpvc)
 	if err != nil {
 		log.WithFields(log.Fields{
 			"Namespace": pvc.Namespace,
@@ -49,7 +50,8 @@ func (k *Kube) CreatePersistentVolumeClaim(pvc *api_core.PersistentVolumeClaim)
 
 //UpdatePersistentVolumeClaim updates pvc
 func (k *Kube) UpdatePersistentVolumeClaim(pvc *api_core.PersistentVolumeClaim) (*api_core.PersistentVolumeClaim, error) {
-	updpvc, err := k.CoreV1().PersistentVolumeClaims(pvc.Namespace).Update(pvc)
+	updpvc := &api_core.PersistentVolumeClaim{}
+	err := k.update(k.CoreV1().RESTClient(), pvc.Namespace, "persistentvolumeclaims", pvc, updpvc)
 	if err != nil {
 		log.WithFields(log.Fields{
 			"Namespace": pvc.Namespace,
@@ -62,7 +64,7 @@ func (k *Kube) UpdatePersistentVolumeClaim(pvc *api_core.PersistentVolumeClaim)
 
 //DeletePersistentVolumeClaim deletes pvc
 func (k *Kube) DeletePersistentVolumeClaim(ns string, pvc string) error {
-	err := k.CoreV1().PersistentVolumeClaims(ns).Delete(pvc, &meta_v1.DeleteOptions{})
+	err := k.delete(k.CoreV1().RESTClient(), ns, "persistentvolumeclaims", pvc)
 	if err != nil {
 		log.WithFields(log.Fields{
 			"Namespace": ns,
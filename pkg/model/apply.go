@@ -0,0 +1,558 @@
+package model
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+
+	"git.containerum.net/ch/kube-api/pkg/model/networking"
+	"github.com/containerum/cherry"
+	kube_types "github.com/containerum/kube-client/pkg/model"
+	"github.com/ghodss/yaml"
+	api_apps "k8s.io/api/apps/v1"
+	api_core "k8s.io/api/core/v1"
+	api_extensions "k8s.io/api/extensions/v1beta1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	api_resource "k8s.io/apimachinery/pkg/api/resource"
+	api_meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	k8s_yaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+const (
+	ApplyStatusCreated   = "created"
+	ApplyStatusUpdated   = "updated"
+	ApplyStatusUnchanged = "unchanged"
+	ApplyStatusFailed    = "failed"
+	ApplyStatusSkipped   = "skipped"
+)
+
+// ApplyResult -- result of applying single manifest object
+//
+// swagger:model
+type ApplyResult struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+	// created, updated, unchanged, failed or skipped
+	Status string      `json:"status"`
+	Error  *cherry.Err `json:"error,omitempty"`
+}
+
+// ApplyResultList -- results of applying manifest objects in order of appearance
+//
+// swagger:model
+type ApplyResultList struct {
+	Objects []ApplyResult `json:"objects"`
+}
+
+// ManifestObject -- kubernetes object decoded from manifest
+type ManifestObject struct {
+	Kind      string
+	Name      string
+	Namespace string
+	Object    runtime.Object
+}
+
+// DecodeManifests decodes multi-document YAML or JSON to kubernetes objects
+func DecodeManifests(data []byte) ([]ManifestObject, error) {
+	var objects []ManifestObject
+	reader := k8s_yaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(data)))
+	for doc := 1; ; doc++ {
+		raw, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("document %v: %v", doc, err)
+		}
+		jsonDoc, err := yaml.YAMLToJSON(raw)
+		if err != nil {
+			return nil, fmt.Errorf("document %v: %v", doc, err)
+		}
+		if jsonDoc = bytes.TrimSpace(jsonDoc); len(jsonDoc) == 0 || string(jsonDoc) == "null" {
+			// empty document or comments only
+			continue
+		}
+		obj, gvk, err := decodeManifest(jsonDoc)
+		if err != nil {
+			return nil, fmt.Errorf("document %v: %v", doc, err)
+		}
+		objMeta, err := meta.Accessor(obj)
+		if err != nil {
+			return nil, fmt.Errorf("document %v: %v", doc, err)
+		}
+		objects = append(objects, ManifestObject{
+			Kind:      gvk.Kind,
+			Name:      objMeta.GetName(),
+			Namespace: objMeta.GetNamespace(),
+			Object:    obj,
+		})
+	}
+	if len(objects) == 0 {
+		return nil, fmt.Errorf(fieldShouldExist, "objects")
+	}
+	return objects, nil
+}
+
+// decodeManifest decodes single object.
+// Ingresses of every supported Ingress API version are converted to extensions/v1beta1,
+// so manifests exported from clusters serving networking.k8s.io can be applied.
+func decodeManifest(data []byte) (runtime.Object, *schema.GroupVersionKind, error) {
+	var typeMeta api_meta.TypeMeta
+	if err := json.Unmarshal(data, &typeMeta); err != nil {
+		return nil, nil, err
+	}
+	if typeMeta.Kind == ingressKind {
+		for _, groupVersion := range networking.PreferredVersions {
+			if typeMeta.APIVersion == groupVersion {
+				ingress, err := networking.DecodeIngress(data, groupVersion)
+				if err != nil {
+					return nil, nil, err
+				}
+				gvk := typeMeta.GroupVersionKind()
+				return ingress, &gvk, nil
+			}
+		}
+	}
+	return scheme.Codecs.UniversalDeserializer().Decode(data, nil, nil)
+}
+
+// ManifestToKube converts manifest object to kube-api model and makes kubernetes object from it
+// in the same way as create endpoints do, so the same validation and labels are applied.
+// Fields which can't be represented in kube-api model are rejected.
+func ManifestToKube(obj ManifestObject, nsName string, nsLabels map[string]string) (runtime.Object, []error) {
+	if obj.Namespace != "" && obj.Namespace != nsName {
+		return nil, []error{fmt.Errorf(foreignNamespace, obj.Namespace, nsName)}
+	}
+
+	// ToKube adds object labels to map
+	labels := make(map[string]string, len(nsLabels))
+	for k, v := range nsLabels {
+		labels[k] = v
+	}
+
+	switch native := obj.Object.(type) {
+	case *api_apps.Deployment:
+		deploy, errs := manifestToDeployment(native)
+		if errs != nil {
+			return nil, errs
+		}
+		kubeObj, errs := deploy.ToKube(nsName, labels)
+		if errs != nil {
+			return nil, errs
+		}
+		return kubeObj, nil
+	case *api_core.Service:
+		svc, errs := manifestToService(native)
+		if errs != nil {
+			return nil, errs
+		}
+		kubeObj, errs := svc.ToKube(nsName, labels)
+		if errs != nil {
+			return nil, errs
+		}
+		return kubeObj, nil
+	case *api_extensions.Ingress:
+		ingress, errs := manifestToIngress(native)
+		if errs != nil {
+			return nil, errs
+		}
+		kubeObj, errs := ingress.ToKube(nsName, native.Labels[solutionLabel], labels)
+		if errs != nil {
+			return nil, errs
+		}
+		return kubeObj, nil
+	case *api_core.ConfigMap:
+		cm, errs := manifestToConfigMap(native)
+		if errs != nil {
+			return nil, errs
+		}
+		kubeObj, errs := cm.ToKube(nsName, native.Labels[solutionLabel], labels)
+		if errs != nil {
+			return nil, errs
+		}
+		return kubeObj, nil
+	case *api_core.Secret:
+		secret, secretType, errs := manifestToSecret(native)
+		if errs != nil {
+			return nil, errs
+		}
+		kubeObj, errs := secret.ToKube(nsName, native.Labels[solutionLabel], labels, secretType)
+		if errs != nil {
+			return nil, errs
+		}
+		return kubeObj, nil
+	case *api_core.PersistentVolumeClaim:
+		pvc := manifestToVolume(native)
+		kubeObj, errs := pvc.ToKube(nsName, native.Labels[solutionLabel], labels)
+		if errs != nil {
+			return nil, errs
+		}
+		return kubeObj, nil
+	default:
+		gvk := obj.Object.GetObjectKind().GroupVersionKind()
+		return nil, []error{fmt.Errorf(unsupportedKind, gvk.GroupVersion(), gvk.Kind)}
+	}
+}
+
+func manifestToDeployment(native *api_apps.Deployment) (*DeploymentKubeAPI, []error) {
+	var errs []error
+	spec := native.Spec.Template.Spec
+
+	if spec.HostNetwork || spec.HostPID || spec.HostIPC {
+		errs = append(errs, fmt.Errorf(notAllowedField, "host namespaces sharing"))
+	}
+	if len(spec.InitContainers) > 0 {
+		errs = append(errs, fmt.Errorf(unsupportedField, "initContainers"))
+	}
+	if spec.ServiceAccountName != "" && spec.ServiceAccountName != "default" {
+		errs = append(errs, fmt.Errorf(unsupportedField, "serviceAccountName"))
+	}
+	for k, v := range spec.NodeSelector {
+		// node role is set by kube-api
+		if k != nodeRoleLabel || v != nodeRoleSlave {
+			errs = append(errs, fmt.Errorf(unsupportedField, "nodeSelector"))
+			break
+		}
+	}
+	if len(spec.Tolerations) > 0 {
+		errs = append(errs, fmt.Errorf(unsupportedField, "tolerations"))
+	}
+	if spec.Affinity != nil {
+		errs = append(errs, fmt.Errorf(unsupportedField, "affinity"))
+	}
+	if spec.SecurityContext != nil && !reflect.DeepEqual(*spec.SecurityContext, api_core.PodSecurityContext{}) {
+		errs = append(errs, fmt.Errorf(unsupportedField, "securityContext"))
+	}
+
+	claims := make(map[string]string)
+	configMaps := make(map[string]*api_core.ConfigMapVolumeSource)
+	secrets := make(map[string]*api_core.SecretVolumeSource)
+	declared := make(map[string]bool)
+	for _, v := range spec.Volumes {
+		declared[v.Name] = true
+		switch {
+		case v.HostPath != nil:
+			errs = append(errs, fmt.Errorf(notAllowedField, "hostPath volume "+v.Name))
+		case v.PersistentVolumeClaim != nil:
+			claims[v.Name] = v.PersistentVolumeClaim.ClaimName
+		case v.ConfigMap != nil && len(v.ConfigMap.Items) == 0:
+			configMaps[v.Name] = v.ConfigMap
+		case v.ConfigMap != nil:
+			errs = append(errs, fmt.Errorf(unsupportedField, "volumes.configMap.items"))
+		case v.Secret != nil:
+			secrets[v.Name] = v.Secret
+		default:
+			errs = append(errs, fmt.Errorf(unsupportedField, "volume "+v.Name+" type"))
+		}
+	}
+
+	containers := make([]ContainerWithRefs, 0, len(spec.Containers))
+	for _, c := range spec.Containers {
+		if sc := c.SecurityContext; sc != nil {
+			if sc.Privileged != nil && *sc.Privileged {
+				errs = append(errs, fmt.Errorf(notAllowedField, "privileged container "+c.Name))
+			}
+			if sc.AllowPrivilegeEscalation != nil && *sc.AllowPrivilegeEscalation {
+				errs = append(errs, fmt.Errorf(notAllowedField, "privilege escalation in container "+c.Name))
+			}
+			if sc.Capabilities != nil && len(sc.Capabilities.Add) > 0 {
+				errs = append(errs, fmt.Errorf(notAllowedField, "adding capabilities to container "+c.Name))
+			}
+		}
+		if len(c.Args) > 0 && len(c.Command) == 0 {
+			errs = append(errs, fmt.Errorf(unsupportedField, "args without command"))
+		}
+		if c.WorkingDir != "" {
+			errs = append(errs, fmt.Errorf(unsupportedField, "workingDir"))
+		}
+		if c.LivenessProbe != nil {
+			errs = append(errs, fmt.Errorf(unsupportedField, "livenessProbe"))
+		}
+		if c.ReadinessProbe != nil {
+			errs = append(errs, fmt.Errorf(unsupportedField, "readinessProbe"))
+		}
+		if c.Lifecycle != nil {
+			errs = append(errs, fmt.Errorf(unsupportedField, "lifecycle"))
+		}
+		limits := manifestContainerLimits(c.Resources)
+		if !manifestRequestsSupported(c.Resources, limits) {
+			errs = append(errs, fmt.Errorf(unsupportedField, "resources.requests other than derived from limits"))
+		}
+
+		container := ContainerWithRefs{
+			Container: kube_types.Container{
+				Name:     c.Name,
+				Image:    c.Image,
+				Commands: append(append([]string{}, c.Command...), c.Args...),
+				Limits:   limits,
+			},
+		}
+		for _, env := range c.Env {
+			// only secret and config map keys can be referenced
+			if env.ValueFrom != nil && (env.ValueFrom.FieldRef != nil || env.ValueFrom.ResourceFieldRef != nil) {
+				errs = append(errs, fmt.Errorf(unsupportedField, "env.valueFrom of "+env.Name+" other than secret or config map key"))
+			}
+		}
+		container.Env = getEnv(c.Env)
+		container.EnvFrom = getEnvFrom(c.EnvFrom)
+		for _, port := range c.Ports {
+			if port.HostPort != 0 {
+				errs = append(errs, fmt.Errorf(notAllowedField, "hostPort"))
+			}
+			protocol := kube_types.Protocol(port.Protocol)
+			if protocol == "" {
+				protocol = kube_types.TCP
+			}
+			container.Ports = append(container.Ports, kube_types.ContainerPort{
+				Name:     port.Name,
+				Port:     int(port.ContainerPort),
+				Protocol: protocol,
+			})
+		}
+		for _, mount := range c.VolumeMounts {
+			volume := kube_types.ContainerVolume{MountPath: mount.MountPath}
+			if mount.SubPath != "" {
+				subPath := mount.SubPath
+				volume.SubPath = &subPath
+			}
+			if claim, isClaim := claims[mount.Name]; isClaim {
+				volume.Name = claim
+				container.VolumeMounts = append(container.VolumeMounts, volume)
+			} else if cm, isConfigMap := configMaps[mount.Name]; isConfigMap {
+				volume.Name = cm.Name
+				if cm.DefaultMode != nil {
+					mode := strconv.FormatInt(int64(*cm.DefaultMode), 8)
+					volume.Mode = &mode
+				}
+				container.ConfigMaps = append(container.ConfigMaps, volume)
+			} else if secret, isSecret := secrets[mount.Name]; isSecret {
+				container.Secrets = append(container.Secrets, getSecretMount(mount, secret))
+			} else if !declared[mount.Name] {
+				errs = append(errs, fmt.Errorf(unknownVolume, mount.Name))
+			}
+		}
+		containers = append(containers, container)
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	replicas := 1
+	if native.Spec.Replicas != nil {
+		replicas = int(*native.Spec.Replicas)
+	}
+
+	return &DeploymentKubeAPI{
+		Deployment: kube_types.Deployment{
+			Name:             native.Name,
+			Replicas:         replicas,
+			ImagePullSecrets: getImagePullSecrets(spec.ImagePullSecrets),
+			SolutionID:       native.Labels[solutionLabel],
+		},
+		Containers: containers,
+	}, nil
+}
+
+// manifestContainerLimits returns container limits, requests are used if limits are not set
+func manifestContainerLimits(res api_core.ResourceRequirements) kube_types.Resource {
+	get := func(name api_core.ResourceName) api_resource.Quantity {
+		if value, ok := res.Limits[name]; ok {
+			return value
+		}
+		return res.Requests[name]
+	}
+	cpu := get(api_core.ResourceCPU)
+	mem := get(api_core.ResourceMemory)
+	return kube_types.Resource{
+		CPU:    uint(cpu.ScaledValue(api_resource.Milli)),
+		Memory: uint(mem.Value() / 1024 / 1024),
+	}
+}
+
+// manifestRequestsSupported checks that container requests are not set or equal to ones set by kube-api for limits.
+// Requests are used as limits if limits are not set.
+func manifestRequestsSupported(res api_core.ResourceRequirements, limits kube_types.Resource) bool {
+	if len(res.Limits) == 0 {
+		return true
+	}
+	expected := makeContainerResourceQuota(limits.CPU, limits.Memory).Requests
+	for name, value := range res.Requests {
+		if expectedValue, ok := expected[name]; !ok || value.Cmp(expectedValue) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func manifestToService(native *api_core.Service) (*ServiceWithParam, []error) {
+	var errs []error
+	if native.Spec.Type != "" && native.Spec.Type != api_core.ServiceTypeClusterIP {
+		errs = append(errs, fmt.Errorf(unsupportedField, "type "+string(native.Spec.Type)))
+	}
+	if len(native.Spec.ExternalIPs) > 0 {
+		errs = append(errs, fmt.Errorf(notAllowedField, "externalIPs"))
+	}
+	deploy := native.Spec.Selector[appLabel]
+	if len(native.Spec.Selector) != 1 || deploy == "" {
+		errs = append(errs, fmt.Errorf(unsupportedField, "selector other than '"+appLabel+"' label"))
+	}
+
+	ports := make([]kube_types.ServicePort, 0, len(native.Spec.Ports))
+	for _, p := range native.Spec.Ports {
+		if p.TargetPort.Type == intstr.String {
+			errs = append(errs, fmt.Errorf(unsupportedField, "named targetPort"))
+			continue
+		}
+		port := int(p.Port)
+		targetPort := p.TargetPort.IntValue()
+		if targetPort == 0 {
+			targetPort = port
+		}
+		protocol := kube_types.Protocol(p.Protocol)
+		if protocol == "" {
+			protocol = kube_types.TCP
+		}
+		ports = append(ports, kube_types.ServicePort{
+			Name:       p.Name,
+			Port:       &port,
+			TargetPort: targetPort,
+			Protocol:   protocol,
+		})
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return &ServiceWithParam{
+		Service: &kube_types.Service{
+			Name:       native.Name,
+			Deploy:     deploy,
+			Ports:      ports,
+			SolutionID: native.Labels[solutionLabel],
+		},
+	}, nil
+}
+
+func manifestToIngress(native *api_extensions.Ingress) (*IngressKubeAPI, []error) {
+	var errs []error
+	if native.Spec.Backend != nil {
+		errs = append(errs, fmt.Errorf(unsupportedField, "default backend"))
+	}
+	// ingress class and TLS annotations are set by kube-api, options are translated back to annotations
+	options, raw := parseIngressAnnotations(native.Annotations)
+	for _, k := range sortedKeys(raw) {
+		errs = append(errs, fmt.Errorf(unsupportedField, "annotation "+k))
+	}
+
+	tlsSecrets := make(map[string]string)
+	for _, tls := range native.Spec.TLS {
+		for _, host := range tls.Hosts {
+			tlsSecrets[host] = tls.SecretName
+		}
+	}
+
+	rules := make([]kube_types.Rule, 0, len(native.Spec.Rules))
+	for _, r := range native.Spec.Rules {
+		if r.HTTP == nil {
+			errs = append(errs, fmt.Errorf(fieldShouldExist, "rules.http"))
+			continue
+		}
+		rule := kube_types.Rule{Host: r.Host}
+		if secret, hasTLS := tlsSecrets[r.Host]; hasTLS {
+			rule.TLSSecret = &secret
+		}
+		for _, p := range r.HTTP.Paths {
+			if p.Backend.ServicePort.Type == intstr.String {
+				errs = append(errs, fmt.Errorf(unsupportedField, "named servicePort"))
+				continue
+			}
+			rule.Path = append(rule.Path, kube_types.Path{
+				Path:        p.Path,
+				ServiceName: p.Backend.ServiceName,
+				ServicePort: p.Backend.ServicePort.IntValue(),
+			})
+		}
+		rules = append(rules, rule)
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return &IngressKubeAPI{
+		Ingress: kube_types.Ingress{
+			Name:  native.Name,
+			Rules: rules,
+		},
+		Options: options,
+	}, nil
+}
+
+func manifestToConfigMap(native *api_core.ConfigMap) (*ConfigMapKubeAPI, []error) {
+	// ToKube expects base64 encoded values
+	data := make(kube_types.ConfigMapData, len(native.Data))
+	for k, v := range native.Data {
+		data[k] = base64.StdEncoding.EncodeToString([]byte(v))
+	}
+	var binaryData kube_types.ConfigMapData
+	if len(native.BinaryData) > 0 {
+		binaryData = make(kube_types.ConfigMapData, len(native.BinaryData))
+		for k, v := range native.BinaryData {
+			binaryData[k] = base64.StdEncoding.EncodeToString(v)
+		}
+	}
+	return &ConfigMapKubeAPI{
+		ConfigMap: kube_types.ConfigMap{
+			Name: native.Name,
+			Data: data,
+		},
+		BinaryData: binaryData,
+	}, nil
+}
+
+func manifestToSecret(native *api_core.Secret) (*SecretKubeAPI, api_core.SecretType, []error) {
+	secretType := native.Type
+	switch secretType {
+	case "":
+		secretType = api_core.SecretTypeOpaque
+	case api_core.SecretTypeOpaque, api_core.SecretTypeTLS, api_core.SecretTypeDockerConfigJson:
+	default:
+		return nil, "", []error{fmt.Errorf(unsupportedField, "type "+string(secretType))}
+	}
+	data := make(map[string]string, len(native.Data)+len(native.StringData))
+	for k, v := range native.Data {
+		data[k] = string(v)
+	}
+	// string data overrides data like in kubernetes
+	for k, v := range native.StringData {
+		data[k] = v
+	}
+	return &SecretKubeAPI{
+		Name: native.Name,
+		Data: data,
+	}, secretType, nil
+}
+
+func manifestToVolume(native *api_core.PersistentVolumeClaim) *VolumeKubeAPI {
+	storageClass := native.Annotations[api_core.BetaStorageClassAnnotation]
+	if native.Spec.StorageClassName != nil {
+		storageClass = *native.Spec.StorageClassName
+	}
+	storage := native.Spec.Resources.Requests[api_core.ResourceStorage]
+	// capacity is rounded up to Gi
+	const gi = 1024 * 1024 * 1024
+	return &VolumeKubeAPI{
+		Name:        native.Name,
+		Capacity:    uint((storage.Value() + gi - 1) / gi),
+		StorageName: storageClass,
+	}
+}
@@ -0,0 +1,298 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	api_apps "k8s.io/api/apps/v1"
+	api_core "k8s.io/api/core/v1"
+	api_extensions "k8s.io/api/extensions/v1beta1"
+)
+
+const testManifests = `
+# web application
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: web-config
+data:
+  nginx.conf: "worker_processes 1;"
+binaryData:
+  favicon.ico: AAABAA==
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: web-auth
+type: Opaque
+data:
+  user: YWRtaW4=
+stringData:
+  password: secret
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  labels:
+    solution: blog
+spec:
+  replicas: 2
+  selector:
+    matchLabels:
+      app: web
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      nodeSelector:
+        role: slave
+      containers:
+      - name: nginx
+        image: nginx:1.15
+        command: ["nginx"]
+        args: ["-g", "daemon off;"]
+        env:
+        - name: AUTH_USER
+          valueFrom:
+            secretKeyRef:
+              name: web-auth
+              key: user
+        envFrom:
+        - prefix: NGINX_
+          configMapRef:
+            name: web-config
+        ports:
+        - name: http
+          containerPort: 80
+        resources:
+          limits:
+            cpu: 200m
+            memory: 128Mi
+          requests:
+            cpu: 100m
+            memory: 64Mi
+        volumeMounts:
+        - name: config
+          mountPath: /etc/nginx
+        - name: data
+          mountPath: /var/www
+        - name: auth
+          mountPath: /etc/nginx/auth
+      volumes:
+      - name: config
+        configMap:
+          name: web-config
+      - name: data
+        persistentVolumeClaim:
+          claimName: web-data
+      - name: auth
+        secret:
+          secretName: web-auth
+          defaultMode: 0400
+          items:
+          - key: user
+            path: htpasswd
+---
+---
+{"apiVersion": "v1", "kind": "Service", "metadata": {"name": "web"},
+ "spec": {"selector": {"app": "web"}, "ports": [{"name": "http", "port": 80, "targetPort": 8080}]}}
+---
+apiVersion: extensions/v1beta1
+kind: Ingress
+metadata:
+  name: web
+  namespace: ns
+spec:
+  tls:
+  - hosts: [web.example.com]
+    secretName: web-tls
+  rules:
+  - host: web.example.com
+    http:
+      paths:
+      - path: /
+        backend:
+          serviceName: web
+          servicePort: 80
+`
+
+func TestDecodeManifests(t *testing.T) {
+	objects, err := DecodeManifests([]byte(testManifests))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var kinds []string
+	for _, obj := range objects {
+		kinds = append(kinds, obj.Kind+"/"+obj.Name)
+	}
+	if strings.Join(kinds, " ") != "ConfigMap/web-config Secret/web-auth Deployment/web Service/web Ingress/web" {
+		t.Fatalf("unexpected objects: %v", kinds)
+	}
+
+	nsLabels := map[string]string{ownerLabel: "user"}
+	for _, obj := range objects {
+		kubeObj, errs := ManifestToKube(obj, "ns", nsLabels)
+		if errs != nil {
+			t.Fatalf("%v: %v", obj.Kind, errs)
+		}
+		switch native := kubeObj.(type) {
+		case *api_apps.Deployment:
+			spec := native.Spec.Template.Spec
+			if native.Labels[solutionLabel] != "blog" || native.Labels[ownerLabel] != "user" || native.Labels[appLabel] != "web" {
+				t.Errorf("unexpected deployment labels: %v", native.Labels)
+			}
+			if strings.Join(spec.Containers[0].Command, " ") != "nginx -g daemon off;" {
+				t.Errorf("unexpected command: %v", spec.Containers[0].Command)
+			}
+			if len(spec.Volumes) != 3 || spec.Volumes[0].PersistentVolumeClaim.ClaimName != "web-data" || spec.Volumes[1].ConfigMap.Name != "web-config" {
+				t.Fatalf("unexpected volumes: %+v", spec.Volumes)
+			}
+			if secret := spec.Volumes[2].Secret; secret == nil || secret.SecretName != "web-auth" || *secret.DefaultMode != 0400 ||
+				len(secret.Items) != 1 || secret.Items[0].Key != "user" || secret.Items[0].Path != "htpasswd" {
+				t.Errorf("unexpected secret volume: %+v", spec.Volumes[2])
+			}
+			container := spec.Containers[0]
+			if len(container.Env) != 1 || container.Env[0].ValueFrom == nil || container.Env[0].ValueFrom.SecretKeyRef.Name != "web-auth" || container.Env[0].ValueFrom.SecretKeyRef.Key != "user" {
+				t.Errorf("unexpected env: %+v", container.Env)
+			}
+			if len(container.EnvFrom) != 1 || container.EnvFrom[0].Prefix != "NGINX_" || container.EnvFrom[0].ConfigMapRef.Name != "web-config" {
+				t.Errorf("unexpected env from: %+v", container.EnvFrom)
+			}
+		case *api_core.Service:
+			if native.Spec.Ports[0].TargetPort.IntValue() != 8080 || native.Spec.Selector[appLabel] != "web" {
+				t.Errorf("unexpected service spec: %+v", native.Spec)
+			}
+		case *api_extensions.Ingress:
+			if native.Namespace != "ns" || len(native.Spec.TLS) != 1 || native.Spec.TLS[0].SecretName != "web-tls" {
+				t.Errorf("unexpected ingress: %+v", native)
+			}
+		case *api_core.ConfigMap:
+			if native.Data["nginx.conf"] != "worker_processes 1;" || string(native.BinaryData["favicon.ico"]) != "\x00\x00\x01\x00" {
+				t.Errorf("unexpected config map data: %v %v", native.Data, native.BinaryData)
+			}
+		case *api_core.Secret:
+			if native.Type != api_core.SecretTypeOpaque || string(native.Data["user"]) != "admin" || string(native.Data["password"]) != "secret" {
+				t.Errorf("unexpected secret: %v %v", native.Type, native.Data)
+			}
+		}
+	}
+	if _, hasApp := nsLabels[appLabel]; hasApp || len(nsLabels) != 1 {
+		t.Errorf("namespace labels are modified: %v", nsLabels)
+	}
+}
+
+func TestManifestToKubeRejected(t *testing.T) {
+	manifests := `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: agent
+spec:
+  template:
+    spec:
+      hostNetwork: true
+      nodeSelector:
+        disk: ssd
+      tolerations:
+      - operator: Exists
+      securityContext:
+        runAsUser: 0
+      containers:
+      - name: agent
+        image: agent
+        securityContext:
+          privileged: true
+        workingDir: /srv
+        env:
+        - name: NODE
+          valueFrom:
+            fieldRef:
+              fieldPath: spec.nodeName
+        livenessProbe:
+          tcpSocket:
+            port: 80
+        resources:
+          limits:
+            cpu: 200m
+          requests:
+            cpu: 50m
+        volumeMounts:
+        - name: root
+          mountPath: /host
+      volumes:
+      - name: root
+        hostPath:
+          path: /
+---
+apiVersion: extensions/v1beta1
+kind: Ingress
+metadata:
+  name: web
+  annotations:
+    nginx.ingress.kubernetes.io/configuration-snippet: "deny all;"
+spec:
+  rules:
+  - host: web.example.com
+    http:
+      paths:
+      - backend:
+          serviceName: web
+          servicePort: 80
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+  namespace: kube-system
+data:
+  key: value
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: token
+type: kubernetes.io/service-account-token
+data:
+  token: dG9rZW4=
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: pod
+`
+	objects, err := DecodeManifests([]byte(manifests))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := [][]string{
+		{"host namespaces", "hostPath volume root", "privileged container agent", "nodeSelector", "tolerations",
+			"'securityContext'", "workingDir", "env.valueFrom of NODE", "livenessProbe", "resources.requests"},
+		{"annotation nginx.ingress.kubernetes.io/configuration-snippet"},
+		{"kube-system"},
+		{"type kubernetes.io/service-account-token"},
+		{"unsupported kind"},
+	}
+	for i, obj := range objects {
+		_, errs := ManifestToKube(obj, "ns", map[string]string{})
+		var msgs []string
+		for _, err := range errs {
+			msgs = append(msgs, err.Error())
+		}
+		joined := strings.Join(msgs, "; ")
+		for _, substr := range expected[i] {
+			if !strings.Contains(joined, substr) {
+				t.Errorf("%v: expected error about %q, got %q", obj.Kind, substr, joined)
+			}
+		}
+	}
+}
+
+func TestDecodeManifestsInvalid(t *testing.T) {
+	for _, manifests := range []string{"", "# comment only\n---\n", "kind: [", "apiVersion: v1\nkind: Unknown\n"} {
+		if _, err := DecodeManifests([]byte(manifests)); err == nil {
+			t.Errorf("expected error for %q", manifests)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package model
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	api_core "k8s.io/api/core/v1"
+	api_extensions "k8s.io/api/extensions/v1beta1"
+)
+
+const certificateExpired = "certificate expired at %v"
+
+var (
+	ErrNoCertificate     = errors.New("no PEM encoded certificate found")
+	ErrInvalidPrivateKey = errors.New("invalid PEM encoded private key")
+)
+
+// CertificateInfo -- metadata of TLS certificate
+//
+// swagger:model
+type CertificateInfo struct {
+	Subject     string   `json:"subject"`
+	Issuer      string   `json:"issuer"`
+	DNSNames    []string `json:"dns_names,omitempty"`
+	IPAddresses []string `json:"ip_addresses,omitempty"`
+	//not before date in RFC3339 format
+	NotBefore string `json:"not_before"`
+	//not after date in RFC3339 format
+	NotAfter string `json:"not_after"`
+}
+
+// ParseCertificateChain parses PEM encoded certificate chain and returns leaf certificate
+func ParseCertificateChain(certPEM []byte) (*x509.Certificate, error) {
+	var leaf *x509.Certificate
+	for rest := certPEM; ; {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		if leaf == nil {
+			leaf = cert
+		}
+	}
+	if leaf == nil {
+		return nil, ErrNoCertificate
+	}
+	return leaf, nil
+}
+
+// ValidateTLSData checks that TLS secret data contains certificate chain and matching private key
+// and that certificate is not expired at now
+func ValidateTLSData(data map[string]string, now time.Time) []error {
+	var errs []error
+	certPEM, hasCert := data[api_core.TLSCertKey]
+	if !hasCert {
+		errs = append(errs, fmt.Errorf(fieldShouldExist, api_core.TLSCertKey))
+	}
+	keyPEM, hasKey := data[api_core.TLSPrivateKeyKey]
+	if !hasKey {
+		errs = append(errs, fmt.Errorf(fieldShouldExist, api_core.TLSPrivateKeyKey))
+	}
+	if errs != nil {
+		return errs
+	}
+
+	leaf, err := ParseCertificateChain([]byte(certPEM))
+	if err != nil {
+		return []error{fmt.Errorf("%v: %v", api_core.TLSCertKey, err)}
+	}
+	if block, _ := pem.Decode([]byte(keyPEM)); block == nil {
+		return []error{fmt.Errorf("%v: %v", api_core.TLSPrivateKeyKey, ErrInvalidPrivateKey)}
+	}
+	// checks that key is parseable and matches certificate
+	if _, err := tls.X509KeyPair([]byte(certPEM), []byte(keyPEM)); err != nil {
+		errs = append(errs, fmt.Errorf("%v: %v", api_core.TLSPrivateKeyKey, err))
+	}
+	if now.After(leaf.NotAfter) {
+		errs = append(errs, fmt.Errorf(certificateExpired, leaf.NotAfter.UTC().Format(time.RFC3339)))
+	}
+	return errs
+}
+
+// ParseCertificateInfo returns metadata of leaf certificate
+func ParseCertificateInfo(cert *x509.Certificate) *CertificateInfo {
+	info := CertificateInfo{
+		Subject:   cert.Subject.String(),
+		Issuer:    cert.Issuer.String(),
+		DNSNames:  cert.DNSNames,
+		NotBefore: cert.NotBefore.UTC().Format(time.RFC3339),
+		NotAfter:  cert.NotAfter.UTC().Format(time.RFC3339),
+	}
+	for _, ip := range cert.IPAddresses {
+		info.IPAddresses = append(info.IPAddresses, ip.String())
+	}
+	return &info
+}
+
+// CertificateExpiry -- expiry of TLS secret certificate
+//
+// swagger:model
+type CertificateExpiry struct {
+	Namespace string   `json:"namespace"`
+	Secret    string   `json:"secret"`
+	Hosts     []string `json:"hosts"`
+	//not after date in RFC3339 format
+	NotAfter string `json:"not_after"`
+	// seconds left before expiry, negative if certificate is expired
+	ExpiresIn int64 `json:"expires_in"`
+	// ingresses using secret
+	Ingresses []string `json:"ingresses"`
+}
+
+// CertificateExpiryList -- certificates sorted by time to expiry
+//
+// swagger:model
+type CertificateExpiryList struct {
+	Certificates []CertificateExpiry `json:"certificates"`
+}
+
+// ParseCertificateExpiries returns expiry of certificates of TLS secrets and ingresses referencing them.
+// Secrets without valid certificate are skipped.
+func ParseCertificateExpiries(secrets interface{}, ingresses interface{}, now time.Time) []CertificateExpiry {
+	secretList := secrets.(*api_core.SecretList)
+	ingressList := ingresses.(*api_extensions.IngressList)
+
+	// namespace/secret -> ingresses
+	secretIngresses := make(map[string][]string)
+	for _, ingress := range ingressList.Items {
+		for _, tls := range ingress.Spec.TLS {
+			key := ingress.Namespace + "/" + tls.SecretName
+			secretIngresses[key] = append(secretIngresses[key], ingress.Name)
+		}
+	}
+
+	certificates := make([]CertificateExpiry, 0)
+	for _, secret := range secretList.Items {
+		cert, err := ParseCertificateChain(secret.Data[api_core.TLSCertKey])
+		if err != nil {
+			continue
+		}
+		hosts := cert.DNSNames
+		if len(hosts) == 0 && cert.Subject.CommonName != "" {
+			hosts = []string{cert.Subject.CommonName}
+		}
+		usedBy := secretIngresses[secret.Namespace+"/"+secret.Name]
+		if usedBy == nil {
+			usedBy = make([]string, 0)
+		}
+		certificates = append(certificates, CertificateExpiry{
+			Namespace: secret.Namespace,
+			Secret:    secret.Name,
+			Hosts:     hosts,
+			NotAfter:  cert.NotAfter.UTC().Format(time.RFC3339),
+			ExpiresIn: int64(cert.NotAfter.Sub(now) / time.Second),
+			Ingresses: usedBy,
+		})
+	}
+	return certificates
+}
+
+// NewCertificateExpiryList sorts certificates by time to expiry
+func NewCertificateExpiryList(certificates []CertificateExpiry) *CertificateExpiryList {
+	sort.SliceStable(certificates, func(i, j int) bool {
+		if certificates[i].ExpiresIn != certificates[j].ExpiresIn {
+			return certificates[i].ExpiresIn < certificates[j].ExpiresIn
+		}
+		if certificates[i].Namespace != certificates[j].Namespace {
+			return certificates[i].Namespace < certificates[j].Namespace
+		}
+		return certificates[i].Secret < certificates[j].Secret
+	})
+	return &CertificateExpiryList{Certificates: certificates}
+}
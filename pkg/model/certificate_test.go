@@ -0,0 +1,138 @@
+package model
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	kube_types "github.com/containerum/kube-client/pkg/model"
+	api_core "k8s.io/api/core/v1"
+	api_extensions "k8s.io/api/extensions/v1beta1"
+	api_meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func testCertificate(t *testing.T, notAfter time.Time) (certPEM, keyPEM string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "shop.example.com"},
+		DNSNames:     []string{"shop.example.com", "www.shop.example.com"},
+		IPAddresses:  []net.IP{net.ParseIP("10.0.0.1")},
+		NotBefore:    notAfter.Add(-24 * time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM = string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	keyPEM = string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+	return certPEM, keyPEM
+}
+
+func TestTLSSecret(t *testing.T) {
+	certPEM, keyPEM := testCertificate(t, time.Now().Add(time.Hour))
+	secret := SecretKubeAPI{Name: "shop-tls", Data: map[string]string{
+		api_core.TLSCertKey:       certPEM,
+		api_core.TLSPrivateKeyKey: keyPEM,
+	}}
+	native, errs := secret.ToKube("ns", "", map[string]string{}, api_core.SecretTypeTLS)
+	if errs != nil {
+		t.Fatal(errs)
+	}
+
+	parsed, err := ParseKubeSecretWithType(native, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, hasKey := parsed.Data[api_core.TLSPrivateKeyKey]; hasKey {
+		t.Error("private key is returned")
+	}
+	cert := parsed.Certificate
+	if cert == nil || cert.Subject != "CN=shop.example.com" || cert.Issuer != "CN=shop.example.com" ||
+		strings.Join(cert.DNSNames, ",") != "shop.example.com,www.shop.example.com" ||
+		strings.Join(cert.IPAddresses, ",") != "10.0.0.1" || cert.NotAfter == "" || cert.NotBefore == "" {
+		t.Errorf("unexpected certificate info: %+v", cert)
+	}
+}
+
+func TestTLSSecretInvalid(t *testing.T) {
+	certPEM, keyPEM := testCertificate(t, time.Now().Add(time.Hour))
+	_, otherKeyPEM := testCertificate(t, time.Now().Add(time.Hour))
+	expiredCertPEM, expiredKeyPEM := testCertificate(t, time.Now().Add(-time.Hour))
+
+	for name, data := range map[string]map[string]string{
+		"missing key":     {api_core.TLSCertKey: certPEM},
+		"invalid cert":    {api_core.TLSCertKey: "certificate", api_core.TLSPrivateKeyKey: keyPEM},
+		"invalid key":     {api_core.TLSCertKey: certPEM, api_core.TLSPrivateKeyKey: "key"},
+		"mismatched keys": {api_core.TLSCertKey: certPEM, api_core.TLSPrivateKeyKey: otherKeyPEM},
+		"expired":         {api_core.TLSCertKey: expiredCertPEM, api_core.TLSPrivateKeyKey: expiredKeyPEM},
+	} {
+		secret := SecretKubeAPI(kube_types.Secret{Name: "shop-tls", Data: data})
+		if _, errs := secret.ToKube("ns", "", map[string]string{}, api_core.SecretTypeTLS); errs == nil {
+			t.Errorf("%v: expected error", name)
+		}
+	}
+}
+
+func TestCertificateExpiries(t *testing.T) {
+	now := time.Now()
+	soonCert, _ := testCertificate(t, now.Add(time.Hour))
+	laterCert, _ := testCertificate(t, now.Add(48*time.Hour))
+	expiredCert, _ := testCertificate(t, now.Add(-time.Hour))
+
+	secret := func(ns, name, cert string) api_core.Secret {
+		return api_core.Secret{
+			ObjectMeta: api_meta.ObjectMeta{Namespace: ns, Name: name},
+			Data:       map[string][]byte{api_core.TLSCertKey: []byte(cert)},
+		}
+	}
+	secrets := &api_core.SecretList{Items: []api_core.Secret{
+		secret("shop", "later", laterCert),
+		secret("shop", "db", "not a certificate"),
+		secret("blog", "soon", soonCert),
+		secret("shop", "expired", expiredCert),
+	}}
+	ingresses := &api_extensions.IngressList{Items: []api_extensions.Ingress{
+		{
+			ObjectMeta: api_meta.ObjectMeta{Namespace: "shop", Name: "web"},
+			Spec:       api_extensions.IngressSpec{TLS: []api_extensions.IngressTLS{{SecretName: "later"}}},
+		},
+		{
+			// secret with the same name from other namespace
+			ObjectMeta: api_meta.ObjectMeta{Namespace: "blog", Name: "web"},
+			Spec:       api_extensions.IngressSpec{TLS: []api_extensions.IngressTLS{{SecretName: "later"}}},
+		},
+	}}
+
+	list := NewCertificateExpiryList(ParseCertificateExpiries(secrets, ingresses, now))
+	var order []string
+	for _, cert := range list.Certificates {
+		order = append(order, cert.Namespace+"/"+cert.Secret)
+	}
+	if strings.Join(order, " ") != "shop/expired blog/soon shop/later" {
+		t.Fatalf("unexpected order: %v", order)
+	}
+	if list.Certificates[0].ExpiresIn >= 0 {
+		t.Errorf("expired certificate has positive time to expiry: %v", list.Certificates[0].ExpiresIn)
+	}
+	later := list.Certificates[2]
+	if strings.Join(later.Ingresses, ",") != "web" || strings.Join(later.Hosts, ",") != "shop.example.com,www.shop.example.com" {
+		t.Errorf("unexpected certificate: %+v", later)
+	}
+}
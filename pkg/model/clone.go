@@ -0,0 +1,217 @@
+package model
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"git.containerum.net/ch/kube-api/pkg/kubeerrors"
+	api_core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	api_validation "k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	// CloneVolumesEmpty -- volumes are created empty with the same capacity
+	CloneVolumesEmpty = "empty"
+	// CloneVolumesSkip -- volumes are not cloned, deployments using them fail
+	CloneVolumesSkip = "skip"
+)
+
+// NamespaceCloneRequest -- new namespace and options of copying objects to it
+//
+// swagger:model
+type NamespaceCloneRequest struct {
+	// new namespace ID, owner and quota
+	// required: true
+	Namespace NamespaceWithQuota `json:"namespace"`
+	// suffix added to the first label of ingress hosts, "-<namespace ID>" by default
+	HostSuffix string `json:"host_suffix,omitempty"`
+	// "empty" (default) or "skip"
+	Volumes string `json:"volumes,omitempty"`
+}
+
+// NamespaceCloneResult -- created namespace and results of copying objects
+//
+// swagger:model
+type NamespaceCloneResult struct {
+	Namespace NamespaceWithQuota `json:"namespace"`
+	Objects   []ApplyResult      `json:"objects"`
+}
+
+// Validate checks clone options and sets defaults
+func (req *NamespaceCloneRequest) Validate() []error {
+	var errs []error
+	switch req.Volumes {
+	case "":
+		req.Volumes = CloneVolumesEmpty
+	case CloneVolumesEmpty, CloneVolumesSkip:
+	default:
+		errs = append(errs, fmt.Errorf("invalid volumes option %q, expected %q or %q", req.Volumes, CloneVolumesEmpty, CloneVolumesSkip))
+	}
+	if req.HostSuffix == "" {
+		req.HostSuffix = "-" + req.Namespace.ID
+	}
+	if err := api_validation.IsDNS1123Label("host" + req.HostSuffix); len(err) > 0 {
+		errs = append(errs, fmt.Errorf(invalidName, req.HostSuffix, strings.Join(err, ",")))
+	}
+	return errs
+}
+
+// CloneNamespaceObjects makes objects of new namespace from source namespace objects
+// using the same converters as get and create endpoints.
+// External IPs of services are removed, ingress hosts get suffix and volumes are created empty or skipped.
+// Objects are returned in order of creation, objects which can't be cloned are returned as failed or skipped results.
+func CloneNamespaceObjects(objects interface{}, nsName string, nsLabels map[string]string, req NamespaceCloneRequest) ([]ManifestObject, []ApplyResult) {
+	objs := objects.(*NamespaceObjects)
+
+	var cloned []ManifestObject
+	var results []ApplyResult
+	add := func(kind, name string, makeObj func(labels map[string]string) (runtime.Object, []error)) {
+		// ToKube adds object labels to map
+		labels := make(map[string]string, len(nsLabels))
+		for k, v := range nsLabels {
+			labels[k] = v
+		}
+		obj, errs := makeObj(labels)
+		if errs != nil {
+			results = append(results, ApplyResult{
+				Kind:   kind,
+				Name:   name,
+				Status: ApplyStatusFailed,
+				Error:  kubeerrors.ErrRequestValidationFailed().AddDetailsErr(errs...),
+			})
+			return
+		}
+		cloned = append(cloned, ManifestObject{
+			Kind:      kind,
+			Name:      name,
+			Namespace: nsName,
+			Object:    obj,
+		})
+	}
+	skip := func(kind, name string) {
+		results = append(results, ApplyResult{Kind: kind, Name: name, Status: ApplyStatusSkipped})
+	}
+
+	for i := range objs.PersistentVolumeClaims.Items {
+		native := &objs.PersistentVolumeClaims.Items[i]
+		if req.Volumes == CloneVolumesSkip {
+			skip(pvcKind, native.Name)
+			continue
+		}
+		add(pvcKind, native.Name, func(labels map[string]string) (runtime.Object, []error) {
+			volume, err := ParseKubePersistentVolumeClaim(native, false)
+			if err != nil {
+				return nil, []error{err}
+			}
+			pvc := VolumeKubeAPI(*volume)
+			obj, errs := pvc.ToKube(nsName, native.Labels[solutionLabel], labels)
+			if errs != nil {
+				return nil, errs
+			}
+			return obj, nil
+		})
+	}
+	for i := range objs.ConfigMaps.Items {
+		native := &objs.ConfigMaps.Items[i]
+		add(configMapKind, native.Name, func(labels map[string]string) (runtime.Object, []error) {
+			parsed, err := ParseKubeConfigMap(native, false)
+			if err != nil {
+				return nil, []error{err}
+			}
+			cm := ConfigMapKubeAPI(*parsed)
+			// ToKube expects base64 encoded values
+			for k, v := range cm.Data {
+				cm.Data[k] = base64.StdEncoding.EncodeToString([]byte(v))
+			}
+			obj, errs := cm.ToKube(nsName, native.Labels[solutionLabel], labels)
+			if errs != nil {
+				return nil, errs
+			}
+			return obj, nil
+		})
+	}
+	if objs.Secrets != nil {
+		for i := range objs.Secrets.Items {
+			native := &objs.Secrets.Items[i]
+			if native.Type == api_core.SecretTypeServiceAccountToken {
+				// created by token controller in new namespace
+				continue
+			}
+			add(secretKind, native.Name, func(labels map[string]string) (runtime.Object, []error) {
+				parsed, err := ParseKubeSecret(native, false)
+				if err != nil {
+					return nil, []error{err}
+				}
+				secret := SecretKubeAPI(*parsed)
+				obj, errs := secret.ToKube(nsName, native.Labels[solutionLabel], labels, native.Type)
+				if errs != nil {
+					return nil, errs
+				}
+				return obj, nil
+			})
+		}
+	}
+	for i := range objs.Deployments.Items {
+		native := &objs.Deployments.Items[i]
+		add(deploymentKind, native.Name, func(labels map[string]string) (runtime.Object, []error) {
+			parsed, err := ParseKubeDeployment(native, false)
+			if err != nil {
+				return nil, []error{err}
+			}
+			deploy := DeploymentKubeAPI(*parsed)
+			obj, errs := deploy.ToKube(nsName, labels)
+			if errs != nil {
+				return nil, errs
+			}
+			return obj, nil
+		})
+	}
+	for i := range objs.Services.Items {
+		native := &objs.Services.Items[i]
+		add(serviceKind, native.Name, func(labels map[string]string) (runtime.Object, []error) {
+			svc, err := ParseKubeService(native, false)
+			if err != nil {
+				return nil, []error{err}
+			}
+			// external IPs and domains are bound to source namespace
+			svc.IPs = nil
+			svc.Domain = ""
+			obj, errs := svc.ToKube(nsName, labels)
+			if errs != nil {
+				return nil, errs
+			}
+			return obj, nil
+		})
+	}
+	for i := range objs.Ingresses.Items {
+		native := &objs.Ingresses.Items[i]
+		add(ingressKind, native.Name, func(labels map[string]string) (runtime.Object, []error) {
+			parsed, err := ParseKubeIngress(native, false)
+			if err != nil {
+				return nil, []error{err}
+			}
+			ingress := IngressKubeAPI(*parsed)
+			for j := range ingress.Rules {
+				ingress.Rules[j].Host = addHostSuffix(ingress.Rules[j].Host, req.HostSuffix)
+			}
+			obj, errs := ingress.ToKube(nsName, native.Labels[solutionLabel], labels)
+			if errs != nil {
+				return nil, errs
+			}
+			return obj, nil
+		})
+	}
+	return cloned, results
+}
+
+// addHostSuffix adds suffix to the first label of host, so host stays in the same domain
+func addHostSuffix(host, suffix string) string {
+	if host == "" {
+		return host
+	}
+	parts := strings.SplitN(host, ".", 2)
+	parts[0] += suffix
+	return strings.Join(parts, ".")
+}
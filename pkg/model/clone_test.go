@@ -0,0 +1,117 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	kube_types "github.com/containerum/kube-client/pkg/model"
+	api_apps "k8s.io/api/apps/v1"
+	api_core "k8s.io/api/core/v1"
+	api_extensions "k8s.io/api/extensions/v1beta1"
+	api_meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func testSolutionNamespaceObjects(t *testing.T) *NamespaceObjects {
+	solution := testSolution()
+	manifests, errs := solution.ToKube("ns", "blog-1", map[string]string{ownerLabel: "user"})
+	if errs != nil {
+		t.Fatal(errs)
+	}
+	objects := &NamespaceObjects{
+		Deployments:            &api_apps.DeploymentList{},
+		Services:               &api_core.ServiceList{},
+		Ingresses:              &api_extensions.IngressList{},
+		ConfigMaps:             &api_core.ConfigMapList{},
+		PersistentVolumeClaims: &api_core.PersistentVolumeClaimList{},
+		Secrets: &api_core.SecretList{Items: []api_core.Secret{{
+			ObjectMeta: api_meta.ObjectMeta{Name: "default-token", Namespace: "ns"},
+			Type:       api_core.SecretTypeServiceAccountToken,
+		}}},
+	}
+	for _, obj := range manifests {
+		switch native := obj.Object.(type) {
+		case *api_apps.Deployment:
+			objects.Deployments.Items = append(objects.Deployments.Items, *native)
+		case *api_core.Service:
+			native.Spec.ExternalIPs = []string{"192.168.0.1"}
+			objects.Services.Items = append(objects.Services.Items, *native)
+		case *api_extensions.Ingress:
+			objects.Ingresses.Items = append(objects.Ingresses.Items, *native)
+		case *api_core.ConfigMap:
+			objects.ConfigMaps.Items = append(objects.ConfigMaps.Items, *native)
+		case *api_core.PersistentVolumeClaim:
+			objects.PersistentVolumeClaims.Items = append(objects.PersistentVolumeClaims.Items, *native)
+		case *api_core.Secret:
+			objects.Secrets.Items = append(objects.Secrets.Items, *native)
+		}
+	}
+	return objects
+}
+
+func TestCloneNamespaceObjects(t *testing.T) {
+	req := NamespaceCloneRequest{Namespace: NamespaceWithQuota{Namespace: kube_types.Namespace{ID: "staging"}}}
+	if errs := req.Validate(); errs != nil {
+		t.Fatal(errs)
+	}
+	if req.Volumes != CloneVolumesEmpty || req.HostSuffix != "-staging" {
+		t.Errorf("unexpected defaults: %+v", req)
+	}
+
+	nsLabels := map[string]string{ownerLabel: "admin"}
+	cloned, results := CloneNamespaceObjects(testSolutionNamespaceObjects(t), "staging", nsLabels, req)
+	if results != nil {
+		t.Errorf("unexpected results: %+v", results)
+	}
+
+	var order []string
+	for _, obj := range cloned {
+		order = append(order, obj.Kind)
+		meta := obj.Object.(api_meta.Object)
+		if obj.Namespace != "staging" || meta.GetNamespace() != "staging" {
+			t.Errorf("%v %v is not in new namespace", obj.Kind, obj.Name)
+		}
+		if meta.GetLabels()[ownerLabel] != "admin" || meta.GetLabels()[solutionLabel] != "blog-1" {
+			t.Errorf("unexpected %v labels: %v", obj.Kind, meta.GetLabels())
+		}
+	}
+	if strings.Join(order, " ") != "PersistentVolumeClaim ConfigMap Secret Deployment Service Ingress" {
+		t.Fatalf("unexpected order: %v", order)
+	}
+	if len(nsLabels) != 1 {
+		t.Errorf("namespace labels are modified: %v", nsLabels)
+	}
+
+	if cm := cloned[1].Object.(*api_core.ConfigMap); cm.Data["config.json"] != "{}" {
+		t.Errorf("unexpected config map data: %v", cm.Data)
+	}
+	if secret := cloned[2].Object.(*api_core.Secret); secret.Type != api_core.SecretTypeOpaque || string(secret.Data["password"]) != "secret" {
+		t.Errorf("unexpected secret: %+v", secret)
+	}
+	if svc := cloned[4].Object.(*api_core.Service); len(svc.Spec.ExternalIPs) != 0 {
+		t.Errorf("external IPs are cloned: %v", svc.Spec.ExternalIPs)
+	}
+	if ingress := cloned[5].Object.(*api_extensions.Ingress); ingress.Spec.Rules[0].Host != "blog-staging.example.com" {
+		t.Errorf("unexpected ingress host %q", ingress.Spec.Rules[0].Host)
+	}
+}
+
+func TestCloneNamespaceSkipVolumes(t *testing.T) {
+	req := NamespaceCloneRequest{
+		Namespace:  NamespaceWithQuota{Namespace: kube_types.Namespace{ID: "staging"}},
+		HostSuffix: "-copy",
+		Volumes:    CloneVolumesSkip,
+	}
+	if errs := req.Validate(); errs != nil {
+		t.Fatal(errs)
+	}
+	cloned, results := CloneNamespaceObjects(testSolutionNamespaceObjects(t), "staging", map[string]string{}, req)
+	if len(cloned) != 5 || len(results) != 1 || results[0].Kind != pvcKind || results[0].Status != ApplyStatusSkipped {
+		t.Errorf("unexpected clone: %v objects, results %+v", len(cloned), results)
+	}
+
+	req.Volumes = "copy"
+	req.HostSuffix = ".example"
+	if errs := req.Validate(); len(errs) != 2 {
+		t.Errorf("expected 2 errors, got %v", errs)
+	}
+}
@@ -3,6 +3,7 @@ package model
 import (
 	"fmt"
 	"strings"
+	"unicode/utf8"
 
 	"time"
 
@@ -16,16 +17,41 @@ import (
 	api_validation "k8s.io/apimachinery/pkg/util/validation"
 )
 
-type ConfigMapKubeAPI kube_types.ConfigMap
+// ConfigMapSizeMax -- maximum total size of config map keys and values
+const ConfigMapSizeMax = 1 << 20 // 1 MiB
+
+// SelectedConfigMapsWithBinaryDataList -- model for config maps list from all namespaces
+//
+// swagger:model
+type SelectedConfigMapsWithBinaryDataList map[string]ConfigMapWithBinaryDataList
+
+// ConfigMapWithBinaryDataList -- model for config maps list
+//
+// swagger:model
+type ConfigMapWithBinaryDataList struct {
+	ConfigMaps []ConfigMapWithBinaryData `json:"configmaps"`
+}
+
+// ConfigMapWithBinaryData -- model for config map with binary data
+//
+// swagger:model
+type ConfigMapWithBinaryData struct {
+	// swagger: allOf
+	kube_types.ConfigMap
+	// base64 encoded binary data, keys must not overlap with data keys
+	BinaryData kube_types.ConfigMapData `json:"binary_data,omitempty" yaml:"binary_data,omitempty"`
+}
+
+type ConfigMapKubeAPI ConfigMapWithBinaryData
 
 // ParseKubeConfigMapList parses kubernetes v1.ConfigMapList to more convenient []ConfigMap struct.
-func ParseKubeConfigMapList(cmi interface{}, parseforuser bool) (*kube_types.ConfigMapsList, error) {
+func ParseKubeConfigMapList(cmi interface{}, parseforuser bool) (*ConfigMapWithBinaryDataList, error) {
 	cmList := cmi.(*api_core.ConfigMapList)
 	if cmList == nil {
 		return nil, ErrUnableConvertConfigMapList
 	}
 
-	newCms := make([]kube_types.ConfigMap, 0)
+	newCms := make([]ConfigMapWithBinaryData, 0)
 	for _, cm := range cmList.Items {
 		newCm, err := ParseKubeConfigMap(&cm, parseforuser)
 		if err != nil {
@@ -33,11 +59,11 @@ func ParseKubeConfigMapList(cmi interface{}, parseforuser bool) (*kube_types.Con
 		}
 		newCms = append(newCms, *newCm)
 	}
-	return &kube_types.ConfigMapsList{ConfigMaps: newCms}, nil
+	return &ConfigMapWithBinaryDataList{ConfigMaps: newCms}, nil
 }
 
 // ParseKubeConfigMap parses kubernetes v1.ConfigMap to more convenient ConfigMap struct.
-func ParseKubeConfigMap(cmi interface{}, parseforuser bool) (*kube_types.ConfigMap, error) {
+func ParseKubeConfigMap(cmi interface{}, parseforuser bool) (*ConfigMapWithBinaryData, error) {
 	cm := cmi.(*api_core.ConfigMap)
 	if cm == nil {
 		return nil, ErrUnableConvertConfigMap
@@ -48,14 +74,25 @@ func ParseKubeConfigMap(cmi interface{}, parseforuser bool) (*kube_types.ConfigM
 		newData[k] = v
 	}
 
+	var newBinaryData map[string]string
+	if len(cm.BinaryData) > 0 {
+		newBinaryData = make(map[string]string, len(cm.BinaryData))
+		for k, v := range cm.BinaryData {
+			newBinaryData[k] = base64.StdEncoding.EncodeToString(v)
+		}
+	}
+
 	owner := cm.GetObjectMeta().GetLabels()[ownerLabel]
 
-	newCm := kube_types.ConfigMap{
-		Name:      cm.GetName(),
-		Namespace: cm.Namespace,
-		CreatedAt: cm.CreationTimestamp.UTC().Format(time.RFC3339),
-		Data:      kube_types.ConfigMapData(newData),
-		Owner:     owner,
+	newCm := ConfigMapWithBinaryData{
+		ConfigMap: kube_types.ConfigMap{
+			Name:      cm.GetName(),
+			Namespace: cm.Namespace,
+			CreatedAt: cm.CreationTimestamp.UTC().Format(time.RFC3339),
+			Data:      kube_types.ConfigMapData(newData),
+			Owner:     owner,
+		},
+		BinaryData: kube_types.ConfigMapData(newBinaryData),
 	}
 
 	if parseforuser {
@@ -64,8 +101,9 @@ func ParseKubeConfigMap(cmi interface{}, parseforuser bool) (*kube_types.ConfigM
 	return &newCm, nil
 }
 
-// ToKube creates kubernetes v1.ConfigMap from ConfigMap struct and namespace labels
-func (cm *ConfigMapKubeAPI) ToKube(nsName string, labels map[string]string) (*api_core.ConfigMap, []error) {
+// ToKube creates kubernetes v1.ConfigMap from ConfigMap struct and namespace labels.
+// If solutionID is not empty, config map is labeled as part of solution.
+func (cm *ConfigMapKubeAPI) ToKube(nsName string, solutionID string, labels map[string]string) (*api_core.ConfigMap, []error) {
 	if err := cm.Validate(); err != nil {
 		return nil, err
 	}
@@ -73,6 +111,9 @@ func (cm *ConfigMapKubeAPI) ToKube(nsName string, labels map[string]string) (*ap
 	if labels == nil {
 		return nil, []error{errors.New("invalid project labels")}
 	}
+	if solutionID != "" {
+		labels[solutionLabel] = solutionID
+	}
 
 	for k, v := range cm.Data {
 		dec, err := base64.StdEncoding.DecodeString(v)
@@ -82,6 +123,22 @@ func (cm *ConfigMapKubeAPI) ToKube(nsName string, labels map[string]string) (*ap
 		cm.Data[k] = string(dec)
 	}
 
+	var binaryData map[string][]byte
+	if len(cm.BinaryData) > 0 {
+		binaryData = make(map[string][]byte, len(cm.BinaryData))
+		for k, v := range cm.BinaryData {
+			dec, err := base64.StdEncoding.DecodeString(v)
+			if err != nil {
+				return nil, []error{fmt.Errorf("unable to decode base64 value of key '%v': %v", k, err)}
+			}
+			binaryData[k] = dec
+		}
+	}
+
+	if size := configMapSize(cm.Data, binaryData); size > ConfigMapSizeMax {
+		return nil, []error{fmt.Errorf(configMapTooLarge, size, ConfigMapSizeMax)}
+	}
+
 	newCm := api_core.ConfigMap{
 		TypeMeta: api_meta.TypeMeta{
 			Kind:       "ConfigMap",
@@ -92,7 +149,8 @@ func (cm *ConfigMapKubeAPI) ToKube(nsName string, labels map[string]string) (*ap
 			Name:      cm.Name,
 			Namespace: nsName,
 		},
-		Data: cm.Data,
+		Data:       cm.Data,
+		BinaryData: binaryData,
 	}
 	return &newCm, nil
 }
@@ -104,7 +162,7 @@ func (cm *ConfigMapKubeAPI) Validate() []error {
 	} else if err := api_validation.IsDNS1123Label(cm.Name); len(err) > 0 {
 		errs = append(errs, fmt.Errorf(invalidName, cm.Name, strings.Join(err, ",")))
 	}
-	if len(cm.Data) == 0 {
+	if len(cm.Data) == 0 && len(cm.BinaryData) == 0 {
 		errs = append(errs, fmt.Errorf(fieldShouldExist, "data"))
 	} else {
 		for k := range cm.Data {
@@ -112,9 +170,62 @@ func (cm *ConfigMapKubeAPI) Validate() []error {
 				errs = append(errs, fmt.Errorf(invalidName, k, strings.Join(err, ",")))
 			}
 		}
+		for k := range cm.BinaryData {
+			if err := api_validation.IsConfigMapKey(k); len(err) > 0 {
+				errs = append(errs, fmt.Errorf(invalidName, k, strings.Join(err, ",")))
+			}
+			if _, inData := cm.Data[k]; inData {
+				errs = append(errs, fmt.Errorf(duplicateKey, k))
+			}
+		}
 	}
 	if len(errs) > 0 {
 		return errs
 	}
 	return nil
 }
+
+// ConfigMapFromFiles makes config map with one key per file.
+// UTF-8 files are put to data and other files to binary data, values are base64 encoded as ToKube expects.
+func ConfigMapFromFiles(name string, files map[string][]byte) *ConfigMapKubeAPI {
+	cm := ConfigMapKubeAPI{
+		ConfigMap: kube_types.ConfigMap{
+			Name: name,
+			Data: make(kube_types.ConfigMapData),
+		},
+		BinaryData: make(kube_types.ConfigMapData),
+	}
+	for k, v := range files {
+		if utf8.Valid(v) {
+			cm.Data[k] = base64.StdEncoding.EncodeToString(v)
+		} else {
+			cm.BinaryData[k] = base64.StdEncoding.EncodeToString(v)
+		}
+	}
+	return &cm
+}
+
+// GetConfigMapFiles returns contents of data and binary data keys of kubernetes v1.ConfigMap
+func GetConfigMapFiles(cmi interface{}) map[string][]byte {
+	cm := cmi.(*api_core.ConfigMap)
+	files := make(map[string][]byte, len(cm.Data)+len(cm.BinaryData))
+	for k, v := range cm.Data {
+		files[k] = []byte(v)
+	}
+	for k, v := range cm.BinaryData {
+		files[k] = v
+	}
+	return files
+}
+
+// configMapSize returns size of keys and values in the same way as kubernetes does
+func configMapSize(data map[string]string, binaryData map[string][]byte) int {
+	size := 0
+	for k, v := range data {
+		size += len(k) + len(v)
+	}
+	for k, v := range binaryData {
+		size += len(k) + len(v)
+	}
+	return size
+}
@@ -0,0 +1,68 @@
+package model
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	kube_types "github.com/containerum/kube-client/pkg/model"
+)
+
+func TestConfigMapFromFiles(t *testing.T) {
+	binary := []byte{0x89, 'P', 'N', 'G', 0xff, 0x00}
+	cm := ConfigMapFromFiles("files", map[string][]byte{
+		"app.conf": []byte("listen 80\n"),
+		"logo.png": binary,
+	})
+	native, errs := cm.ToKube("ns", "", map[string]string{})
+	if errs != nil {
+		t.Fatal(errs)
+	}
+	if native.Data["app.conf"] != "listen 80\n" || len(native.Data) != 1 {
+		t.Errorf("unexpected data: %v", native.Data)
+	}
+	if !bytes.Equal(native.BinaryData["logo.png"], binary) || len(native.BinaryData) != 1 {
+		t.Errorf("unexpected binary data: %v", native.BinaryData)
+	}
+
+	parsed, err := ParseKubeConfigMap(native, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := GetConfigMapFiles(native)
+	if string(files["app.conf"]) != "listen 80\n" || !bytes.Equal(files["logo.png"], binary) {
+		t.Errorf("unexpected files: %v", files)
+	}
+
+	// parsed config map can be sent back without changes
+	again := ConfigMapKubeAPI(*parsed)
+	for k, v := range again.Data {
+		again.Data[k] = base64.StdEncoding.EncodeToString([]byte(v))
+	}
+	native, errs = again.ToKube("ns", "", map[string]string{})
+	if errs != nil {
+		t.Fatal(errs)
+	}
+	if !bytes.Equal(native.BinaryData["logo.png"], binary) {
+		t.Errorf("binary data changed after round trip: %v", native.BinaryData)
+	}
+}
+
+func TestConfigMapValidation(t *testing.T) {
+	cm := ConfigMapKubeAPI{ConfigMap: kube_types.ConfigMap{Name: "dup"}}
+	cm.Data = map[string]string{"key": base64.StdEncoding.EncodeToString([]byte("a"))}
+	cm.BinaryData = map[string]string{"key": base64.StdEncoding.EncodeToString([]byte("b"))}
+	if _, errs := cm.ToKube("ns", "", map[string]string{}); errs == nil {
+		t.Error("expected error for key in data and binary data")
+	}
+
+	large := ConfigMapFromFiles("large", map[string][]byte{
+		"a": bytes.Repeat([]byte("a"), ConfigMapSizeMax/2),
+		"b": bytes.Repeat([]byte("b"), ConfigMapSizeMax/2),
+	})
+	_, errs := large.ToKube("ns", "", map[string]string{})
+	if errs == nil || !strings.Contains(errs[0].Error(), "exceeds limit") {
+		t.Errorf("expected size limit error, got %v", errs)
+	}
+}
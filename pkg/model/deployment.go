@@ -6,6 +6,7 @@ import (
 	"strconv"
 
 	"path"
+	"reflect"
 	"strings"
 
 	"time"
@@ -32,22 +33,132 @@ const (
 
 	volumePostfix = "-volume"
 	cmPostfix     = "-cm"
+	secretPostfix = "-secret"
 
 	appLabel      = "app"
 	versionLabel  = "version"
 	solutionLabel = "solution"
+
+	// user pods are scheduled only to nodes with this role
+	nodeRoleLabel = "role"
+	nodeRoleSlave = "slave"
 )
 
-type DeploymentKubeAPI kube_types.Deployment
+// DeploymentWithRefsList -- model for deployments list
+//
+// swagger:model
+type DeploymentWithRefsList struct {
+	Deployments []DeploymentWithRefs `json:"deployments"`
+}
+
+// DeploymentWithRefs -- model for deployment with containers referencing secrets and config maps
+//
+// swagger:model
+type DeploymentWithRefs struct {
+	// swagger: allOf
+	kube_types.Deployment
+	// required: true
+	Containers []ContainerWithRefs `json:"containers" yaml:"containers"`
+}
+
+// ContainerWithRefs -- model for container with environment from secrets and config maps
+//
+// swagger:model
+type ContainerWithRefs struct {
+	// swagger: allOf
+	kube_types.Container
+	Env []EnvVar `json:"env,omitempty"`
+	// all keys of secrets and config maps as environment variables
+	EnvFrom []EnvFrom         `json:"env_from,omitempty"`
+	Secrets []ContainerSecret `json:"secrets,omitempty"`
+}
+
+// AddEnv sets environment variable value or reference, variable is added if it doesn't exist
+func (container *ContainerWithRefs) AddEnv(env EnvVar) {
+	for i, cont := range container.Env {
+		if cont.Name == env.Name {
+			container.Env[i].Value = env.Value
+			container.Env[i].ValueFrom = env.ValueFrom
+			return
+		}
+	}
+	container.Env = append(container.Env, env)
+}
+
+// ContainerSecret -- secret mounted in container
+//
+// swagger:model
+type ContainerSecret struct {
+	// required: true
+	Name string  `json:"name"`
+	Mode *string `json:"mode,omitempty"`
+	// required: true
+	MountPath string  `json:"mount_path"`
+	SubPath   *string `json:"sub_path,omitempty"`
+	// keys mounted as files, all keys are mounted if empty
+	Items []SecretItem `json:"items,omitempty"`
+}
+
+// SecretItem -- secret key mounted as file
+//
+// swagger:model
+type SecretItem struct {
+	// required: true
+	Key string `json:"key"`
+	// relative file path, key is used by default
+	Path string `json:"path,omitempty"`
+}
+
+// EnvVar -- environment variable with value or value from secret or config map key
+//
+// swagger:model
+type EnvVar struct {
+	// required if value_from is not set
+	Value string `json:"value"`
+	// required: true
+	Name string `json:"name"`
+	// value from secret or config map key
+	ValueFrom *EnvValueFrom `json:"value_from,omitempty"`
+}
+
+// EnvValueFrom -- source of environment variable value
+//
+// swagger:model
+type EnvValueFrom struct {
+	SecretKeyRef    *EnvKeyRef `json:"secret_key_ref,omitempty"`
+	ConfigMapKeyRef *EnvKeyRef `json:"config_map_key_ref,omitempty"`
+}
+
+// EnvKeyRef -- key of secret or config map
+//
+// swagger:model
+type EnvKeyRef struct {
+	// required: true
+	Name string `json:"name"`
+	// required: true
+	Key string `json:"key"`
+}
+
+// EnvFrom -- all keys of secret or config map as environment variables
+//
+// swagger:model
+type EnvFrom struct {
+	// prefix added to variable names
+	Prefix    string `json:"prefix,omitempty"`
+	Secret    string `json:"secret,omitempty"`
+	ConfigMap string `json:"config_map,omitempty"`
+}
+
+type DeploymentKubeAPI DeploymentWithRefs
 
 // ParseKubeDeploymentList parses kubernetes v1.DeploymentList to more convenient []Deployment struct
-func ParseKubeDeploymentList(deploys interface{}, parseforuser bool) (*kube_types.DeploymentsList, error) {
+func ParseKubeDeploymentList(deploys interface{}, parseforuser bool) (*DeploymentWithRefsList, error) {
 	deployList := deploys.(*api_apps.DeploymentList)
 	if deployList == nil {
 		return nil, ErrUnableConvertDeploymentList
 	}
 
-	deployments := make([]kube_types.Deployment, 0)
+	deployments := make([]DeploymentWithRefs, 0)
 	for _, deployment := range deployList.Items {
 		deployment, err := ParseKubeDeployment(&deployment, parseforuser)
 		if err != nil {
@@ -56,11 +167,11 @@ func ParseKubeDeploymentList(deploys interface{}, parseforuser bool) (*kube_type
 
 		deployments = append(deployments, *deployment)
 	}
-	return &kube_types.DeploymentsList{Deployments: deployments}, nil
+	return &DeploymentWithRefsList{Deployments: deployments}, nil
 }
 
 // ParseKubeDeployment parses kubernetes v1.Deployment to more convenient Deployment struct
-func ParseKubeDeployment(deployment interface{}, parseforuser bool) (*kube_types.Deployment, error) {
+func ParseKubeDeployment(deployment interface{}, parseforuser bool) (*DeploymentWithRefs, error) {
 	deploy := deployment.(*api_apps.Deployment)
 	if deploy == nil {
 		return nil, ErrUnableConvertDeployment
@@ -70,30 +181,32 @@ func ParseKubeDeployment(deployment interface{}, parseforuser bool) (*kube_types
 	if r := deploy.Spec.Replicas; r != nil {
 		replicas = int(*r)
 	}
-	containers, totalcpu, totalmem := getContainers(deploy.Spec.Template.Spec.Containers, getVolumeMode(deploy.Spec.Template.Spec.Volumes), getVolumeStorageName(deploy.Spec.Template.Spec.Volumes), replicas)
+	containers, totalcpu, totalmem := getContainers(deploy.Spec.Template.Spec.Containers, getVolumeMode(deploy.Spec.Template.Spec.Volumes), getVolumeStorageName(deploy.Spec.Template.Spec.Volumes), getVolumeSecrets(deploy.Spec.Template.Spec.Volumes), replicas)
 
 	version, _ := semver.ParseTolerant(deploy.GetObjectMeta().GetLabels()[versionLabel])
 
-	newDeploy := kube_types.Deployment{
-		Name:      deploy.GetName(),
-		Namespace: deploy.Namespace,
-		Replicas:  replicas,
-		Status: &kube_types.DeploymentStatus{
-			Replicas:            int(deploy.Status.Replicas),
-			ReadyReplicas:       int(deploy.Status.ReadyReplicas),
-			AvailableReplicas:   int(deploy.Status.AvailableReplicas),
-			UpdatedReplicas:     int(deploy.Status.UpdatedReplicas),
-			UnavailableReplicas: int(deploy.Status.UnavailableReplicas),
+	newDeploy := DeploymentWithRefs{
+		Deployment: kube_types.Deployment{
+			Name:      deploy.GetName(),
+			Namespace: deploy.Namespace,
+			Replicas:  replicas,
+			Status: &kube_types.DeploymentStatus{
+				Replicas:            int(deploy.Status.Replicas),
+				ReadyReplicas:       int(deploy.Status.ReadyReplicas),
+				AvailableReplicas:   int(deploy.Status.AvailableReplicas),
+				UpdatedReplicas:     int(deploy.Status.UpdatedReplicas),
+				UnavailableReplicas: int(deploy.Status.UnavailableReplicas),
+			},
+			CreatedAt:        deploy.ObjectMeta.CreationTimestamp.UTC().Format(time.RFC3339),
+			SolutionID:       deploy.GetObjectMeta().GetLabels()[solutionLabel],
+			ImagePullSecrets: getImagePullSecrets(deploy.Spec.Template.Spec.ImagePullSecrets),
+			TotalCPU:         uint(totalcpu.ScaledValue(api_resource.Milli)),
+			TotalMemory:      uint(totalmem.Value() / 1024 / 1024),
+			Owner:            deploy.GetObjectMeta().GetLabels()[ownerLabel],
+			Version:          version,
+			Active:           true,
 		},
-		CreatedAt:        deploy.ObjectMeta.CreationTimestamp.UTC().Format(time.RFC3339),
-		SolutionID:       deploy.GetObjectMeta().GetLabels()[solutionLabel],
-		Containers:       containers,
-		ImagePullSecrets: getImagePullSecrets(deploy.Spec.Template.Spec.ImagePullSecrets),
-		TotalCPU:         uint(totalcpu.ScaledValue(api_resource.Milli)),
-		TotalMemory:      uint(totalmem.Value() / 1024 / 1024),
-		Owner:            deploy.GetObjectMeta().GetLabels()[ownerLabel],
-		Version:          version,
-		Active:           true,
+		Containers: containers,
 	}
 
 	if parseforuser {
@@ -123,6 +236,16 @@ func getVolumeStorageName(volumes []api_core.Volume) map[string]string {
 	return volumemap
 }
 
+func getVolumeSecrets(volumes []api_core.Volume) map[string]*api_core.SecretVolumeSource {
+	volumemap := make(map[string]*api_core.SecretVolumeSource)
+	for _, v := range volumes {
+		if v.Secret != nil {
+			volumemap[v.Name] = v.Secret
+		}
+	}
+	return volumemap
+}
+
 func getImagePullSecrets(secrets []api_core.LocalObjectReference) []string {
 	secretsList := []string{}
 	for _, v := range secrets {
@@ -196,7 +319,7 @@ func (deploy *DeploymentKubeAPI) ToKube(nsName string, labels map[string]string)
 				Spec: api_core.PodSpec{
 					Containers: containers,
 					NodeSelector: map[string]string{
-						"role": "slave",
+						nodeRoleLabel: nodeRoleSlave,
 					},
 					ImagePullSecrets: imagePullSecrets,
 					Volumes:          volumes,
@@ -211,7 +334,7 @@ func (deploy *DeploymentKubeAPI) ToKube(nsName string, labels map[string]string)
 	return &newDeploy, nil
 }
 
-func makeContainers(containers []kube_types.Container) ([]api_core.Container, []error) {
+func makeContainers(containers []ContainerWithRefs) ([]api_core.Container, []error) {
 	containersAfter := make([]api_core.Container, len(containers))
 
 	for i, c := range containers {
@@ -226,14 +349,18 @@ func makeContainers(containers []kube_types.Container) ([]api_core.Container, []
 			Command: makeContainerCommands(c.Commands),
 		}
 
-		if c.VolumeMounts != nil || c.ConfigMaps != nil {
-			container.VolumeMounts = makeContainerVolumes(c.VolumeMounts, c.ConfigMaps)
+		if c.VolumeMounts != nil || c.ConfigMaps != nil || c.Secrets != nil {
+			container.VolumeMounts = makeContainerVolumes(c.VolumeMounts, c.ConfigMaps, c.Secrets)
 		}
 
 		if c.Env != nil {
 			container.Env = makeContainerEnv(c.Env)
 		}
 
+		if c.EnvFrom != nil {
+			container.EnvFrom = makeContainerEnvFrom(c.EnvFrom)
+		}
+
 		if c.Ports != nil {
 			container.Ports = makeContainerPorts(c.Ports)
 		}
@@ -247,7 +374,7 @@ func makeContainers(containers []kube_types.Container) ([]api_core.Container, []
 	return containersAfter, nil
 }
 
-func makeContainerVolumes(volumes []kube_types.ContainerVolume, configMaps []kube_types.ContainerVolume) []api_core.VolumeMount {
+func makeContainerVolumes(volumes []kube_types.ContainerVolume, configMaps []kube_types.ContainerVolume, secrets []ContainerSecret) []api_core.VolumeMount {
 	volumeMounts := make([]api_core.VolumeMount, 0)
 	for _, v := range volumes {
 		var subpath string
@@ -263,18 +390,107 @@ func makeContainerVolumes(volumes []kube_types.ContainerVolume, configMaps []kub
 		}
 		volumeMounts = append(volumeMounts, api_core.VolumeMount{Name: v.Name + cmPostfix, MountPath: v.MountPath, SubPath: subpath})
 	}
+	for _, v := range secrets {
+		var subpath string
+		if v.SubPath != nil {
+			subpath = *v.SubPath
+		}
+		volumeMounts = append(volumeMounts, api_core.VolumeMount{Name: v.Name + secretPostfix, MountPath: v.MountPath, SubPath: subpath, ReadOnly: true})
+	}
 
 	return volumeMounts
 }
 
-func makeContainerEnv(env []kube_types.Env) []api_core.EnvVar {
+func makeContainerEnv(env []EnvVar) []api_core.EnvVar {
 	envvar := make([]api_core.EnvVar, 0)
 	for _, v := range env {
-		envvar = append(envvar, api_core.EnvVar{Name: v.Name, Value: v.Value})
+		newEnv := api_core.EnvVar{Name: v.Name, Value: v.Value}
+		if v.ValueFrom != nil {
+			newEnv.ValueFrom = &api_core.EnvVarSource{}
+			if ref := v.ValueFrom.SecretKeyRef; ref != nil {
+				newEnv.ValueFrom.SecretKeyRef = &api_core.SecretKeySelector{
+					LocalObjectReference: api_core.LocalObjectReference{Name: ref.Name},
+					Key:                  ref.Key,
+				}
+			}
+			if ref := v.ValueFrom.ConfigMapKeyRef; ref != nil {
+				newEnv.ValueFrom.ConfigMapKeyRef = &api_core.ConfigMapKeySelector{
+					LocalObjectReference: api_core.LocalObjectReference{Name: ref.Name},
+					Key:                  ref.Key,
+				}
+			}
+		}
+		envvar = append(envvar, newEnv)
 	}
 	return envvar
 }
 
+func makeContainerEnvFrom(envFrom []EnvFrom) []api_core.EnvFromSource {
+	sources := make([]api_core.EnvFromSource, 0)
+	for _, v := range envFrom {
+		source := api_core.EnvFromSource{Prefix: v.Prefix}
+		if v.Secret != "" {
+			source.SecretRef = &api_core.SecretEnvSource{LocalObjectReference: api_core.LocalObjectReference{Name: v.Secret}}
+		}
+		if v.ConfigMap != "" {
+			source.ConfigMapRef = &api_core.ConfigMapEnvSource{LocalObjectReference: api_core.LocalObjectReference{Name: v.ConfigMap}}
+		}
+		sources = append(sources, source)
+	}
+	return sources
+}
+
+// ObjectReference -- secret or config map used by container.
+// Key is empty if all keys are used.
+type ObjectReference struct {
+	Kind string
+	Name string
+	Key  string
+}
+
+// IsSecret checks if referenced object is secret
+func (ref ObjectReference) IsSecret() bool {
+	return ref.Kind == secretKind
+}
+
+// GetObjectReferences returns secrets and config maps used in environment of deployment containers and secrets mounted as volumes
+func GetObjectReferences(deploy *api_apps.Deployment) []ObjectReference {
+	var refs []ObjectReference
+	for _, v := range deploy.Spec.Template.Spec.Volumes {
+		if v.Secret == nil {
+			continue
+		}
+		if len(v.Secret.Items) == 0 {
+			refs = append(refs, ObjectReference{Kind: secretKind, Name: v.Secret.SecretName})
+		}
+		for _, item := range v.Secret.Items {
+			refs = append(refs, ObjectReference{Kind: secretKind, Name: v.Secret.SecretName, Key: item.Key})
+		}
+	}
+	for _, c := range deploy.Spec.Template.Spec.Containers {
+		for _, env := range c.Env {
+			if env.ValueFrom == nil {
+				continue
+			}
+			if ref := env.ValueFrom.SecretKeyRef; ref != nil {
+				refs = append(refs, ObjectReference{Kind: secretKind, Name: ref.Name, Key: ref.Key})
+			}
+			if ref := env.ValueFrom.ConfigMapKeyRef; ref != nil {
+				refs = append(refs, ObjectReference{Kind: configMapKind, Name: ref.Name, Key: ref.Key})
+			}
+		}
+		for _, env := range c.EnvFrom {
+			if env.SecretRef != nil {
+				refs = append(refs, ObjectReference{Kind: secretKind, Name: env.SecretRef.Name})
+			}
+			if env.ConfigMapRef != nil {
+				refs = append(refs, ObjectReference{Kind: configMapKind, Name: env.ConfigMapRef.Name})
+			}
+		}
+	}
+	return refs
+}
+
 func makeContainerPorts(ports []kube_types.ContainerPort) []api_core.ContainerPort {
 	contports := make([]api_core.ContainerPort, 0)
 	for _, v := range ports {
@@ -311,10 +527,11 @@ func makeContainerResourceQuota(cpu, memory uint) *api_core.ResourceRequirements
 	}
 }
 
-func makeTemplateVolumes(containers []kube_types.Container) ([]api_core.Volume, error) {
+func makeTemplateVolumes(containers []ContainerWithRefs) ([]api_core.Volume, error) {
 	templateVolumes := make([]api_core.Volume, 0)
 	existingVolume := make(map[string]bool)
 	existingMountPath := make(map[string]bool)
+	secretVolumes := make(map[string]*api_core.SecretVolumeSource)
 
 	for _, c := range containers {
 		for _, v := range c.VolumeMounts {
@@ -372,6 +589,48 @@ func makeTemplateVolumes(containers []kube_types.Container) ([]api_core.Volume,
 				continue
 			}
 		}
+
+		for _, v := range c.Secrets {
+			defMode := int32(0644)
+			if v.Mode != nil {
+				if mode, err := strconv.ParseInt(*v.Mode, 8, 32); err == nil {
+					defMode = int32(mode)
+				}
+			}
+
+			var items []api_core.KeyToPath
+			for _, item := range v.Items {
+				itemPath := item.Path
+				if itemPath == "" {
+					itemPath = item.Key
+				}
+				items = append(items, api_core.KeyToPath{Key: item.Key, Path: itemPath})
+			}
+
+			newVolume := api_core.Volume{
+				Name: v.Name + secretPostfix,
+				VolumeSource: api_core.VolumeSource{
+					Secret: &api_core.SecretVolumeSource{
+						SecretName:  v.Name,
+						Items:       items,
+						DefaultMode: &defMode,
+					},
+				},
+			}
+			if !existingMountPath[v.MountPath] {
+				existingMountPath[v.MountPath] = true
+			} else {
+				return nil, fmt.Errorf(duplicateMountPath, v.MountPath)
+			}
+
+			// secret is mounted from one volume, so all its mounts must have the same items and mode
+			if existing, ok := secretVolumes[newVolume.Name]; !ok {
+				templateVolumes = append(templateVolumes, newVolume)
+				secretVolumes[newVolume.Name] = newVolume.Secret
+			} else if !reflect.DeepEqual(existing, newVolume.Secret) {
+				return nil, fmt.Errorf(conflictingMount, v.Name)
+			}
+		}
 	}
 
 	return templateVolumes, nil
@@ -414,7 +673,7 @@ func (deploy *DeploymentKubeAPI) Validate() []error {
 	return nil
 }
 
-func validateContainer(container kube_types.Container, cpu, mem uint) []error {
+func validateContainer(container ContainerWithRefs, cpu, mem uint) []error {
 	var errs []error
 	if container.Name == "" {
 		errs = append(errs, fmt.Errorf(fieldShouldExist, "name"))
@@ -452,6 +711,23 @@ func validateContainer(container kube_types.Container, cpu, mem uint) []error {
 		} else if err := api_validation.IsEnvVarName(v.Name); len(err) > 0 {
 			errs = append(errs, fmt.Errorf(invalidName, v.Name, strings.Join(err, ",")))
 		}
+		if v.ValueFrom != nil {
+			errs = append(errs, validateEnvValueFrom(v)...)
+		}
+	}
+
+	for _, v := range container.EnvFrom {
+		if (v.Secret == "") == (v.ConfigMap == "") {
+			errs = append(errs, fmt.Errorf(exclusiveFields, "container.env_from.secret", "container.env_from.config_map"))
+		}
+		for _, name := range []string{v.Secret, v.ConfigMap} {
+			if err := api_validation.IsDNS1123Label(name); name != "" && len(err) > 0 {
+				errs = append(errs, fmt.Errorf(invalidName, name, strings.Join(err, ",")))
+			}
+		}
+		if err := api_validation.IsEnvVarName(v.Prefix); v.Prefix != "" && len(err) > 0 {
+			errs = append(errs, fmt.Errorf(invalidName, v.Prefix, strings.Join(err, ",")))
+		}
 	}
 
 	for _, v := range container.VolumeMounts {
@@ -482,8 +758,68 @@ func validateContainer(container kube_types.Container, cpu, mem uint) []error {
 		}
 	}
 
+	for _, v := range container.Secrets {
+		if v.Name == "" {
+			errs = append(errs, fmt.Errorf(fieldShouldExist, "container.secrets.name"))
+		} else if err := api_validation.IsDNS1123Label(v.Name); len(err) > 0 {
+			errs = append(errs, fmt.Errorf(invalidName, v.Name, strings.Join(err, ",")))
+		}
+		if v.MountPath == "" {
+			errs = append(errs, fmt.Errorf(fieldShouldExist, "container.secrets.mount_path"))
+		}
+		if v.SubPath != nil && path.IsAbs(*v.SubPath) {
+			errs = append(errs, fmt.Errorf(subPathRelative, *v.SubPath))
+		}
+		if v.Mode != nil {
+			if _, err := strconv.ParseInt(*v.Mode, 8, 32); err != nil {
+				errs = append(errs, fmt.Errorf(invalidFileMode, *v.Mode))
+			}
+		}
+		for _, item := range v.Items {
+			if item.Key == "" {
+				errs = append(errs, fmt.Errorf(fieldShouldExist, "container.secrets.items.key"))
+			} else if err := api_validation.IsConfigMapKey(item.Key); len(err) > 0 {
+				errs = append(errs, fmt.Errorf(invalidName, item.Key, strings.Join(err, ",")))
+			}
+			if path.IsAbs(item.Path) {
+				errs = append(errs, fmt.Errorf(pathRelative, item.Path))
+			} else if hasDotDot(item.Path) {
+				errs = append(errs, fmt.Errorf(pathTraversal, item.Path))
+			}
+		}
+	}
+
 	if len(errs) > 0 {
 		return errs
 	}
 	return nil
 }
+
+func validateEnvValueFrom(env EnvVar) []error {
+	var errs []error
+	if env.Value != "" {
+		errs = append(errs, fmt.Errorf(exclusiveFields, "container.env.value", "container.env.value_from"))
+	}
+	refs := make([]*EnvKeyRef, 0, 2)
+	for _, ref := range []*EnvKeyRef{env.ValueFrom.SecretKeyRef, env.ValueFrom.ConfigMapKeyRef} {
+		if ref != nil {
+			refs = append(refs, ref)
+		}
+	}
+	if len(refs) != 1 {
+		errs = append(errs, fmt.Errorf(exclusiveFields, "container.env.value_from.secret_key_ref", "container.env.value_from.config_map_key_ref"))
+	}
+	for _, ref := range refs {
+		if ref.Name == "" {
+			errs = append(errs, fmt.Errorf(fieldShouldExist, "container.env.value_from.name"))
+		} else if err := api_validation.IsDNS1123Label(ref.Name); len(err) > 0 {
+			errs = append(errs, fmt.Errorf(invalidName, ref.Name, strings.Join(err, ",")))
+		}
+		if ref.Key == "" {
+			errs = append(errs, fmt.Errorf(fieldShouldExist, "container.env.value_from.key"))
+		} else if err := api_validation.IsConfigMapKey(ref.Key); len(err) > 0 {
+			errs = append(errs, fmt.Errorf(invalidName, ref.Key, strings.Join(err, ",")))
+		}
+	}
+	return errs
+}
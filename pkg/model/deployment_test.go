@@ -0,0 +1,146 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestDeploymentEnvReferences(t *testing.T) {
+	deploy := DeploymentKubeAPI(testSolution().Deployments[0])
+	deploy.Containers[0].Env = []EnvVar{
+		{Name: "MODE", Value: "production"},
+		{Name: "DB_PASSWORD", ValueFrom: &EnvValueFrom{SecretKeyRef: &EnvKeyRef{Name: "db", Key: "password"}}},
+		{Name: "DB_HOST", ValueFrom: &EnvValueFrom{ConfigMapKeyRef: &EnvKeyRef{Name: "db-config", Key: "host"}}},
+	}
+	deploy.Containers[0].EnvFrom = []EnvFrom{{Secret: "api-keys", Prefix: "API_"}, {ConfigMap: "settings"}}
+
+	native, errs := deploy.ToKube("ns", map[string]string{})
+	if errs != nil {
+		t.Fatal(errs)
+	}
+	refs := GetObjectReferences(native)
+	expected := []ObjectReference{
+		{Kind: secretKind, Name: "db", Key: "password"},
+		{Kind: configMapKind, Name: "db-config", Key: "host"},
+		{Kind: secretKind, Name: "api-keys"},
+		{Kind: configMapKind, Name: "settings"},
+	}
+	if len(refs) != len(expected) {
+		t.Fatalf("unexpected references: %+v", refs)
+	}
+	for i := range expected {
+		if refs[i] != expected[i] {
+			t.Errorf("unexpected reference %+v, expected %+v", refs[i], expected[i])
+		}
+	}
+
+	parsed, err := ParseKubeDeployment(native, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	container := parsed.Containers[0]
+	if container.Env[1].Value != "" || container.Env[1].ValueFrom.SecretKeyRef.Key != "password" ||
+		container.Env[2].ValueFrom.ConfigMapKeyRef.Name != "db-config" {
+		t.Errorf("unexpected env: %+v", container.Env)
+	}
+	if len(container.EnvFrom) != 2 || container.EnvFrom[0].Secret != "api-keys" || container.EnvFrom[0].Prefix != "API_" ||
+		container.EnvFrom[1].ConfigMap != "settings" {
+		t.Errorf("unexpected env from: %+v", container.EnvFrom)
+	}
+}
+
+func TestDeploymentEnvReferencesInvalid(t *testing.T) {
+	deploy := DeploymentKubeAPI(testSolution().Deployments[0])
+	deploy.Containers[0].Env = []EnvVar{
+		{Name: "BOTH", Value: "value", ValueFrom: &EnvValueFrom{SecretKeyRef: &EnvKeyRef{Name: "db", Key: "password"}}},
+		{Name: "NONE", ValueFrom: &EnvValueFrom{}},
+		{Name: "NO_KEY", ValueFrom: &EnvValueFrom{ConfigMapKeyRef: &EnvKeyRef{Name: "db"}}},
+	}
+	deploy.Containers[0].EnvFrom = []EnvFrom{{Secret: "db", ConfigMap: "db"}, {Secret: "Invalid_Name"}}
+	if _, errs := deploy.ToKube("ns", map[string]string{}); len(errs) != 5 {
+		t.Errorf("expected 5 errors, got %v", errs)
+	}
+}
+
+func TestDeploymentSecretMounts(t *testing.T) {
+	mode := "400"
+	subPath := "tls.crt"
+	deploy := DeploymentKubeAPI(testSolution().Deployments[0])
+	items := []SecretItem{{Key: "tls.crt"}, {Key: "tls.key", Path: "private/tls.key"}}
+	deploy.Containers[0].Secrets = []ContainerSecret{
+		{Name: "tls", MountPath: "/etc/tls", Mode: &mode, Items: items},
+		{Name: "tls", MountPath: "/etc/ssl/cert.pem", SubPath: &subPath, Mode: &mode, Items: items},
+	}
+
+	native, errs := deploy.ToKube("ns", map[string]string{})
+	if errs != nil {
+		t.Fatal(errs)
+	}
+	refs := GetObjectReferences(native)
+	if len(refs) != 2 || refs[0].Key != "tls.crt" || refs[1].Key != "tls.key" || refs[1].Name != "tls" {
+		t.Errorf("unexpected references: %+v", refs)
+	}
+
+	parsed, err := ParseKubeDeployment(native, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secrets := parsed.Containers[0].Secrets
+	if len(secrets) != 2 || len(parsed.Containers[0].VolumeMounts) != 1 {
+		t.Fatalf("unexpected mounts: %+v", parsed.Containers[0])
+	}
+	if secrets[0].Name != "tls" || *secrets[0].Mode != "400" || secrets[0].Items[1].Path != "private/tls.key" || secrets[0].Items[0].Path != "tls.crt" {
+		t.Errorf("unexpected secret mount: %+v", secrets[0])
+	}
+	if secrets[1].MountPath != "/etc/ssl/cert.pem" || *secrets[1].SubPath != "tls.crt" {
+		t.Errorf("unexpected secret mount: %+v", secrets[1])
+	}
+
+	deploy.Containers[0].Secrets[1].MountPath = "/etc/tls"
+	if _, errs := deploy.ToKube("ns", map[string]string{}); errs == nil {
+		t.Error("expected duplicate mount path error")
+	}
+
+	deploy.Containers[0].Secrets[1].MountPath = "/etc/ssl/cert.pem"
+	deploy.Containers[0].Secrets[1].Items = nil
+	if _, errs := deploy.ToKube("ns", map[string]string{}); errs == nil {
+		t.Error("expected conflicting mount error")
+	}
+
+	badMode := "rw"
+	deploy.Containers[0].Secrets = []ContainerSecret{
+		{Name: "tls", MountPath: "/etc/tls", Mode: &badMode, Items: []SecretItem{{Key: "tls.crt", Path: "../tls.crt"}, {Path: "/tls.key"}}},
+	}
+	if _, errs := deploy.ToKube("ns", map[string]string{}); len(errs) != 4 {
+		t.Errorf("expected 4 errors, got %v", errs)
+	}
+
+	deploy.Containers[0].Secrets = []ContainerSecret{
+		{Name: "tls", MountPath: "/etc/tls", Items: []SecretItem{{Key: "tls.crt", Path: "certs..d/tls.crt"}}},
+	}
+	if _, errs := deploy.ToKube("ns", map[string]string{}); errs != nil {
+		t.Errorf("unexpected errors for path with dots in name: %v", errs)
+	}
+}
+
+func TestDeploymentWithRefsJSON(t *testing.T) {
+	data := `{"name":"blog","replicas":1,"containers":[{"name":"blog","image":"ghost","limits":{"cpu":100,"memory":128},` +
+		`"env":[{"name":"DB_PASSWORD","value_from":{"secret_key_ref":{"name":"db","key":"password"}}}],"env_from":[{"config_map":"settings"}]}]}`
+	var deploy DeploymentKubeAPI
+	if err := json.Unmarshal([]byte(data), &deploy); err != nil {
+		t.Fatal(err)
+	}
+	if len(deploy.Containers) != 1 || deploy.Containers[0].Image != "ghost" ||
+		deploy.Containers[0].Env[0].ValueFrom.SecretKeyRef.Key != "password" || deploy.Containers[0].EnvFrom[0].ConfigMap != "settings" {
+		t.Errorf("unexpected deployment: %+v", deploy)
+	}
+
+	encoded, err := json.Marshal(deploy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded DeploymentKubeAPI
+	if err := json.Unmarshal(encoded, &decoded); err != nil || decoded.Containers[0].EnvFrom[0].ConfigMap != "settings" {
+		t.Errorf("references are lost after encoding: %s", encoded)
+	}
+}
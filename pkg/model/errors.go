@@ -45,6 +45,7 @@ var (
 const (
 	noContainer           = "container '%v' is not found in deployment"
 	fieldShouldExist      = "field '%v' should be provided"
+	exclusiveFields       = "only one of fields '%v' and '%v' should be provided"
 	invalidReplicas       = "invalid replicas number: %v. It must be between 1 and %v"
 	invalidPort           = "invalid port: %v. It must be between %v and %v"
 	invalidProtocol       = "invalid protocol: %v. It must be TCP or UDP"
@@ -53,11 +54,30 @@ const (
 	invalidIP             = "invalid IP: %v. It must be a valid IP address, (e.g. 10.9.8.7)"
 	invalidCPUQuota       = "invalid CPU quota: %v. It must be between %v(m) and %v(m)"
 	invalidMemoryQuota    = "invalid memory quota: %v. It must be between %v(Mi) and %v(Mi)"
+	invalidQuota          = "invalid %v quota: %v. It must be between %v and %v"
 	subPathRelative       = "invalid Sub Path: %v. It must be relative path"
+	invalidFileMode       = "invalid file mode: %v. It must be octal number"
 	noResource            = "resource '%v' is not found in %v"
 	noNamespace           = "project is not found"
 	resourceAlreadyExists = "resource '%v' already exists in %v"
 	duplicateMountPath    = "duplicate mount path '%v'"
+	conflictingMount      = "secret '%v' is mounted several times with different items or mode"
+	duplicatePort         = "duplicate port: %v"
+	duplicateKey          = "duplicate key '%v' in data and binary data"
+	configMapTooLarge     = "config map size %v bytes exceeds limit of %v bytes"
+	tooManyPorts          = "too many ports: %v. Maximum is %v"
+	noPodPort             = "TCP port %v is not declared in pod '%v'"
+	pathAbsolute          = "invalid path: %v. It must be absolute path"
+	pathRelative          = "invalid path: %v. It must be relative path"
+	pathTraversal         = "invalid path: %v. It must not contain '..'"
+	unsupportedKind       = "unsupported kind: %v %v"
+	unsupportedField      = "field '%v' is not supported"
+	notAllowedField       = "%v is not allowed"
+	foreignNamespace      = "namespace '%v' differs from '%v'"
+	unknownVolume         = "volume '%v' is not declared in pod template"
+	unsupportedOption     = "option '%v' is not supported by %v ingress controller"
+	managedAnnotation     = "annotation '%v' is managed by ingress options"
+	invalidOptionValue    = "invalid %v: %v"
 )
 
 //ParseKubernetesResourceError checks error status
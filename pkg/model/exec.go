@@ -0,0 +1,51 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	api_validation "k8s.io/apimachinery/pkg/util/validation"
+)
+
+// ExecRequest -- model for non-interactive command execution in container
+//
+// swagger:model
+type ExecRequest struct {
+	// command with arguments
+	//
+	// required: true
+	Command []string `json:"command"`
+	// container name, first pod container is used if empty
+	Container string `json:"container,omitempty"`
+}
+
+// ExecResult -- model for non-interactive command execution result
+//
+// swagger:model
+type ExecResult struct {
+	Stdout string `json:"stdout"`
+	Stderr string `json:"stderr"`
+	// true if stdout was longer than limit and was cut
+	StdoutTruncated bool `json:"stdout_truncated,omitempty"`
+	// true if stderr was longer than limit and was cut
+	StderrTruncated bool `json:"stderr_truncated,omitempty"`
+	ExitCode        int  `json:"exit_code"`
+	// command execution time in milliseconds
+	Duration int64 `json:"duration"`
+}
+
+func (exec *ExecRequest) Validate() []error {
+	var errs []error
+	if len(exec.Command) == 0 || exec.Command[0] == "" {
+		errs = append(errs, fmt.Errorf(fieldShouldExist, "command"))
+	}
+	if exec.Container != "" {
+		if err := api_validation.IsDNS1123Label(exec.Container); len(err) > 0 {
+			errs = append(errs, fmt.Errorf(invalidName, exec.Container, strings.Join(err, ",")))
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
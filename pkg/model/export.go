@@ -0,0 +1,219 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"path"
+	"strings"
+
+	"git.containerum.net/ch/kube-api/pkg/model/networking"
+	"github.com/ghodss/yaml"
+	api_apps "k8s.io/api/apps/v1"
+	api_core "k8s.io/api/core/v1"
+	api_extensions "k8s.io/api/extensions/v1beta1"
+)
+
+const (
+	configMapKind       = "ConfigMap"
+	configMapAPIVersion = "v1"
+)
+
+// annotations set by apiserver and controllers, they shouldn't be applied to another cluster
+var managedAnnotationPrefixes = []string{
+	"kubectl.kubernetes.io/last-applied-configuration",
+	"deployment.kubernetes.io/",
+	"pv.kubernetes.io/",
+	"volume.beta.kubernetes.io/storage-provisioner",
+	"control-plane.alpha.kubernetes.io/",
+}
+
+// NamespaceObjects -- user objects of namespace.
+// It has the same fields as objects returned by kubernetes.GetNamespaceObjects, so it can be converted directly.
+type NamespaceObjects struct {
+	Deployments            *api_apps.DeploymentList
+	Services               *api_core.ServiceList
+	Ingresses              *api_extensions.IngressList
+	ConfigMaps             *api_core.ConfigMapList
+	PersistentVolumeClaims *api_core.PersistentVolumeClaimList
+	// nil if secrets are not requested
+	Secrets *api_core.SecretList
+	// Ingress API version served by cluster, ingresses are exported in it
+	IngressGroupVersion string
+}
+
+// Manifest -- kubernetes object in YAML format
+type Manifest struct {
+	Kind string
+	Name string
+	Data []byte
+}
+
+// FileName returns path of manifest in export archive
+func (m Manifest) FileName() string {
+	return path.Join(strings.ToLower(m.Kind), m.Name+".yaml")
+}
+
+// MakeNamespaceManifests converts namespace objects to manifests which can be applied to another cluster.
+// Status, server-managed metadata and owner label are stripped.
+// If parseforuser is true, raw ingress annotations which are hidden from users are stripped too.
+// Manifests are ordered so that dependencies are created before objects using them.
+func MakeNamespaceManifests(objects interface{}, parseforuser bool) ([]Manifest, error) {
+	objs := objects.(*NamespaceObjects)
+
+	var manifests []Manifest
+	add := func(kind, apiVersion string, obj interface{}) error {
+		manifest, err := makeManifest(kind, apiVersion, obj)
+		if err != nil {
+			return err
+		}
+		manifests = append(manifests, manifest)
+		return nil
+	}
+
+	for _, cm := range objs.ConfigMaps.Items {
+		if err := add(configMapKind, configMapAPIVersion, cm); err != nil {
+			return nil, err
+		}
+	}
+	if objs.Secrets != nil {
+		for _, secret := range objs.Secrets.Items {
+			if secret.Type == api_core.SecretTypeServiceAccountToken {
+				// recreated by token controller
+				continue
+			}
+			if err := add(secretKind, secretAPIVersion, secret); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, pvc := range objs.PersistentVolumeClaims.Items {
+		pvc := pvc.DeepCopy()
+		// volume is bound by cluster
+		pvc.Spec.VolumeName = ""
+		if err := add(pvcKind, pvcAPIVersion, pvc); err != nil {
+			return nil, err
+		}
+	}
+	for _, svc := range objs.Services.Items {
+		svc := svc.DeepCopy()
+		// addresses and ports are allocated by cluster
+		if svc.Spec.ClusterIP != api_core.ClusterIPNone {
+			svc.Spec.ClusterIP = ""
+		}
+		for i := range svc.Spec.Ports {
+			svc.Spec.Ports[i].NodePort = 0
+		}
+		svc.Spec.HealthCheckNodePort = 0
+		if err := add(serviceKind, serviceAPIVersion, svc); err != nil {
+			return nil, err
+		}
+	}
+	for _, deploy := range objs.Deployments.Items {
+		deploy := deploy.DeepCopy()
+		// owner label and node role are set again when deployment is applied
+		if deploy.Spec.Selector != nil {
+			delete(deploy.Spec.Selector.MatchLabels, ownerLabel)
+		}
+		delete(deploy.Spec.Template.Labels, ownerLabel)
+		if deploy.Spec.Template.Spec.NodeSelector[nodeRoleLabel] == nodeRoleSlave {
+			delete(deploy.Spec.Template.Spec.NodeSelector, nodeRoleLabel)
+		}
+		if err := add(deploymentKind, deploymentAPIVersion, deploy); err != nil {
+			return nil, err
+		}
+	}
+	ingressGroupVersion := objs.IngressGroupVersion
+	if ingressGroupVersion == "" {
+		ingressGroupVersion = networking.ExtensionsV1beta1
+	}
+	for _, ingress := range objs.Ingresses.Items {
+		ingress := ingress.DeepCopy()
+		if parseforuser {
+			_, raw := parseIngressAnnotations(ingress.Annotations)
+			for k := range raw {
+				delete(ingress.Annotations, k)
+			}
+		}
+		data, err := networking.EncodeIngress(ingress, ingressGroupVersion)
+		if err != nil {
+			return nil, err
+		}
+		if err := add(ingressKind, ingressGroupVersion, json.RawMessage(data)); err != nil {
+			return nil, err
+		}
+	}
+
+	return manifests, nil
+}
+
+// JoinManifests makes multi-document YAML from manifests
+func JoinManifests(manifests []Manifest) []byte {
+	var buf bytes.Buffer
+	for _, manifest := range manifests {
+		buf.WriteString("---\n")
+		buf.Write(manifest.Data)
+	}
+	return buf.Bytes()
+}
+
+func makeManifest(kind, apiVersion string, obj interface{}) (Manifest, error) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return Manifest{}, err
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return Manifest{}, err
+	}
+
+	meta, _ := fields["metadata"].(map[string]interface{})
+	name, _ := meta["name"].(string)
+	fields["kind"] = kind
+	fields["apiVersion"] = apiVersion
+	fields["metadata"] = exportMetadata(meta)
+	delete(fields, "status")
+	if spec, isMap := fields["spec"].(map[string]interface{}); isMap {
+		if template, isMap := spec["template"].(map[string]interface{}); isMap {
+			if templateMeta, isMap := template["metadata"].(map[string]interface{}); isMap {
+				delete(templateMeta, "creationTimestamp")
+			}
+		}
+	}
+
+	data, err = yaml.Marshal(fields)
+	if err != nil {
+		return Manifest{}, err
+	}
+	return Manifest{
+		Kind: kind,
+		Name: name,
+		Data: data,
+	}, nil
+}
+
+// exportMetadata leaves only name, labels and annotations set by user
+func exportMetadata(meta map[string]interface{}) map[string]interface{} {
+	ret := map[string]interface{}{
+		"name": meta["name"],
+	}
+	if labels, isMap := meta["labels"].(map[string]interface{}); isMap {
+		delete(labels, ownerLabel)
+		if len(labels) > 0 {
+			ret["labels"] = labels
+		}
+	}
+	if annotations, isMap := meta["annotations"].(map[string]interface{}); isMap {
+		for key := range annotations {
+			for _, prefix := range managedAnnotationPrefixes {
+				if strings.HasPrefix(key, prefix) {
+					delete(annotations, key)
+					break
+				}
+			}
+		}
+		if len(annotations) > 0 {
+			ret["annotations"] = annotations
+		}
+	}
+	return ret
+}
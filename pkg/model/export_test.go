@@ -0,0 +1,191 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"git.containerum.net/ch/kube-api/pkg/model/networking"
+	"github.com/ghodss/yaml"
+	api_apps "k8s.io/api/apps/v1"
+	api_core "k8s.io/api/core/v1"
+	api_extensions "k8s.io/api/extensions/v1beta1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func testNamespaceObjects() *NamespaceObjects {
+	meta := func(name string) meta_v1.ObjectMeta {
+		return meta_v1.ObjectMeta{
+			Name:              name,
+			Namespace:         "ns",
+			UID:               "2f0c9d2e-1b1b-11e8-9b5c-fa163e1d3b13",
+			ResourceVersion:   "12345",
+			CreationTimestamp: meta_v1.Now(),
+			Labels:            map[string]string{ownerLabel: "user", appLabel: name},
+			Annotations: map[string]string{
+				"deployment.kubernetes.io/revision": "3",
+				"description":                       "kept",
+			},
+		}
+	}
+	return &NamespaceObjects{
+		Deployments: &api_apps.DeploymentList{Items: []api_apps.Deployment{{
+			ObjectMeta: meta("web"),
+			Spec: api_apps.DeploymentSpec{
+				Selector: &meta_v1.LabelSelector{MatchLabels: map[string]string{ownerLabel: "user", appLabel: "web"}},
+				Template: api_core.PodTemplateSpec{
+					ObjectMeta: meta_v1.ObjectMeta{Labels: map[string]string{ownerLabel: "user", appLabel: "web"}},
+					Spec: api_core.PodSpec{
+						NodeSelector: map[string]string{nodeRoleLabel: nodeRoleSlave, "disk": "ssd"},
+					},
+				},
+			},
+			Status: api_apps.DeploymentStatus{Replicas: 1},
+		}}},
+		Services: &api_core.ServiceList{Items: []api_core.Service{{
+			ObjectMeta: meta("web"),
+			Spec: api_core.ServiceSpec{
+				ClusterIP: "10.0.0.10",
+				Ports:     []api_core.ServicePort{{Port: 80, NodePort: 31080}},
+			},
+		}}},
+		Ingresses:  &api_extensions.IngressList{},
+		ConfigMaps: &api_core.ConfigMapList{Items: []api_core.ConfigMap{{ObjectMeta: meta("config")}}},
+		PersistentVolumeClaims: &api_core.PersistentVolumeClaimList{Items: []api_core.PersistentVolumeClaim{{
+			ObjectMeta: meta("data"),
+			Spec:       api_core.PersistentVolumeClaimSpec{VolumeName: "pvc-2f0c9d2e"},
+		}}},
+		Secrets: &api_core.SecretList{Items: []api_core.Secret{
+			{ObjectMeta: meta("tls"), Type: api_core.SecretTypeTLS},
+			{ObjectMeta: meta("default-token"), Type: api_core.SecretTypeServiceAccountToken},
+		}},
+	}
+}
+
+func TestMakeNamespaceManifests(t *testing.T) {
+	manifests, err := MakeNamespaceManifests(testNamespaceObjects(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, manifest := range manifests {
+		names = append(names, manifest.FileName())
+	}
+	expected := "configmap/config.yaml secret/tls.yaml persistentvolumeclaim/data.yaml service/web.yaml deployment/web.yaml"
+	if strings.Join(names, " ") != expected {
+		t.Fatalf("unexpected manifests: %v", names)
+	}
+
+	for _, manifest := range manifests {
+		var obj map[string]interface{}
+		if err := yaml.Unmarshal(manifest.Data, &obj); err != nil {
+			t.Fatal(err)
+		}
+		if obj["kind"] != manifest.Kind || obj["apiVersion"] == nil {
+			t.Errorf("%v: type is not set: %v %v", manifest.FileName(), obj["kind"], obj["apiVersion"])
+		}
+		if _, hasStatus := obj["status"]; hasStatus {
+			t.Errorf("%v: status is not stripped", manifest.FileName())
+		}
+		meta := obj["metadata"].(map[string]interface{})
+		for _, field := range []string{"uid", "resourceVersion", "creationTimestamp", "namespace"} {
+			if _, ok := meta[field]; ok {
+				t.Errorf("%v: metadata %v is not stripped", manifest.FileName(), field)
+			}
+		}
+		labels := meta["labels"].(map[string]interface{})
+		if _, ok := labels[ownerLabel]; ok || labels[appLabel] == nil {
+			t.Errorf("%v: unexpected labels %v", manifest.FileName(), labels)
+		}
+		annotations := meta["annotations"].(map[string]interface{})
+		if len(annotations) != 1 || annotations["description"] != "kept" {
+			t.Errorf("%v: unexpected annotations %v", manifest.FileName(), annotations)
+		}
+	}
+
+	svc := string(manifests[3].Data)
+	if strings.Contains(svc, "10.0.0.10") || strings.Contains(svc, "31080") {
+		t.Errorf("service allocated fields are not stripped:\n%v", svc)
+	}
+	deploy := string(manifests[4].Data)
+	if strings.Contains(deploy, ownerLabel) || strings.Contains(deploy, nodeRoleSlave) || !strings.Contains(deploy, "disk: ssd") {
+		t.Errorf("deployment owner label and node role are not stripped:\n%v", deploy)
+	}
+	if strings.Contains(string(manifests[2].Data), "pvc-2f0c9d2e") {
+		t.Errorf("volume name is not stripped:\n%s", manifests[2].Data)
+	}
+}
+
+func TestMakeNamespaceManifestsWithoutSecrets(t *testing.T) {
+	objects := testNamespaceObjects()
+	objects.Secrets = nil
+	manifests, err := MakeNamespaceManifests(objects, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, manifest := range manifests {
+		if manifest.Kind == secretKind {
+			t.Errorf("unexpected secret %v", manifest.Name)
+		}
+	}
+	if docs := strings.Count(string(JoinManifests(manifests)), "---\n"); docs != len(manifests) {
+		t.Errorf("expected %v documents, got %v", len(manifests), docs)
+	}
+}
+
+func TestMakeNamespaceManifestsIngressV1(t *testing.T) {
+	objects := testNamespaceObjects()
+	objects.IngressGroupVersion = networking.NetworkingV1
+	objects.Ingresses.Items = []api_extensions.Ingress{{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:            "web",
+			Namespace:       "ns",
+			ResourceVersion: "12345",
+			Annotations: map[string]string{
+				ingressClassAnnotation:                       "nginx",
+				"nginx.ingress.kubernetes.io/rewrite-target": "/",
+				"example.com/team":                           "web",
+			},
+		},
+		Spec: api_extensions.IngressSpec{
+			Rules: []api_extensions.IngressRule{{
+				Host: "web.hub.containerum.io",
+				IngressRuleValue: api_extensions.IngressRuleValue{HTTP: &api_extensions.HTTPIngressRuleValue{
+					Paths: []api_extensions.HTTPIngressPath{{
+						Path:    "/",
+						Backend: api_extensions.IngressBackend{ServiceName: "web", ServicePort: intstr.FromInt(80)},
+					}},
+				}},
+			}},
+		},
+	}}
+	manifests, err := MakeNamespaceManifests(objects, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ingress := manifests[len(manifests)-1]
+	if strings.Contains(string(ingress.Data), "example.com/team") {
+		t.Errorf("raw annotation is not stripped for user:\n%s", ingress.Data)
+	}
+	if ingress.Kind != ingressKind || !strings.Contains(string(ingress.Data), "apiVersion: "+networking.NetworkingV1) {
+		t.Fatalf("ingress is not exported in %v:\n%s", networking.NetworkingV1, ingress.Data)
+	}
+
+	// exported ingress can be applied back
+	decoded, err := DecodeManifests(ingress.Data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kubeObj, errs := ManifestToKube(decoded[0], "ns", map[string]string{ownerLabel: "user"})
+	if errs != nil {
+		t.Fatal(errs)
+	}
+	applied := kubeObj.(*api_extensions.Ingress)
+	if len(applied.Spec.Rules) != 1 || applied.Spec.Rules[0].HTTP.Paths[0].Backend.ServiceName != "web" {
+		t.Errorf("unexpected applied ingress %+v", applied.Spec)
+	}
+	if applied.Annotations["nginx.ingress.kubernetes.io/rewrite-target"] != "/" {
+		t.Errorf("ingress options are lost: %v", applied.Annotations)
+	}
+}
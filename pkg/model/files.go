@@ -0,0 +1,44 @@
+package model
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// ValidateContainerPath checks that path to file in container is absolute and doesn't go up the tree
+func ValidateContainerPath(containerPath string) []error {
+	var errs []error
+	switch {
+	case containerPath == "":
+		errs = append(errs, fmt.Errorf(fieldShouldExist, "path"))
+	case !path.IsAbs(containerPath):
+		errs = append(errs, fmt.Errorf(pathAbsolute, containerPath))
+	case hasDotDot(containerPath):
+		errs = append(errs, fmt.Errorf(pathTraversal, containerPath))
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// ValidateArchivePath checks that tar entry name and link target stay inside of extraction directory
+func ValidateArchivePath(name string) error {
+	if path.IsAbs(name) {
+		return fmt.Errorf(pathRelative, name)
+	}
+	if cleaned := path.Clean(name); cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return fmt.Errorf(pathTraversal, name)
+	}
+	return nil
+}
+
+func hasDotDot(p string) bool {
+	for _, part := range strings.Split(p, "/") {
+		if part == ".." {
+			return true
+		}
+	}
+	return false
+}
@@ -20,15 +20,45 @@ const (
 	ingressAPIVersion = "extensions/v1beta1"
 )
 
-type IngressKubeAPI kube_types.Ingress
+// SelectedIngressesWithOptionsList -- model for ingresses list from all namespaces
+//
+// swagger:model
+type SelectedIngressesWithOptionsList map[string]IngressWithOptionsList
+
+// IngressWithOptionsList -- model for ingresses list
+//
+// swagger:model
+type IngressWithOptionsList struct {
+	Ingress []IngressWithOptions `json:"ingresses"`
+}
+
+// IngressWithOptions -- model for ingress with routing options
+//
+// swagger:model
+type IngressWithOptions struct {
+	// swagger: allOf
+	kube_types.Ingress
+	// routing options translated to ingress controller annotations
+	Options *IngressOptions `json:"options,omitempty" yaml:"options,omitempty"`
+	// raw ingress controller annotations, not available for users
+	Annotations map[string]string `json:"annotations,omitempty" yaml:"annotations,omitempty"`
+}
+
+// Mask removes information not interesting for users
+func (ingress *IngressWithOptions) Mask() {
+	ingress.Ingress.Mask()
+	ingress.Annotations = nil
+}
+
+type IngressKubeAPI IngressWithOptions
 
 // ParseKubeIngressList parses kubernetes v1beta1.IngressList to more convenient []Ingress struct
-func ParseKubeIngressList(ingressi interface{}, parseforuser bool) (*kube_types.IngressesList, error) {
+func ParseKubeIngressList(ingressi interface{}, parseforuser bool) (*IngressWithOptionsList, error) {
 	ingresses := ingressi.(*api_extensions.IngressList)
 	if ingresses == nil {
 		return nil, ErrUnableConvertIngressList
 	}
-	newIngresses := make([]kube_types.Ingress, 0)
+	newIngresses := make([]IngressWithOptions, 0)
 	for _, ingress := range ingresses.Items {
 		newIngress, err := ParseKubeIngress(&ingress, parseforuser)
 		if err != nil {
@@ -36,23 +66,26 @@ func ParseKubeIngressList(ingressi interface{}, parseforuser bool) (*kube_types.
 		}
 		newIngresses = append(newIngresses, *newIngress)
 	}
-	return &kube_types.IngressesList{Ingress: newIngresses}, nil
+	return &IngressWithOptionsList{Ingress: newIngresses}, nil
 }
 
 // ParseKubeIngress parses kubernetes v1beta1.Ingress to more convenient Ingress struct
-func ParseKubeIngress(ingressi interface{}, parseforuser bool) (*kube_types.Ingress, error) {
+func ParseKubeIngress(ingressi interface{}, parseforuser bool) (*IngressWithOptions, error) {
 	ingress := ingressi.(*api_extensions.Ingress)
 	if ingress == nil {
 		return nil, ErrUnableConvertIngress
 	}
 
-	newIngress := kube_types.Ingress{
-		Name:      ingress.GetName(),
-		Namespace: ingress.Namespace,
-		CreatedAt: ingress.CreationTimestamp.UTC().Format(time.RFC3339),
-		Rules:     parseRules(ingress.Spec.Rules, parseTLS(ingress.Spec.TLS)),
-		Owner:     ingress.GetObjectMeta().GetLabels()[ownerLabel],
+	newIngress := IngressWithOptions{
+		Ingress: kube_types.Ingress{
+			Name:      ingress.GetName(),
+			Namespace: ingress.Namespace,
+			CreatedAt: ingress.CreationTimestamp.UTC().Format(time.RFC3339),
+			Rules:     parseRules(ingress.Spec.Rules, parseTLS(ingress.Spec.TLS)),
+			Owner:     ingress.GetObjectMeta().GetLabels()[ownerLabel],
+		},
 	}
+	newIngress.Options, newIngress.Annotations = parseIngressAnnotations(ingress.Annotations)
 
 	if parseforuser {
 		newIngress.Mask()
@@ -96,8 +129,10 @@ func parseTLS(tlss []api_extensions.IngressTLS) map[string]string {
 	return secrets
 }
 
-// ToKube creates kubernetes v1beta1.Ingress from Ingress struct and namespace labels
-func (ingress *IngressKubeAPI) ToKube(nsName string, labels map[string]string) (*api_extensions.Ingress, []error) {
+// ToKube creates kubernetes v1beta1.Ingress from Ingress struct and namespace labels.
+// Options are translated to annotations of configured ingress controller.
+// If solutionID is not empty, ingress is labeled as part of solution.
+func (ingress *IngressKubeAPI) ToKube(nsName string, solutionID string, labels map[string]string) (*api_extensions.Ingress, []error) {
 	err := ingress.Validate()
 	if err != nil {
 		return nil, err
@@ -105,9 +140,17 @@ func (ingress *IngressKubeAPI) ToKube(nsName string, labels map[string]string) (
 	if labels == nil {
 		return nil, []error{kubeerrors.ErrInternalError().AddDetails("invalid project labels")}
 	}
+	if solutionID != "" {
+		labels[solutionLabel] = solutionID
+	}
 
 	rules, secrets, tls := makeIngressRules(ingress.Rules)
 
+	annotations, errs := makeIngressAnnotations(ingress.Options, ingress.Annotations, tls)
+	if errs != nil {
+		return nil, errs
+	}
+
 	newIngress := api_extensions.Ingress{
 		TypeMeta: api_meta.TypeMeta{
 			Kind:       ingressKind,
@@ -117,17 +160,13 @@ func (ingress *IngressKubeAPI) ToKube(nsName string, labels map[string]string) (
 			Labels:      labels,
 			Name:        ingress.Name,
 			Namespace:   nsName,
-			Annotations: map[string]string{"kubernetes.io/ingress.class": "nginx"},
+			Annotations: annotations,
 		},
 		Spec: api_extensions.IngressSpec{
 			Rules: rules,
 			TLS:   secrets,
 		},
 	}
-
-	if tls {
-		newIngress.ObjectMeta.Annotations["kubernetes.io/tls-acme"] = "true"
-	}
 	return &newIngress, nil
 }
 
@@ -0,0 +1,481 @@
+package model
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	api_extensions "k8s.io/api/extensions/v1beta1"
+	api_validation "k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	ingressClassAnnotation = "kubernetes.io/ingress.class"
+	tlsAcmeAnnotation      = "kubernetes.io/tls-acme"
+
+	maxIngressTimeout = 3600    // seconds
+	maxIngressMaxAge  = 1 << 30 // seconds, for cookies and CORS preflight cache
+)
+
+// ingress options, names are paths of fields in IngressOptions to be used in errors
+const (
+	optRewriteTarget   = "rewrite_target"
+	optMaxBodySize     = "max_body_size"
+	optConnectTimeout  = "connect_timeout"
+	optReadTimeout     = "read_timeout"
+	optSendTimeout     = "send_timeout"
+	optWhitelist       = "whitelist"
+	optBasicAuth       = "basic_auth"
+	optBasicAuthSecret = "basic_auth.secret"
+	optBasicAuthRealm  = "basic_auth.realm"
+	optSticky          = "sticky_sessions"
+	optStickyCookie    = "sticky_sessions.cookie_name"
+	optStickyMaxAge    = "sticky_sessions.max_age"
+	optCORS            = "cors"
+	optCORSOrigin      = "cors.allow_origin"
+	optCORSMethods     = "cors.allow_methods"
+	optCORSHeaders     = "cors.allow_headers"
+	optCORSCredentials = "cors.allow_credentials"
+	optCORSMaxAge      = "cors.max_age"
+)
+
+const (
+	ingressControllerNginx   = "nginx"
+	ingressControllerTraefik = "traefik"
+
+	basicAuthType = "basic"
+)
+
+// values of options are checked to not break controller configuration
+var (
+	rewriteTargetRegexp = regexp.MustCompile(`^/[A-Za-z0-9/_.~$%-]*$`)
+	bodySizeRegexp      = regexp.MustCompile(`^[0-9]+[kKmMgG]?$`)
+	authRealmRegexp     = regexp.MustCompile(`^[A-Za-z0-9 _.-]+$`)
+	cookieNameRegexp    = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+	corsOriginRegexp    = regexp.MustCompile(`^(\*|https?://[A-Za-z0-9.-]+(:[0-9]+)?)$`)
+	headerNameRegexp    = regexp.MustCompile(`^[A-Za-z0-9-]+$`)
+	httpMethods         = map[string]bool{"GET": true, "HEAD": true, "POST": true, "PUT": true, "PATCH": true, "DELETE": true, "OPTIONS": true}
+)
+
+// IngressOptions -- ingress routing options
+//
+// swagger:model
+type IngressOptions struct {
+	// path which requests are rewritten to
+	RewriteTarget string `json:"rewrite_target,omitempty" yaml:"rewrite_target,omitempty"`
+	// maximum request body size, e.g. 8m
+	MaxBodySize string `json:"max_body_size,omitempty" yaml:"max_body_size,omitempty"`
+	// timeouts of connection to service in seconds
+	ConnectTimeout *int `json:"connect_timeout,omitempty" yaml:"connect_timeout,omitempty"`
+	ReadTimeout    *int `json:"read_timeout,omitempty" yaml:"read_timeout,omitempty"`
+	SendTimeout    *int `json:"send_timeout,omitempty" yaml:"send_timeout,omitempty"`
+	// client IPs or CIDRs allowed to access ingress
+	Whitelist []string `json:"whitelist,omitempty" yaml:"whitelist,omitempty"`
+	// basic authentication with htpasswd file from "auth" key of secret
+	BasicAuth *IngressBasicAuth `json:"basic_auth,omitempty" yaml:"basic_auth,omitempty"`
+	// cookie based session affinity
+	StickySessions *IngressStickySessions `json:"sticky_sessions,omitempty" yaml:"sticky_sessions,omitempty"`
+	CORS           *IngressCORS           `json:"cors,omitempty" yaml:"cors,omitempty"`
+}
+
+// IngressBasicAuth -- basic authentication options
+//
+// swagger:model
+type IngressBasicAuth struct {
+	// required: true
+	Secret string `json:"secret" yaml:"secret"`
+	Realm  string `json:"realm,omitempty" yaml:"realm,omitempty"`
+}
+
+// IngressStickySessions -- session affinity options
+//
+// swagger:model
+type IngressStickySessions struct {
+	CookieName string `json:"cookie_name,omitempty" yaml:"cookie_name,omitempty"`
+	// cookie max age in seconds
+	MaxAge *int `json:"max_age,omitempty" yaml:"max_age,omitempty"`
+}
+
+// IngressCORS -- cross-origin resource sharing options
+//
+// swagger:model
+type IngressCORS struct {
+	AllowOrigin      string   `json:"allow_origin,omitempty" yaml:"allow_origin,omitempty"`
+	AllowMethods     []string `json:"allow_methods,omitempty" yaml:"allow_methods,omitempty"`
+	AllowHeaders     []string `json:"allow_headers,omitempty" yaml:"allow_headers,omitempty"`
+	AllowCredentials bool     `json:"allow_credentials,omitempty" yaml:"allow_credentials,omitempty"`
+	// preflight response max age in seconds
+	MaxAge *int `json:"max_age,omitempty" yaml:"max_age,omitempty"`
+}
+
+// ingressController -- ingress class and annotations of supported options
+type ingressController struct {
+	name  string
+	class string
+	// option -> annotation
+	annotations map[string]string
+	// value of session affinity annotation
+	affinity string
+}
+
+func nginxIngressController() *ingressController {
+	const prefix = "nginx.ingress.kubernetes.io/"
+	return &ingressController{
+		name:  ingressControllerNginx,
+		class: "nginx",
+		annotations: map[string]string{
+			optRewriteTarget:   prefix + "rewrite-target",
+			optMaxBodySize:     prefix + "proxy-body-size",
+			optConnectTimeout:  prefix + "proxy-connect-timeout",
+			optReadTimeout:     prefix + "proxy-read-timeout",
+			optSendTimeout:     prefix + "proxy-send-timeout",
+			optWhitelist:       prefix + "whitelist-source-range",
+			optBasicAuth:       prefix + "auth-type",
+			optBasicAuthSecret: prefix + "auth-secret",
+			optBasicAuthRealm:  prefix + "auth-realm",
+			optSticky:          prefix + "affinity",
+			optStickyCookie:    prefix + "session-cookie-name",
+			optStickyMaxAge:    prefix + "session-cookie-max-age",
+			optCORS:            prefix + "enable-cors",
+			optCORSOrigin:      prefix + "cors-allow-origin",
+			optCORSMethods:     prefix + "cors-allow-methods",
+			optCORSHeaders:     prefix + "cors-allow-headers",
+			optCORSCredentials: prefix + "cors-allow-credentials",
+			optCORSMaxAge:      prefix + "cors-max-age",
+		},
+		affinity: "cookie",
+	}
+}
+
+func traefikIngressController() *ingressController {
+	const prefix = "traefik.ingress.kubernetes.io/"
+	return &ingressController{
+		name:  ingressControllerTraefik,
+		class: "traefik",
+		annotations: map[string]string{
+			optRewriteTarget:   prefix + "rewrite-target",
+			optWhitelist:       prefix + "whitelist-source-range",
+			optBasicAuth:       "ingress.kubernetes.io/auth-type",
+			optBasicAuthSecret: "ingress.kubernetes.io/auth-secret",
+			optSticky:          prefix + "affinity",
+			optStickyCookie:    prefix + "session-cookie-name",
+		},
+		affinity: "true",
+	}
+}
+
+var ingressControllers = map[string]func() *ingressController{
+	ingressControllerNginx:   nginxIngressController,
+	ingressControllerTraefik: traefikIngressController,
+}
+
+var ingressCtrl = nginxIngressController()
+
+// SetIngressController sets flavour of ingress controller used for ingress class and options annotations
+func SetIngressController(name string) error {
+	newController, ok := ingressControllers[name]
+	if !ok {
+		return fmt.Errorf("unsupported ingress controller %q, expected %q or %q", name, ingressControllerNginx, ingressControllerTraefik)
+	}
+	ingressCtrl = newController()
+	return nil
+}
+
+// ValidateUserInput checks that ingress from user doesn't contain raw annotations
+func (ingress *IngressKubeAPI) ValidateUserInput() []error {
+	if len(ingress.Annotations) > 0 {
+		return []error{fmt.Errorf(notAllowedField, "setting annotations")}
+	}
+	return nil
+}
+
+// CopyUnmanagedAnnotations sets raw annotations to annotations of old ingress not managed by kube-api.
+// Users can't see raw annotations, so they are kept on update.
+func (ingress *IngressKubeAPI) CopyUnmanagedAnnotations(old *api_extensions.Ingress) {
+	managed := managedIngressAnnotations()
+	ingress.Annotations = nil
+	for k, v := range old.Annotations {
+		if managed[k] {
+			continue
+		}
+		if ingress.Annotations == nil {
+			ingress.Annotations = make(map[string]string)
+		}
+		ingress.Annotations[k] = v
+	}
+}
+
+// managedIngressAnnotations returns annotations set by kube-api from ingress class and options
+func managedIngressAnnotations() map[string]bool {
+	managed := map[string]bool{ingressClassAnnotation: true, tlsAcmeAnnotation: true}
+	for _, annotation := range ingressCtrl.annotations {
+		managed[annotation] = true
+	}
+	return managed
+}
+
+// makeIngressAnnotations returns ingress class, options and raw annotations
+func makeIngressAnnotations(options *IngressOptions, raw map[string]string, tls bool) (map[string]string, []error) {
+	values, errs := encodeIngressOptions(options)
+
+	annotations := map[string]string{ingressClassAnnotation: ingressCtrl.class}
+	if tls {
+		annotations[tlsAcmeAnnotation] = "true"
+	}
+	for _, opt := range sortedKeys(values) {
+		annotation, supported := ingressCtrl.annotations[opt]
+		if !supported {
+			errs = append(errs, fmt.Errorf(unsupportedOption, opt, ingressCtrl.name))
+			continue
+		}
+		annotations[annotation] = values[opt]
+	}
+
+	managed := managedIngressAnnotations()
+	for _, k := range sortedKeys(raw) {
+		if managed[k] {
+			errs = append(errs, fmt.Errorf(managedAnnotation, k))
+			continue
+		}
+		if err := api_validation.IsQualifiedName(k); len(err) > 0 {
+			errs = append(errs, fmt.Errorf(invalidName, k, strings.Join(err, ",")))
+			continue
+		}
+		annotations[k] = raw[k]
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return annotations, nil
+}
+
+// encodeIngressOptions validates options and returns their annotation values
+func encodeIngressOptions(options *IngressOptions) (map[string]string, []error) {
+	values := make(map[string]string)
+	if options == nil {
+		return values, nil
+	}
+	var errs []error
+	invalid := func(opt string, value interface{}) {
+		errs = append(errs, fmt.Errorf(invalidOptionValue, opt, value))
+	}
+	setSeconds := func(opt string, value *int, min, max int) {
+		if value == nil {
+			return
+		}
+		if *value < min || *value > max {
+			invalid(opt, *value)
+		}
+		values[opt] = strconv.Itoa(*value)
+	}
+
+	if options.RewriteTarget != "" {
+		if !rewriteTargetRegexp.MatchString(options.RewriteTarget) {
+			invalid(optRewriteTarget, options.RewriteTarget)
+		}
+		values[optRewriteTarget] = options.RewriteTarget
+	}
+	if options.MaxBodySize != "" {
+		if !bodySizeRegexp.MatchString(options.MaxBodySize) {
+			invalid(optMaxBodySize, options.MaxBodySize)
+		}
+		values[optMaxBodySize] = options.MaxBodySize
+	}
+	setSeconds(optConnectTimeout, options.ConnectTimeout, 1, maxIngressTimeout)
+	setSeconds(optReadTimeout, options.ReadTimeout, 1, maxIngressTimeout)
+	setSeconds(optSendTimeout, options.SendTimeout, 1, maxIngressTimeout)
+
+	if len(options.Whitelist) > 0 {
+		for _, source := range options.Whitelist {
+			if _, _, err := net.ParseCIDR(source); err != nil && net.ParseIP(source) == nil {
+				errs = append(errs, fmt.Errorf(invalidIP, source))
+			}
+		}
+		values[optWhitelist] = strings.Join(options.Whitelist, ",")
+	}
+
+	if auth := options.BasicAuth; auth != nil {
+		if auth.Secret == "" {
+			errs = append(errs, fmt.Errorf(fieldShouldExist, optBasicAuthSecret))
+		} else if err := api_validation.IsDNS1123Subdomain(auth.Secret); len(err) > 0 {
+			errs = append(errs, fmt.Errorf(invalidName, auth.Secret, strings.Join(err, ",")))
+		}
+		values[optBasicAuth] = basicAuthType
+		values[optBasicAuthSecret] = auth.Secret
+		if auth.Realm != "" {
+			if !authRealmRegexp.MatchString(auth.Realm) {
+				invalid(optBasicAuthRealm, auth.Realm)
+			}
+			values[optBasicAuthRealm] = auth.Realm
+		}
+	}
+
+	if sticky := options.StickySessions; sticky != nil {
+		values[optSticky] = ingressCtrl.affinity
+		if sticky.CookieName != "" {
+			if !cookieNameRegexp.MatchString(sticky.CookieName) {
+				invalid(optStickyCookie, sticky.CookieName)
+			}
+			values[optStickyCookie] = sticky.CookieName
+		}
+		setSeconds(optStickyMaxAge, sticky.MaxAge, 1, maxIngressMaxAge)
+	}
+
+	if cors := options.CORS; cors != nil {
+		values[optCORS] = "true"
+		if cors.AllowOrigin != "" {
+			if !corsOriginRegexp.MatchString(cors.AllowOrigin) {
+				invalid(optCORSOrigin, cors.AllowOrigin)
+			}
+			values[optCORSOrigin] = cors.AllowOrigin
+		}
+		if len(cors.AllowMethods) > 0 {
+			for _, method := range cors.AllowMethods {
+				if !httpMethods[method] {
+					invalid(optCORSMethods, method)
+				}
+			}
+			values[optCORSMethods] = strings.Join(cors.AllowMethods, ", ")
+		}
+		if len(cors.AllowHeaders) > 0 {
+			for _, header := range cors.AllowHeaders {
+				if !headerNameRegexp.MatchString(header) {
+					invalid(optCORSHeaders, header)
+				}
+			}
+			values[optCORSHeaders] = strings.Join(cors.AllowHeaders, ", ")
+		}
+		if cors.AllowCredentials {
+			values[optCORSCredentials] = "true"
+		}
+		setSeconds(optCORSMaxAge, cors.MaxAge, 0, maxIngressMaxAge)
+	}
+	return values, errs
+}
+
+// parseIngressAnnotations returns options of configured ingress controller and annotations not managed by kube-api
+func parseIngressAnnotations(annotations map[string]string) (*IngressOptions, map[string]string) {
+	raw := make(map[string]string)
+	for k, v := range annotations {
+		if k != ingressClassAnnotation && k != tlsAcmeAnnotation {
+			raw[k] = v
+		}
+	}
+
+	var options IngressOptions
+	found := false
+	// parent options are sorted before their sub-options
+	for _, opt := range sortedKeys(ingressCtrl.annotations) {
+		annotation := ingressCtrl.annotations[opt]
+		value, ok := raw[annotation]
+		if !ok || !decodeIngressOption(&options, opt, value) {
+			continue
+		}
+		delete(raw, annotation)
+		found = true
+	}
+
+	var parsedOptions *IngressOptions
+	if found {
+		parsedOptions = &options
+	}
+	if len(raw) == 0 {
+		raw = nil
+	}
+	return parsedOptions, raw
+}
+
+// decodeIngressOption sets option from annotation value, returns false if value is not valid
+func decodeIngressOption(options *IngressOptions, opt, value string) bool {
+	seconds := func() *int {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return nil
+		}
+		return &n
+	}
+	list := func() []string {
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		return items
+	}
+	// sub-options are decoded only if parent option was decoded before them
+	if (strings.HasPrefix(opt, optBasicAuth+".") && options.BasicAuth == nil) ||
+		(strings.HasPrefix(opt, optSticky+".") && options.StickySessions == nil) ||
+		(strings.HasPrefix(opt, optCORS+".") && options.CORS == nil) {
+		return false
+	}
+
+	switch opt {
+	case optRewriteTarget:
+		options.RewriteTarget = value
+	case optMaxBodySize:
+		options.MaxBodySize = value
+	case optConnectTimeout:
+		options.ConnectTimeout = seconds()
+		return options.ConnectTimeout != nil
+	case optReadTimeout:
+		options.ReadTimeout = seconds()
+		return options.ReadTimeout != nil
+	case optSendTimeout:
+		options.SendTimeout = seconds()
+		return options.SendTimeout != nil
+	case optWhitelist:
+		options.Whitelist = list()
+	case optBasicAuth:
+		if value != basicAuthType {
+			return false
+		}
+		options.BasicAuth = &IngressBasicAuth{}
+	case optBasicAuthSecret:
+		options.BasicAuth.Secret = value
+	case optBasicAuthRealm:
+		options.BasicAuth.Realm = value
+	case optSticky:
+		if value != ingressCtrl.affinity {
+			return false
+		}
+		options.StickySessions = &IngressStickySessions{}
+	case optStickyCookie:
+		options.StickySessions.CookieName = value
+	case optStickyMaxAge:
+		options.StickySessions.MaxAge = seconds()
+		return options.StickySessions.MaxAge != nil
+	case optCORS:
+		if value != "true" {
+			return false
+		}
+		options.CORS = &IngressCORS{}
+	case optCORSOrigin:
+		options.CORS.AllowOrigin = value
+	case optCORSMethods:
+		options.CORS.AllowMethods = list()
+	case optCORSHeaders:
+		options.CORS.AllowHeaders = list()
+	case optCORSCredentials:
+		options.CORS.AllowCredentials = value == "true"
+	case optCORSMaxAge:
+		options.CORS.MaxAge = seconds()
+		return options.CORS.MaxAge != nil
+	default:
+		return false
+	}
+	return true
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,189 @@
+package model
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	kube_types "github.com/containerum/kube-client/pkg/model"
+)
+
+func testIngressWithOptions() IngressKubeAPI {
+	timeout, maxAge := 120, 3600
+	return IngressKubeAPI{
+		Ingress: kube_types.Ingress{
+			Name: "web",
+			Rules: []kube_types.Rule{{
+				Host: "web.example.com",
+				Path: []kube_types.Path{{Path: "/api", ServiceName: "web", ServicePort: 80}},
+			}},
+		},
+		Options: &IngressOptions{
+			RewriteTarget: "/",
+			MaxBodySize:   "8m",
+			ReadTimeout:   &timeout,
+			Whitelist:     []string{"10.0.0.0/8", "192.168.1.1"},
+			BasicAuth:     &IngressBasicAuth{Secret: "web-auth", Realm: "Restricted"},
+			StickySessions: &IngressStickySessions{
+				CookieName: "route",
+				MaxAge:     &maxAge,
+			},
+			CORS: &IngressCORS{
+				AllowOrigin:      "https://example.com",
+				AllowMethods:     []string{"GET", "POST"},
+				AllowCredentials: true,
+			},
+		},
+	}
+}
+
+func TestIngressOptionsNginx(t *testing.T) {
+	ingress := testIngressWithOptions()
+	native, errs := ingress.ToKube("ns", "", map[string]string{})
+	if errs != nil {
+		t.Fatal(errs)
+	}
+	expected := map[string]string{
+		"kubernetes.io/ingress.class":                        "nginx",
+		"nginx.ingress.kubernetes.io/rewrite-target":         "/",
+		"nginx.ingress.kubernetes.io/proxy-body-size":        "8m",
+		"nginx.ingress.kubernetes.io/proxy-read-timeout":     "120",
+		"nginx.ingress.kubernetes.io/whitelist-source-range": "10.0.0.0/8,192.168.1.1",
+		"nginx.ingress.kubernetes.io/auth-type":              "basic",
+		"nginx.ingress.kubernetes.io/auth-secret":            "web-auth",
+		"nginx.ingress.kubernetes.io/auth-realm":             "Restricted",
+		"nginx.ingress.kubernetes.io/affinity":               "cookie",
+		"nginx.ingress.kubernetes.io/session-cookie-name":    "route",
+		"nginx.ingress.kubernetes.io/session-cookie-max-age": "3600",
+		"nginx.ingress.kubernetes.io/enable-cors":            "true",
+		"nginx.ingress.kubernetes.io/cors-allow-origin":      "https://example.com",
+		"nginx.ingress.kubernetes.io/cors-allow-methods":     "GET, POST",
+		"nginx.ingress.kubernetes.io/cors-allow-credentials": "true",
+	}
+	if !reflect.DeepEqual(native.Annotations, expected) {
+		t.Errorf("unexpected annotations:\n%v\nexpected:\n%v", native.Annotations, expected)
+	}
+
+	parsed, err := ParseKubeIngress(native, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(parsed.Options, ingress.Options) {
+		t.Errorf("unexpected parsed options:\n%+v\nexpected:\n%+v", parsed.Options, ingress.Options)
+	}
+	if parsed.Annotations != nil {
+		t.Errorf("unexpected raw annotations: %v", parsed.Annotations)
+	}
+}
+
+func TestIngressOptionsTraefik(t *testing.T) {
+	if err := SetIngressController("traefik"); err != nil {
+		t.Fatal(err)
+	}
+	defer SetIngressController("nginx")
+
+	ingress := testIngressWithOptions()
+	_, errs := ingress.ToKube("ns", "", map[string]string{})
+	var messages []string
+	for _, err := range errs {
+		messages = append(messages, err.Error())
+	}
+	for _, opt := range []string{"max_body_size", "read_timeout", "basic_auth.realm", "sticky_sessions.max_age", "cors"} {
+		if !strings.Contains(strings.Join(messages, "\n"), "option '"+opt+"' is not supported") {
+			t.Errorf("expected %v to be unsupported, got %v", opt, messages)
+		}
+	}
+
+	ingress.Options = &IngressOptions{
+		RewriteTarget:  "/",
+		StickySessions: &IngressStickySessions{CookieName: "route"},
+	}
+	native, errs := ingress.ToKube("ns", "", map[string]string{})
+	if errs != nil {
+		t.Fatal(errs)
+	}
+	if native.Annotations["kubernetes.io/ingress.class"] != "traefik" ||
+		native.Annotations["traefik.ingress.kubernetes.io/rewrite-target"] != "/" ||
+		native.Annotations["traefik.ingress.kubernetes.io/affinity"] != "true" {
+		t.Errorf("unexpected annotations: %v", native.Annotations)
+	}
+}
+
+func TestIngressOptionsInvalid(t *testing.T) {
+	ingress := testIngressWithOptions()
+	timeout := 0
+	ingress.Options.RewriteTarget = "/;\n more_set_headers"
+	ingress.Options.MaxBodySize = "8 megabytes"
+	ingress.Options.ConnectTimeout = &timeout
+	ingress.Options.Whitelist = []string{"10.0.0.0/33"}
+	ingress.Options.CORS.AllowOrigin = "'*'"
+	if _, errs := ingress.ToKube("ns", "", map[string]string{}); len(errs) != 5 {
+		t.Errorf("expected 5 errors, got %v", errs)
+	}
+}
+
+func TestIngressRawAnnotations(t *testing.T) {
+	ingress := testIngressWithOptions()
+	ingress.Annotations = map[string]string{"example.com/team": "web"}
+	if errs := ingress.ValidateUserInput(); errs == nil {
+		t.Error("expected raw annotations to be rejected for user")
+	}
+
+	native, errs := ingress.ToKube("ns", "", map[string]string{})
+	if errs != nil {
+		t.Fatal(errs)
+	}
+	parsed, err := ParseKubeIngress(native, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(parsed.Annotations, ingress.Annotations) {
+		t.Errorf("unexpected raw annotations: %v", parsed.Annotations)
+	}
+	if parsed, _ = ParseKubeIngress(native, true); parsed.Annotations != nil {
+		t.Errorf("raw annotations should be masked for user: %v", parsed.Annotations)
+	}
+
+	ingress.Annotations = map[string]string{"nginx.ingress.kubernetes.io/rewrite-target": "/"}
+	if _, errs := ingress.ToKube("ns", "", map[string]string{}); errs == nil {
+		t.Error("expected error for annotation managed by options")
+	}
+}
+
+func TestIngressSubOptionsWithoutParent(t *testing.T) {
+	annotations := map[string]string{
+		"nginx.ingress.kubernetes.io/auth-type":           "digest",
+		"nginx.ingress.kubernetes.io/auth-secret":         "web-auth",
+		"nginx.ingress.kubernetes.io/session-cookie-name": "route",
+	}
+	options, raw := parseIngressAnnotations(annotations)
+	if options != nil {
+		t.Errorf("unexpected options: %+v", options)
+	}
+	if !reflect.DeepEqual(raw, annotations) {
+		t.Errorf("unexpected raw annotations: %v", raw)
+	}
+}
+
+func TestIngressCopyUnmanagedAnnotations(t *testing.T) {
+	ingress := testIngressWithOptions()
+	old, errs := ingress.ToKube("ns", "", map[string]string{})
+	if errs != nil {
+		t.Fatal(errs)
+	}
+	old.Annotations["example.com/team"] = "web"
+
+	update := testIngressWithOptions()
+	update.Options = nil
+	update.CopyUnmanagedAnnotations(old)
+	if expected := map[string]string{"example.com/team": "web"}; !reflect.DeepEqual(update.Annotations, expected) {
+		t.Errorf("unexpected copied annotations: %v", update.Annotations)
+	}
+	native, errs := update.ToKube("ns", "", map[string]string{})
+	if errs != nil {
+		t.Fatal(errs)
+	}
+	if native.Annotations["example.com/team"] != "web" || native.Annotations["nginx.ingress.kubernetes.io/auth-type"] != "" {
+		t.Errorf("unexpected annotations: %v", native.Annotations)
+	}
+}
@@ -0,0 +1,35 @@
+// Package metrics contains resources of metrics.k8s.io API,
+// which aren't available in vendored client-go.
+package metrics
+
+import (
+	api_core "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// GroupVersion -- supported version of metrics API
+const GroupVersion = "metrics.k8s.io/v1beta1"
+
+// PodMetrics -- usage of pod containers reported by metrics.k8s.io API
+type PodMetrics struct {
+	meta_v1.TypeMeta   `json:",inline"`
+	meta_v1.ObjectMeta `json:"metadata,omitempty"`
+
+	Timestamp  meta_v1.Time       `json:"timestamp"`
+	Window     meta_v1.Duration   `json:"window"`
+	Containers []ContainerMetrics `json:"containers"`
+}
+
+// ContainerMetrics -- usage of single container
+type ContainerMetrics struct {
+	Name  string                `json:"name"`
+	Usage api_core.ResourceList `json:"usage"`
+}
+
+// PodMetricsList -- list of pod metrics
+type PodMetricsList struct {
+	meta_v1.TypeMeta `json:",inline"`
+	meta_v1.ListMeta `json:"metadata,omitempty"`
+
+	Items []PodMetrics `json:"items"`
+}
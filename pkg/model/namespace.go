@@ -1,6 +1,7 @@
 package model
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"strings"
@@ -9,13 +10,14 @@ import (
 
 	kube_types "github.com/containerum/kube-client/pkg/model"
 	api_core "k8s.io/api/core/v1"
-	api_resource "k8s.io/apimachinery/pkg/api/resource"
 	api_meta "k8s.io/apimachinery/pkg/apis/meta/v1"
 	api_validation "k8s.io/apimachinery/pkg/util/validation"
 )
 
 const (
 	ownerLabel = "owner"
+	// quota limits stored in namespace to restore deleted or changed quota
+	quotaAnnotation = "containerum.io/quota"
 
 	minNamespaceCPU    = 10     //m
 	minNamespaceMemory = 10     //Mi
@@ -23,17 +25,42 @@ const (
 	maxNamespaceMemory = 286720 //Mi
 )
 
-type NamespaceKubeAPI kube_types.Namespace
+// NamespaceWithQuotaList -- model for namespaces list
+//
+// swagger:model
+type NamespaceWithQuotaList struct {
+	Namespaces []NamespaceWithQuota `json:"namespaces"`
+}
+
+// NamespaceWithQuota -- model for namespace with storage and objects count quotas
+//
+// swagger:model
+type NamespaceWithQuota struct {
+	// swagger: allOf
+	kube_types.Namespace
+	// required: true
+	Resources NamespaceResources `json:"resources"`
+}
+
+type NamespaceKubeAPI NamespaceWithQuota
+
+// NamespaceRepairResult -- kinds of namespace objects recreated by repair
+//
+// swagger:model
+type NamespaceRepairResult struct {
+	Namespace string   `json:"namespace"`
+	Created   []string `json:"created"`
+}
 
 // ParseKubeResourceQuotaList parses kubernetes v1.ResourceQuotaList to more convenient []Namespace struct.
 // (resource quouta contains all fields that parent namespace contains)
-func ParseKubeResourceQuotaList(quotas interface{}) (*kube_types.NamespacesList, error) {
+func ParseKubeResourceQuotaList(quotas interface{}) (*NamespaceWithQuotaList, error) {
 	objects := quotas.(*api_core.ResourceQuotaList)
 	if objects == nil {
 		return nil, ErrUnableConvertNamespaceList
 	}
 
-	namespaces := make([]kube_types.Namespace, 0, objects.Size())
+	namespaces := make([]NamespaceWithQuota, 0, objects.Size())
 	for _, quota := range objects.Items {
 		ns, err := ParseKubeResourceQuota(&quota)
 		if err != nil {
@@ -41,37 +68,30 @@ func ParseKubeResourceQuotaList(quotas interface{}) (*kube_types.NamespacesList,
 		}
 		namespaces = append(namespaces, *ns)
 	}
-	return &kube_types.NamespacesList{Namespaces: namespaces}, nil
+	return &NamespaceWithQuotaList{Namespaces: namespaces}, nil
 }
 
 // ParseKubeResourceQuota parses kubernetes v1.ResourceQuota to more convenient Namespace struct.
 // (resource quouta contains all fields that parent namespace contains)
-func ParseKubeResourceQuota(quota interface{}) (*kube_types.Namespace, error) {
+func ParseKubeResourceQuota(quota interface{}) (*NamespaceWithQuota, error) {
 	obj := quota.(*api_core.ResourceQuota)
 	if obj == nil {
 		return nil, ErrUnableConvertNamespace
 	}
 
-	cpuLimit := obj.Spec.Hard[api_core.ResourceLimitsCPU]
-	memoryLimit := obj.Spec.Hard[api_core.ResourceLimitsMemory]
-	cpuUsed := obj.Status.Used[api_core.ResourceLimitsCPU]
-	memoryUsed := obj.Status.Used[api_core.ResourceLimitsMemory]
 	owner := obj.GetObjectMeta().GetLabels()[ownerLabel]
 	createdAt := obj.ObjectMeta.CreationTimestamp.UTC().Format(time.RFC3339)
+	used := parseQuotaResourceList(obj.Status.Used, api_core.ResourceLimitsCPU, api_core.ResourceLimitsMemory)
 
-	ns := kube_types.Namespace{
-		Owner:     owner,
-		ID:        obj.GetNamespace(),
-		CreatedAt: &createdAt,
-		Resources: kube_types.Resources{
-			Hard: kube_types.Resource{
-				CPU:    uint(cpuLimit.ScaledValue(api_resource.Milli)),
-				Memory: uint(memoryLimit.Value() / 1024 / 1024),
-			},
-			Used: &kube_types.Resource{
-				CPU:    uint(cpuUsed.ScaledValue(api_resource.Milli)),
-				Memory: uint(memoryUsed.Value() / 1024 / 1024),
-			},
+	ns := NamespaceWithQuota{
+		Namespace: kube_types.Namespace{
+			Owner:     owner,
+			ID:        obj.GetNamespace(),
+			CreatedAt: &createdAt,
+		},
+		Resources: NamespaceResources{
+			Hard: parseQuotaResourceList(obj.Spec.Hard, api_core.ResourceLimitsCPU, api_core.ResourceLimitsMemory),
+			Used: &used,
 		},
 	}
 
@@ -99,19 +119,15 @@ func (ns *NamespaceKubeAPI) ToKube() (*api_core.Namespace, []error) {
 	return &newNs, nil
 }
 
-// MakeResourceQuota creates kubernetes v1.ResourceQuota from cpu, memory, labels and namespace name
-func MakeResourceQuota(ns string, labels map[string]string, resources kube_types.Resource) (*api_core.ResourceQuota, []error) {
-	errs := ValidateResourceQuota(resources.CPU, resources.Memory)
+// MakeResourceQuota creates kubernetes v1.ResourceQuota from resources, labels and namespace name.
+// Not specified resources are set to quota policy defaults.
+func MakeResourceQuota(ns string, labels map[string]string, resources QuotaResource) (*api_core.ResourceQuota, []error) {
+	ApplyQuotaDefaults(&resources)
+	errs := ValidateResourceQuota(resources)
 	if errs != nil {
 		return nil, errs
 	}
 
-	cpuLim := api_resource.NewScaledQuantity(int64(resources.CPU), api_resource.Milli)
-	memLim := api_resource.NewQuantity(int64(resources.Memory)*1024*1024, api_resource.BinarySI)
-	//Requests is equal to Limits
-	cpuReq := cpuLim
-	memReq := memLim
-
 	newRq := api_core.ResourceQuota{
 		TypeMeta: api_meta.TypeMeta{
 			Kind:       "ResourceQuota",
@@ -123,20 +139,56 @@ func MakeResourceQuota(ns string, labels map[string]string, resources kube_types
 			Namespace: ns,
 		},
 		Spec: api_core.ResourceQuotaSpec{
-			Hard: api_core.ResourceList{
-				api_core.ResourceRequestsCPU:    *cpuReq,
-				api_core.ResourceLimitsCPU:      *cpuLim,
-				api_core.ResourceRequestsMemory: *memReq,
-				api_core.ResourceLimitsMemory:   *memLim,
-			},
+			Hard: makeQuotaResourceList(resources),
 		},
 	}
 	return &newRq, nil
 }
 
-func ParseNamespaceListForUser(headers UserHeaderDataMap, nsl []kube_types.Namespace) *kube_types.NamespacesList {
-	nso := make([]kube_types.Namespace, 0)
-	ret := kube_types.NamespacesList{Namespaces: nso}
+// NamespaceOwner returns ID of user who owns namespace
+func NamespaceOwner(ns *api_core.Namespace) string {
+	return ns.GetObjectMeta().GetLabels()[ownerLabel]
+}
+
+// SetQuotaAnnotation stores quota limits in namespace annotation
+func SetQuotaAnnotation(ns *api_core.Namespace, quota *api_core.ResourceQuota) error {
+	data, err := json.Marshal(quota.Spec.Hard)
+	if err != nil {
+		return err
+	}
+	if ns.Annotations == nil {
+		ns.Annotations = make(map[string]string)
+	}
+	ns.Annotations[quotaAnnotation] = string(data)
+	return nil
+}
+
+// ExpectedResourceQuota returns quota which namespace should have.
+// Limits are taken from namespace annotation and labels are taken from namespace.
+// Namespaces without annotation weren't provisioned with stored limits, so their current quota is expected as is.
+func ExpectedResourceQuota(ns *api_core.Namespace, current *api_core.ResourceQuota) (*api_core.ResourceQuota, []error) {
+	data, ok := ns.Annotations[quotaAnnotation]
+	if !ok {
+		if current == nil {
+			return nil, []error{fmt.Errorf("namespace has no %v annotation", quotaAnnotation)}
+		}
+		return current.DeepCopy(), nil
+	}
+	var stored api_core.ResourceList
+	if err := json.Unmarshal([]byte(data), &stored); err != nil {
+		return nil, []error{fmt.Errorf("invalid %v annotation: %v", quotaAnnotation, err)}
+	}
+	res := parseQuotaResourceList(stored, api_core.ResourceLimitsCPU, api_core.ResourceLimitsMemory)
+	labels := make(map[string]string, len(ns.Labels))
+	for k, v := range ns.Labels {
+		labels[k] = v
+	}
+	return MakeResourceQuota(ns.Name, labels, res)
+}
+
+func ParseNamespaceListForUser(headers UserHeaderDataMap, nsl []NamespaceWithQuota) *NamespaceWithQuotaList {
+	nso := make([]NamespaceWithQuota, 0)
+	ret := NamespaceWithQuotaList{Namespaces: nso}
 	for _, ns := range nsl {
 		ns = *ParseForUser(&ns, headers)
 		if ns.Label != "" {
@@ -146,7 +198,7 @@ func ParseNamespaceListForUser(headers UserHeaderDataMap, nsl []kube_types.Names
 	return &ret
 }
 
-func ParseForUser(ns *kube_types.Namespace, headers UserHeaderDataMap) *kube_types.Namespace {
+func ParseForUser(ns *NamespaceWithQuota, headers UserHeaderDataMap) *NamespaceWithQuota {
 	for _, n := range headers {
 		if ns.ID == n.ID {
 			ns.Label = n.Label
@@ -174,20 +226,3 @@ func (ns *NamespaceKubeAPI) Validate() []error {
 	}
 	return nil
 }
-
-func ValidateResourceQuota(cpu, mem uint) []error {
-	var errs []error
-
-	if cpu < minNamespaceCPU || cpu > maxNamespaceCPU {
-		errs = append(errs, fmt.Errorf(invalidCPUQuota, cpu, minNamespaceCPU, maxNamespaceCPU))
-	}
-
-	if mem < minNamespaceMemory || mem > maxNamespaceMemory {
-		errs = append(errs, fmt.Errorf(invalidMemoryQuota, mem, minNamespaceMemory, maxNamespaceMemory))
-	}
-
-	if len(errs) > 0 {
-		return errs
-	}
-	return nil
-}
@@ -0,0 +1,261 @@
+// Package networking converts ingresses between extensions/v1beta1, used by kube-api internally,
+// and Ingress API versions served by cluster.
+package networking
+
+import (
+	"encoding/json"
+	"fmt"
+
+	api_core "k8s.io/api/core/v1"
+	api_extensions "k8s.io/api/extensions/v1beta1"
+	api_meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// Ingress API group versions
+const (
+	ExtensionsV1beta1 = "extensions/v1beta1"
+	NetworkingV1beta1 = "networking.k8s.io/v1beta1"
+	NetworkingV1      = "networking.k8s.io/v1"
+)
+
+const (
+	ingressKind     = "Ingress"
+	ingressListKind = "IngressList"
+
+	// ingress class annotation is replaced by spec.ingressClassName in networking.k8s.io/v1,
+	// apiserver rejects ingresses with both set
+	ingressClassAnnotation = "kubernetes.io/ingress.class"
+
+	// path type with the same matching rules as paths in beta APIs
+	pathTypeImplementationSpecific = "ImplementationSpecific"
+)
+
+// PreferredVersions -- supported Ingress API versions from the most preferred
+var PreferredVersions = []string{NetworkingV1, NetworkingV1beta1, ExtensionsV1beta1}
+
+// IngressV1 -- networking.k8s.io/v1 Ingress
+type IngressV1 struct {
+	api_meta.TypeMeta   `json:",inline"`
+	api_meta.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   IngressSpecV1                `json:"spec,omitempty"`
+	Status api_extensions.IngressStatus `json:"status,omitempty"`
+}
+
+// IngressListV1 -- networking.k8s.io/v1 IngressList
+type IngressListV1 struct {
+	api_meta.TypeMeta `json:",inline"`
+	api_meta.ListMeta `json:"metadata,omitempty"`
+
+	Items []IngressV1 `json:"items"`
+}
+
+// IngressSpecV1 -- networking.k8s.io/v1 IngressSpec
+type IngressSpecV1 struct {
+	IngressClassName *string                     `json:"ingressClassName,omitempty"`
+	DefaultBackend   *IngressBackendV1           `json:"defaultBackend,omitempty"`
+	TLS              []api_extensions.IngressTLS `json:"tls,omitempty"`
+	Rules            []IngressRuleV1             `json:"rules,omitempty"`
+}
+
+// IngressRuleV1 -- networking.k8s.io/v1 IngressRule
+type IngressRuleV1 struct {
+	Host string                  `json:"host,omitempty"`
+	HTTP *HTTPIngressRuleValueV1 `json:"http,omitempty"`
+}
+
+// HTTPIngressRuleValueV1 -- networking.k8s.io/v1 HTTPIngressRuleValue
+type HTTPIngressRuleValueV1 struct {
+	Paths []HTTPIngressPathV1 `json:"paths"`
+}
+
+// HTTPIngressPathV1 -- networking.k8s.io/v1 HTTPIngressPath
+type HTTPIngressPathV1 struct {
+	Path     string           `json:"path,omitempty"`
+	PathType *string          `json:"pathType"`
+	Backend  IngressBackendV1 `json:"backend"`
+}
+
+// IngressBackendV1 -- networking.k8s.io/v1 IngressBackend
+type IngressBackendV1 struct {
+	Service  *IngressServiceBackendV1            `json:"service,omitempty"`
+	Resource *api_core.TypedLocalObjectReference `json:"resource,omitempty"`
+}
+
+// IngressServiceBackendV1 -- networking.k8s.io/v1 IngressServiceBackend
+type IngressServiceBackendV1 struct {
+	Name string               `json:"name"`
+	Port ServiceBackendPortV1 `json:"port,omitempty"`
+}
+
+// ServiceBackendPortV1 -- networking.k8s.io/v1 ServiceBackendPort
+type ServiceBackendPortV1 struct {
+	Name   string `json:"name,omitempty"`
+	Number int32  `json:"number,omitempty"`
+}
+
+// EncodeIngress converts ingress to JSON of groupVersion
+func EncodeIngress(ingress *api_extensions.Ingress, groupVersion string) ([]byte, error) {
+	typeMeta := api_meta.TypeMeta{Kind: ingressKind, APIVersion: groupVersion}
+	switch groupVersion {
+	case ExtensionsV1beta1, NetworkingV1beta1:
+		// beta APIs have the same schema
+		out := ingress.DeepCopy()
+		out.TypeMeta = typeMeta
+		return json.Marshal(out)
+	case NetworkingV1:
+		out := ToV1(ingress)
+		out.TypeMeta = typeMeta
+		return json.Marshal(out)
+	default:
+		return nil, unsupportedVersion(groupVersion)
+	}
+}
+
+// DecodeIngress converts ingress JSON of groupVersion to extensions/v1beta1 ingress
+func DecodeIngress(data []byte, groupVersion string) (*api_extensions.Ingress, error) {
+	var out api_extensions.Ingress
+	switch groupVersion {
+	case ExtensionsV1beta1, NetworkingV1beta1:
+		if err := json.Unmarshal(data, &out); err != nil {
+			return nil, err
+		}
+	case NetworkingV1:
+		var in IngressV1
+		if err := json.Unmarshal(data, &in); err != nil {
+			return nil, err
+		}
+		out = *FromV1(&in)
+	default:
+		return nil, unsupportedVersion(groupVersion)
+	}
+	out.TypeMeta = api_meta.TypeMeta{Kind: ingressKind, APIVersion: ExtensionsV1beta1}
+	return &out, nil
+}
+
+// DecodeIngressList converts ingress list JSON of groupVersion to extensions/v1beta1 ingress list
+func DecodeIngressList(data []byte, groupVersion string) (*api_extensions.IngressList, error) {
+	var out api_extensions.IngressList
+	switch groupVersion {
+	case ExtensionsV1beta1, NetworkingV1beta1:
+		if err := json.Unmarshal(data, &out); err != nil {
+			return nil, err
+		}
+	case NetworkingV1:
+		var in IngressListV1
+		if err := json.Unmarshal(data, &in); err != nil {
+			return nil, err
+		}
+		out.ListMeta = in.ListMeta
+		out.Items = make([]api_extensions.Ingress, 0, len(in.Items))
+		for i := range in.Items {
+			out.Items = append(out.Items, *FromV1(&in.Items[i]))
+		}
+	default:
+		return nil, unsupportedVersion(groupVersion)
+	}
+	out.TypeMeta = api_meta.TypeMeta{Kind: ingressListKind, APIVersion: ExtensionsV1beta1}
+	for i := range out.Items {
+		out.Items[i].TypeMeta = api_meta.TypeMeta{Kind: ingressKind, APIVersion: ExtensionsV1beta1}
+	}
+	return &out, nil
+}
+
+// ToV1 converts extensions/v1beta1 ingress to networking.k8s.io/v1.
+// Ingress class annotation is moved to ingressClassName, paths get ImplementationSpecific type.
+func ToV1(in *api_extensions.Ingress) *IngressV1 {
+	out := IngressV1{
+		ObjectMeta: *in.ObjectMeta.DeepCopy(),
+		Status:     *in.Status.DeepCopy(),
+	}
+	if class, ok := out.Annotations[ingressClassAnnotation]; ok {
+		out.Spec.IngressClassName = &class
+		delete(out.Annotations, ingressClassAnnotation)
+	}
+	if in.Spec.Backend != nil {
+		out.Spec.DefaultBackend = backendToV1(*in.Spec.Backend)
+	}
+	for _, tls := range in.Spec.TLS {
+		out.Spec.TLS = append(out.Spec.TLS, *tls.DeepCopy())
+	}
+	for _, rule := range in.Spec.Rules {
+		outRule := IngressRuleV1{Host: rule.Host}
+		if rule.HTTP != nil {
+			outRule.HTTP = &HTTPIngressRuleValueV1{Paths: make([]HTTPIngressPathV1, 0, len(rule.HTTP.Paths))}
+			for _, path := range rule.HTTP.Paths {
+				pathType := pathTypeImplementationSpecific
+				outRule.HTTP.Paths = append(outRule.HTTP.Paths, HTTPIngressPathV1{
+					Path:     path.Path,
+					PathType: &pathType,
+					Backend:  *backendToV1(path.Backend),
+				})
+			}
+		}
+		out.Spec.Rules = append(out.Spec.Rules, outRule)
+	}
+	return &out
+}
+
+// FromV1 converts networking.k8s.io/v1 ingress to extensions/v1beta1.
+// IngressClassName is moved to ingress class annotation.
+// Path types and resource backends can't be represented in beta API and are dropped.
+func FromV1(in *IngressV1) *api_extensions.Ingress {
+	out := api_extensions.Ingress{
+		ObjectMeta: *in.ObjectMeta.DeepCopy(),
+		Status:     *in.Status.DeepCopy(),
+	}
+	if in.Spec.IngressClassName != nil {
+		if out.Annotations == nil {
+			out.Annotations = make(map[string]string)
+		}
+		out.Annotations[ingressClassAnnotation] = *in.Spec.IngressClassName
+	}
+	if in.Spec.DefaultBackend != nil && in.Spec.DefaultBackend.Service != nil {
+		out.Spec.Backend = backendFromV1(*in.Spec.DefaultBackend.Service)
+	}
+	for _, tls := range in.Spec.TLS {
+		out.Spec.TLS = append(out.Spec.TLS, *tls.DeepCopy())
+	}
+	for _, rule := range in.Spec.Rules {
+		outRule := api_extensions.IngressRule{Host: rule.Host}
+		if rule.HTTP != nil {
+			outRule.HTTP = &api_extensions.HTTPIngressRuleValue{Paths: make([]api_extensions.HTTPIngressPath, 0, len(rule.HTTP.Paths))}
+			for _, path := range rule.HTTP.Paths {
+				if path.Backend.Service == nil {
+					continue
+				}
+				outRule.HTTP.Paths = append(outRule.HTTP.Paths, api_extensions.HTTPIngressPath{
+					Path:    path.Path,
+					Backend: *backendFromV1(*path.Backend.Service),
+				})
+			}
+		}
+		out.Spec.Rules = append(out.Spec.Rules, outRule)
+	}
+	return &out
+}
+
+func backendToV1(in api_extensions.IngressBackend) *IngressBackendV1 {
+	service := IngressServiceBackendV1{Name: in.ServiceName}
+	if in.ServicePort.Type == intstr.String {
+		service.Port.Name = in.ServicePort.StrVal
+	} else {
+		service.Port.Number = in.ServicePort.IntVal
+	}
+	return &IngressBackendV1{Service: &service}
+}
+
+func backendFromV1(in IngressServiceBackendV1) *api_extensions.IngressBackend {
+	out := api_extensions.IngressBackend{ServiceName: in.Name}
+	if in.Port.Name != "" {
+		out.ServicePort = intstr.FromString(in.Port.Name)
+	} else {
+		out.ServicePort = intstr.FromInt(int(in.Port.Number))
+	}
+	return &out
+}
+
+func unsupportedVersion(groupVersion string) error {
+	return fmt.Errorf("unsupported Ingress API version %v", groupVersion)
+}
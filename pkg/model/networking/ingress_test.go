@@ -0,0 +1,129 @@
+package networking
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	api_extensions "k8s.io/api/extensions/v1beta1"
+	api_meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func testIngress() *api_extensions.Ingress {
+	return &api_extensions.Ingress{
+		ObjectMeta: api_meta.ObjectMeta{
+			Name:        "web",
+			Namespace:   "ns",
+			Labels:      map[string]string{"owner": "user"},
+			Annotations: map[string]string{ingressClassAnnotation: "nginx", "kubernetes.io/tls-acme": "true"},
+		},
+		Spec: api_extensions.IngressSpec{
+			TLS: []api_extensions.IngressTLS{{Hosts: []string{"web.example.com"}, SecretName: "web-tls"}},
+			Rules: []api_extensions.IngressRule{{
+				Host: "web.example.com",
+				IngressRuleValue: api_extensions.IngressRuleValue{HTTP: &api_extensions.HTTPIngressRuleValue{
+					Paths: []api_extensions.HTTPIngressPath{
+						{Path: "/", Backend: api_extensions.IngressBackend{ServiceName: "web", ServicePort: intstr.FromInt(80)}},
+						{Path: "/api", Backend: api_extensions.IngressBackend{ServiceName: "api", ServicePort: intstr.FromString("http")}},
+					},
+				}},
+			}},
+		},
+	}
+}
+
+func TestIngressRoundTrip(t *testing.T) {
+	for _, groupVersion := range PreferredVersions {
+		data, err := EncodeIngress(testIngress(), groupVersion)
+		if err != nil {
+			t.Fatalf("%v: %v", groupVersion, err)
+		}
+		var typeMeta api_meta.TypeMeta
+		if err := json.Unmarshal(data, &typeMeta); err != nil {
+			t.Fatal(err)
+		}
+		if typeMeta.APIVersion != groupVersion || typeMeta.Kind != ingressKind {
+			t.Errorf("%v: unexpected type %+v", groupVersion, typeMeta)
+		}
+
+		decoded, err := DecodeIngress(data, groupVersion)
+		if err != nil {
+			t.Fatalf("%v: %v", groupVersion, err)
+		}
+		expected := testIngress()
+		expected.TypeMeta = api_meta.TypeMeta{Kind: ingressKind, APIVersion: ExtensionsV1beta1}
+		if !reflect.DeepEqual(decoded, expected) {
+			t.Errorf("%v: ingress changed after round trip:\n%+v\nexpected:\n%+v", groupVersion, decoded, expected)
+		}
+	}
+}
+
+func TestIngressToV1(t *testing.T) {
+	data, err := EncodeIngress(testIngress(), NetworkingV1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ingress IngressV1
+	if err := json.Unmarshal(data, &ingress); err != nil {
+		t.Fatal(err)
+	}
+	if ingress.Spec.IngressClassName == nil || *ingress.Spec.IngressClassName != "nginx" {
+		t.Errorf("expected nginx ingress class, got %v", ingress.Spec.IngressClassName)
+	}
+	if _, ok := ingress.Annotations[ingressClassAnnotation]; ok {
+		t.Errorf("ingress class annotation should be removed: %v", ingress.Annotations)
+	}
+	paths := ingress.Spec.Rules[0].HTTP.Paths
+	if *paths[0].PathType != pathTypeImplementationSpecific ||
+		paths[0].Backend.Service.Name != "web" || paths[0].Backend.Service.Port.Number != 80 ||
+		paths[1].Backend.Service.Port.Name != "http" {
+		t.Errorf("unexpected paths: %+v", paths)
+	}
+}
+
+func TestIngressListFromV1(t *testing.T) {
+	data := []byte(`{
+		"kind": "IngressList",
+		"apiVersion": "networking.k8s.io/v1",
+		"items": [{
+			"metadata": {"name": "web", "namespace": "ns"},
+			"spec": {
+				"ingressClassName": "nginx",
+				"defaultBackend": {"service": {"name": "default", "port": {"number": 8080}}},
+				"rules": [{"host": "web.example.com", "http": {"paths": [
+					{"path": "/", "pathType": "Prefix", "backend": {"service": {"name": "web", "port": {"name": "http"}}}},
+					{"path": "/static", "pathType": "Prefix", "backend": {"resource": {"apiGroup": "k8s.example.com", "kind": "Bucket", "name": "static"}}}
+				]}}]
+			}
+		}]
+	}`)
+	list, err := DecodeIngressList(data, NetworkingV1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Items) != 1 {
+		t.Fatalf("unexpected ingresses: %+v", list.Items)
+	}
+	ingress := list.Items[0]
+	if ingress.APIVersion != ExtensionsV1beta1 || ingress.Annotations[ingressClassAnnotation] != "nginx" {
+		t.Errorf("unexpected ingress metadata: %+v %+v", ingress.TypeMeta, ingress.ObjectMeta)
+	}
+	if ingress.Spec.Backend == nil || ingress.Spec.Backend.ServiceName != "default" || ingress.Spec.Backend.ServicePort.IntVal != 8080 {
+		t.Errorf("unexpected default backend: %+v", ingress.Spec.Backend)
+	}
+	// resource backend can't be represented in beta API
+	paths := ingress.Spec.Rules[0].HTTP.Paths
+	if len(paths) != 1 || paths[0].Backend.ServiceName != "web" || paths[0].Backend.ServicePort.StrVal != "http" {
+		t.Errorf("unexpected paths: %+v", paths)
+	}
+}
+
+func TestIngressUnsupportedVersion(t *testing.T) {
+	if _, err := EncodeIngress(testIngress(), "networking.k8s.io/v2"); err == nil {
+		t.Error("expected error for unsupported version")
+	}
+	if _, err := DecodeIngress([]byte(`{}`), "networking.k8s.io/v2"); err == nil {
+		t.Error("expected error for unsupported version")
+	}
+}
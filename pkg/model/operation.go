@@ -0,0 +1,132 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/containerum/cherry"
+	api_core "k8s.io/api/core/v1"
+	api_meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// NamespaceTerminating -- namespace is being deleted
+	NamespaceTerminating = "Terminating"
+	// NamespaceDeleted -- namespace deletion is finished
+	NamespaceDeleted = "Deleted"
+	// NamespaceDeletionFailed -- namespace deletion wasn't started
+	NamespaceDeletionFailed = "Failed"
+
+	namespaceDeletionPrefix = "namespace-deletion"
+)
+
+// ErrInvalidOperationID -- operation ID can't be parsed
+var ErrInvalidOperationID = errors.New("invalid operation ID")
+
+// NamespaceDeletion -- progress of namespace deletion
+//
+// swagger:model
+type NamespaceDeletion struct {
+	OperationID string `json:"operation_id"`
+	Namespace   string `json:"namespace"`
+	// Terminating, Deleted or Failed
+	Phase string `json:"phase"`
+	// reason of failed deletion
+	Error *cherry.Err `json:"error,omitempty"`
+	// number of objects of each kind which are not deleted yet
+	RemainingResources map[string]int `json:"remaining_resources,omitempty"`
+	// finalizers of namespace and objects which block deletion
+	Finalizers []string `json:"finalizers,omitempty"`
+}
+
+// NamespaceDeletionList -- progress of deletion of several namespaces
+//
+// swagger:model
+type NamespaceDeletionList struct {
+	Deletions []NamespaceDeletion `json:"deletions"`
+}
+
+// NamespaceDeletionID returns ID of namespace deletion operation.
+// ID contains namespace UID, so deletion of namespace recreated with the same name is a different operation.
+func NamespaceDeletionID(ns *api_core.Namespace) string {
+	return strings.Join([]string{namespaceDeletionPrefix, ns.Name, string(ns.UID)}, ".")
+}
+
+// ParseNamespaceDeletionID returns namespace name and UID from namespace deletion operation ID
+func ParseNamespaceDeletionID(id string) (name string, uid string, err error) {
+	parts := strings.Split(id, ".")
+	if len(parts) != 3 || parts[0] != namespaceDeletionPrefix || parts[1] == "" || parts[2] == "" {
+		return "", "", ErrInvalidOperationID
+	}
+	return parts[1], parts[2], nil
+}
+
+// ParseNamespaceDeletion makes deletion progress from terminating namespace, its objects and pods
+func ParseNamespaceDeletion(ns *api_core.Namespace, objects interface{}, pods interface{}) NamespaceDeletion {
+	objs := objects.(*NamespaceObjects)
+	podList := pods.(*api_core.PodList)
+
+	deletion := NamespaceDeletion{
+		OperationID:        NamespaceDeletionID(ns),
+		Namespace:          ns.Name,
+		Phase:              NamespaceTerminating,
+		RemainingResources: make(map[string]int),
+	}
+
+	for _, finalizer := range ns.Spec.Finalizers {
+		deletion.Finalizers = append(deletion.Finalizers, fmt.Sprintf("Namespace/%v: %v", ns.Name, finalizer))
+	}
+	count := func(kind string, n int) {
+		if n > 0 {
+			deletion.RemainingResources[kind] = n
+		}
+	}
+	blocked := func(kind string, meta api_meta.Object) {
+		if meta.GetDeletionTimestamp() != nil && len(meta.GetFinalizers()) > 0 {
+			deletion.Finalizers = append(deletion.Finalizers,
+				fmt.Sprintf("%v/%v: %v", kind, meta.GetName(), strings.Join(meta.GetFinalizers(), ",")))
+		}
+	}
+
+	count("Pod", len(podList.Items))
+	for i := range podList.Items {
+		blocked("Pod", &podList.Items[i])
+	}
+	count(deploymentKind, len(objs.Deployments.Items))
+	for i := range objs.Deployments.Items {
+		blocked(deploymentKind, &objs.Deployments.Items[i])
+	}
+	count(serviceKind, len(objs.Services.Items))
+	for i := range objs.Services.Items {
+		blocked(serviceKind, &objs.Services.Items[i])
+	}
+	count(ingressKind, len(objs.Ingresses.Items))
+	for i := range objs.Ingresses.Items {
+		blocked(ingressKind, &objs.Ingresses.Items[i])
+	}
+	count(configMapKind, len(objs.ConfigMaps.Items))
+	for i := range objs.ConfigMaps.Items {
+		blocked(configMapKind, &objs.ConfigMaps.Items[i])
+	}
+	count(pvcKind, len(objs.PersistentVolumeClaims.Items))
+	for i := range objs.PersistentVolumeClaims.Items {
+		blocked(pvcKind, &objs.PersistentVolumeClaims.Items[i])
+	}
+	if objs.Secrets != nil {
+		count(secretKind, len(objs.Secrets.Items))
+		for i := range objs.Secrets.Items {
+			blocked(secretKind, &objs.Secrets.Items[i])
+		}
+	}
+	return deletion
+}
+
+// DeletedNamespace returns progress of finished namespace deletion
+func DeletedNamespace(operationID string, nsName string) NamespaceDeletion {
+	return NamespaceDeletion{
+		OperationID: operationID,
+		Namespace:   nsName,
+		Phase:       NamespaceDeleted,
+	}
+}
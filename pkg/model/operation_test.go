@@ -0,0 +1,57 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	api_apps "k8s.io/api/apps/v1"
+	api_core "k8s.io/api/core/v1"
+	api_extensions "k8s.io/api/extensions/v1beta1"
+	api_meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestNamespaceDeletionID(t *testing.T) {
+	ns := &api_core.Namespace{ObjectMeta: api_meta.ObjectMeta{Name: "ns", UID: types.UID("1f2e-3d4c")}}
+	name, uid, err := ParseNamespaceDeletionID(NamespaceDeletionID(ns))
+	if err != nil || name != "ns" || uid != "1f2e-3d4c" {
+		t.Errorf("unexpected parsed ID: %v %v %v", name, uid, err)
+	}
+
+	for _, id := range []string{"", "ns", "namespace-deletion.ns", "namespace-deletion..uid", "other.ns.uid", "namespace-deletion.a.b.c"} {
+		if _, _, err := ParseNamespaceDeletionID(id); err == nil {
+			t.Errorf("expected error for %q", id)
+		}
+	}
+}
+
+func TestParseNamespaceDeletion(t *testing.T) {
+	now := api_meta.Now()
+	ns := &api_core.Namespace{
+		ObjectMeta: api_meta.ObjectMeta{Name: "ns", UID: types.UID("uid"), DeletionTimestamp: &now},
+		Spec:       api_core.NamespaceSpec{Finalizers: []api_core.FinalizerName{api_core.FinalizerKubernetes}},
+	}
+	objects := &NamespaceObjects{
+		Deployments: &api_apps.DeploymentList{},
+		Services:    &api_core.ServiceList{},
+		Ingresses:   &api_extensions.IngressList{},
+		ConfigMaps:  &api_core.ConfigMapList{Items: []api_core.ConfigMap{{}, {}}},
+		PersistentVolumeClaims: &api_core.PersistentVolumeClaimList{Items: []api_core.PersistentVolumeClaim{{
+			ObjectMeta: api_meta.ObjectMeta{Name: "data", DeletionTimestamp: &now, Finalizers: []string{"kubernetes.io/pvc-protection"}},
+		}}},
+		Secrets: &api_core.SecretList{},
+	}
+	pods := &api_core.PodList{Items: []api_core.Pod{{ObjectMeta: api_meta.ObjectMeta{Name: "web", DeletionTimestamp: &now}}}}
+
+	deletion := ParseNamespaceDeletion(ns, objects, pods)
+	if deletion.Phase != NamespaceTerminating || deletion.OperationID != "namespace-deletion.ns.uid" {
+		t.Errorf("unexpected deletion: %+v", deletion)
+	}
+	if len(deletion.RemainingResources) != 3 || deletion.RemainingResources["ConfigMap"] != 2 ||
+		deletion.RemainingResources["Pod"] != 1 || deletion.RemainingResources["PersistentVolumeClaim"] != 1 {
+		t.Errorf("unexpected remaining resources: %v", deletion.RemainingResources)
+	}
+	if strings.Join(deletion.Finalizers, "; ") != "Namespace/ns: kubernetes; PersistentVolumeClaim/data: kubernetes.io/pvc-protection" {
+		t.Errorf("unexpected finalizers: %v", deletion.Finalizers)
+	}
+}
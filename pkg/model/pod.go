@@ -1,6 +1,7 @@
 package model
 
 import (
+	"fmt"
 	"strconv"
 
 	"time"
@@ -13,36 +14,54 @@ import (
 	api_resource "k8s.io/apimachinery/pkg/api/resource"
 )
 
+// PodWithRefsList -- model for pods list
+//
+// swagger:model
+type PodWithRefsList struct {
+	Pods []PodWithRefs `json:"pods"`
+}
+
+// PodWithRefs -- model for pod with containers referencing secrets and config maps
+//
+// swagger:model
+type PodWithRefs struct {
+	// swagger: allOf
+	kube_types.Pod
+	Containers []ContainerWithRefs `json:"containers"`
+}
+
 // ParseKubePodList parses kubernetes v1.PodList to more convenient []Pod struct.
-func ParseKubePodList(pods interface{}, parseforuser bool) *kube_types.PodsList {
+func ParseKubePodList(pods interface{}, parseforuser bool) *PodWithRefsList {
 	podList := pods.(*api_core.PodList)
-	ret := make([]kube_types.Pod, 0)
+	ret := make([]PodWithRefs, 0)
 	for _, po := range podList.Items {
 		ret = append(ret, ParseKubePod(&po, parseforuser))
 	}
-	return &kube_types.PodsList{Pods: ret}
+	return &PodWithRefsList{Pods: ret}
 }
 
 // ParseKubePod parses kubernetes v1.PodList to more convenient Pod struct.
-func ParseKubePod(pod interface{}, parseforuser bool) kube_types.Pod {
+func ParseKubePod(pod interface{}, parseforuser bool) PodWithRefs {
 	obj := pod.(*api_core.Pod)
 	owner := obj.GetObjectMeta().GetLabels()[ownerLabel]
-	containers, cpu, mem := getContainers(obj.Spec.Containers, nil, nil, 1)
+	containers, cpu, mem := getContainers(obj.Spec.Containers, nil, nil, nil, 1)
 	deploy := obj.GetObjectMeta().GetLabels()[appLabel]
 	createdAt := obj.ObjectMeta.CreationTimestamp.UTC().Format(time.RFC3339)
 
-	newPod := kube_types.Pod{
-		CreatedAt:  &createdAt,
-		Deploy:     &deploy,
-		Name:       obj.GetName(),
-		Containers: containers,
-		Status: &model.PodStatus{
-			Phase: string(obj.Status.Phase),
+	newPod := PodWithRefs{
+		Pod: kube_types.Pod{
+			CreatedAt: &createdAt,
+			Deploy:    &deploy,
+			Name:      obj.GetName(),
+			Status: &model.PodStatus{
+				Phase: string(obj.Status.Phase),
+			},
+			ImagePullSecrets: getImagePullSecrets(obj.Spec.ImagePullSecrets),
+			TotalCPU:         uint(cpu.ScaledValue(api_resource.Milli)),
+			TotalMemory:      uint(mem.Value() / 1024 / 1024),
+			Owner:            owner,
 		},
-		ImagePullSecrets: getImagePullSecrets(obj.Spec.ImagePullSecrets),
-		TotalCPU:         uint(cpu.ScaledValue(api_resource.Milli)),
-		TotalMemory:      uint(mem.Value() / 1024 / 1024),
-		Owner:            owner,
+		Containers: containers,
 	}
 
 	if parseforuser {
@@ -52,11 +71,11 @@ func ParseKubePod(pod interface{}, parseforuser bool) kube_types.Pod {
 	return newPod
 }
 
-func getContainers(cListi interface{}, mode map[string]int32, storageName map[string]string, replicas int) (containers []model.Container, totalcpu, totalmem api_resource.Quantity) {
+func getContainers(cListi interface{}, mode map[string]int32, storageName map[string]string, secrets map[string]*api_core.SecretVolumeSource, replicas int) (containers []ContainerWithRefs, totalcpu, totalmem api_resource.Quantity) {
 	cList := cListi.([]api_core.Container)
 	for _, c := range cList {
 		env := getEnv(c.Env)
-		volumes, configMaps := getVolumes(c.VolumeMounts, mode, storageName)
+		volumes, configMaps, secretMounts := getVolumes(c.VolumeMounts, mode, storageName, secrets)
 
 		cpu := c.Resources.Limits["cpu"]
 		mem := c.Resources.Limits["memory"]
@@ -66,27 +85,36 @@ func getContainers(cListi interface{}, mode map[string]int32, storageName map[st
 			totalmem.Add(c.Resources.Limits["memory"])
 		}
 
-		containers = append(containers, model.Container{
-			Name:         c.Name,
-			Image:        c.Image,
-			Env:          env,
-			VolumeMounts: volumes,
-			ConfigMaps:   configMaps,
-			Commands:     c.Command,
-			Limits: model.Resource{
-				CPU:    uint(cpu.ScaledValue(api_resource.Milli)),
-				Memory: uint(mem.Value() / 1024 / 1024),
+		containers = append(containers, ContainerWithRefs{
+			Container: model.Container{
+				Name:         c.Name,
+				Image:        c.Image,
+				VolumeMounts: volumes,
+				ConfigMaps:   configMaps,
+				Commands:     c.Command,
+				Limits: model.Resource{
+					CPU:    uint(cpu.ScaledValue(api_resource.Milli)),
+					Memory: uint(mem.Value() / 1024 / 1024),
+				},
 			},
+			Env:     env,
+			EnvFrom: getEnvFrom(c.EnvFrom),
+			Secrets: secretMounts,
 		})
 	}
 	return containers, totalcpu, totalmem
 }
 
-func getVolumes(vListi interface{}, mode map[string]int32, storageName map[string]string) ([]model.ContainerVolume, []model.ContainerVolume) {
+func getVolumes(vListi interface{}, mode map[string]int32, storageName map[string]string, secrets map[string]*api_core.SecretVolumeSource) ([]model.ContainerVolume, []model.ContainerVolume, []ContainerSecret) {
 	vList := vListi.([]api_core.VolumeMount)
 	volumes := make([]model.ContainerVolume, 0)
 	configMaps := make([]model.ContainerVolume, 0)
+	secretMounts := make([]ContainerSecret, 0)
 	for _, v := range vList {
+		if secret, ok := secrets[v.Name]; ok {
+			secretMounts = append(secretMounts, getSecretMount(v, secret))
+			continue
+		}
 
 		subpath := v.SubPath
 		newvol := model.ContainerVolume{
@@ -112,17 +140,102 @@ func getVolumes(vListi interface{}, mode map[string]int32, storageName map[strin
 			volumes = append(volumes, newvol)
 		}
 	}
-	return volumes, configMaps
+	return volumes, configMaps, secretMounts
 }
 
-func getEnv(eListi interface{}) []model.Env {
+func getSecretMount(mount api_core.VolumeMount, secret *api_core.SecretVolumeSource) ContainerSecret {
+	newSecret := ContainerSecret{
+		Name:      secret.SecretName,
+		MountPath: mount.MountPath,
+	}
+	if mount.SubPath != "" {
+		subpath := mount.SubPath
+		newSecret.SubPath = &subpath
+	}
+	if secret.DefaultMode != nil {
+		formated := strconv.FormatInt(int64(*secret.DefaultMode), 8)
+		newSecret.Mode = &formated
+	}
+	for _, item := range secret.Items {
+		newSecret.Items = append(newSecret.Items, SecretItem{Key: item.Key, Path: item.Path})
+	}
+	return newSecret
+}
+
+func getEnv(eListi interface{}) []EnvVar {
 	eList := eListi.([]api_core.EnvVar)
-	envs := make([]model.Env, 0)
+	envs := make([]EnvVar, 0)
 	for _, e := range eList {
-		envs = append(envs, model.Env{
+		env := EnvVar{
 			Name:  e.Name,
 			Value: e.Value,
-		})
+		}
+		// only references are returned, values of secrets and config maps are not exposed
+		if e.ValueFrom != nil {
+			env.ValueFrom = &EnvValueFrom{}
+			if ref := e.ValueFrom.SecretKeyRef; ref != nil {
+				env.ValueFrom.SecretKeyRef = &EnvKeyRef{Name: ref.Name, Key: ref.Key}
+			}
+			if ref := e.ValueFrom.ConfigMapKeyRef; ref != nil {
+				env.ValueFrom.ConfigMapKeyRef = &EnvKeyRef{Name: ref.Name, Key: ref.Key}
+			}
+		}
+		envs = append(envs, env)
 	}
 	return envs
 }
+
+func getEnvFrom(sources []api_core.EnvFromSource) []EnvFrom {
+	envs := make([]EnvFrom, 0)
+	for _, source := range sources {
+		env := EnvFrom{Prefix: source.Prefix}
+		if source.SecretRef != nil {
+			env.Secret = source.SecretRef.Name
+		}
+		if source.ConfigMapRef != nil {
+			env.ConfigMap = source.ConfigMapRef.Name
+		}
+		envs = append(envs, env)
+	}
+	return envs
+}
+
+const maxPortForwardPorts = 10
+
+// ValidatePortForward checks that pod exposes all requested ports
+func ValidatePortForward(pod interface{}, ports []int) []error {
+	obj := pod.(*api_core.Pod)
+	var errs []error
+	if len(ports) == 0 {
+		errs = append(errs, fmt.Errorf(fieldShouldExist, "port"))
+	}
+	if len(ports) > maxPortForwardPorts {
+		errs = append(errs, fmt.Errorf(tooManyPorts, len(ports), maxPortForwardPorts))
+	}
+
+	declared := make(map[int]bool)
+	for _, c := range obj.Spec.Containers {
+		for _, p := range c.Ports {
+			if p.Protocol == "" || p.Protocol == api_core.ProtocolTCP {
+				declared[int(p.ContainerPort)] = true
+			}
+		}
+	}
+
+	seen := make(map[int]bool)
+	for _, port := range ports {
+		if seen[port] {
+			errs = append(errs, fmt.Errorf(duplicatePort, port))
+			continue
+		}
+		seen[port] = true
+		if !declared[port] {
+			errs = append(errs, fmt.Errorf(noPodPort, port, obj.GetName()))
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
@@ -0,0 +1,270 @@
+package model
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	kube_types "github.com/containerum/kube-client/pkg/model"
+	"github.com/ghodss/yaml"
+	api_core "k8s.io/api/core/v1"
+	api_resource "k8s.io/apimachinery/pkg/api/resource"
+	api_validation "k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	storageClassQuotaSuffix = ".storageclass.storage.k8s.io/" + string(api_core.ResourceRequestsStorage)
+)
+
+// ingresses are served by several API groups and counted separately by each of them,
+// so the same limit is set for all groups
+var ingressesQuotas = []api_core.ResourceName{
+	"count/ingresses.extensions",
+	"count/ingresses.networking.k8s.io",
+}
+
+// QuotaResource -- namespace CPU, RAM, storage and objects count
+//
+// swagger:model
+type QuotaResource struct {
+	// swagger: allOf
+	kube_types.Resource
+	// total storage requests in Gi
+	Storage uint `json:"storage,omitempty"`
+	// storage requests in Gi by storage class name
+	StorageClasses map[string]uint `json:"storage_classes,omitempty"`
+	// maximum number of objects
+	Pods                   uint `json:"pods,omitempty"`
+	Services               uint `json:"services,omitempty"`
+	PersistentVolumeClaims uint `json:"persistent_volume_claims,omitempty"`
+	ConfigMaps             uint `json:"config_maps,omitempty"`
+	Secrets                uint `json:"secrets,omitempty"`
+	Ingresses              uint `json:"ingresses,omitempty"`
+}
+
+// NamespaceResources -- namespace limits and used resources
+//
+// swagger:model
+type NamespaceResources struct {
+	// Hard resource limits
+	//
+	// required: true
+	Hard QuotaResource  `json:"hard"`
+	Used *QuotaResource `json:"used,omitempty"`
+}
+
+// QuotaBounds -- allowed range of quota resource. Default is used if resource is not specified.
+type QuotaBounds struct {
+	Min     uint `json:"min"`
+	Max     uint `json:"max"`
+	Default uint `json:"default"`
+}
+
+// QuotaPolicy -- bounds of namespace quota resources
+type QuotaPolicy struct {
+	CPU                    QuotaBounds `json:"cpu"`           //m
+	Memory                 QuotaBounds `json:"memory"`        //Mi
+	Storage                QuotaBounds `json:"storage"`       //Gi
+	StorageClass           QuotaBounds `json:"storage_class"` //Gi, for each class
+	Pods                   QuotaBounds `json:"pods"`
+	Services               QuotaBounds `json:"services"`
+	PersistentVolumeClaims QuotaBounds `json:"persistent_volume_claims"`
+	ConfigMaps             QuotaBounds `json:"config_maps"`
+	Secrets                QuotaBounds `json:"secrets"`
+	Ingresses              QuotaBounds `json:"ingresses"`
+}
+
+// DefaultQuotaPolicy returns bounds used if no policy file is provided.
+// Only CPU and memory are required, storage and objects count are not limited.
+func DefaultQuotaPolicy() QuotaPolicy {
+	return QuotaPolicy{
+		CPU:    QuotaBounds{Min: minNamespaceCPU, Max: maxNamespaceCPU},
+		Memory: QuotaBounds{Min: minNamespaceMemory, Max: maxNamespaceMemory},
+	}
+}
+
+var quotaPolicy = DefaultQuotaPolicy()
+
+// SetQuotaPolicy sets bounds used by ValidateResourceQuota
+func SetQuotaPolicy(policy QuotaPolicy) {
+	quotaPolicy = policy
+}
+
+// LoadQuotaPolicy reads quota policy from YAML or JSON file.
+// Resources not mentioned in file keep default bounds.
+func LoadQuotaPolicy(filename string) (QuotaPolicy, error) {
+	policy := DefaultQuotaPolicy()
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return policy, err
+	}
+	err = yaml.Unmarshal(data, &policy)
+	return policy, err
+}
+
+// ApplyQuotaDefaults sets policy default values for not specified resources
+func ApplyQuotaDefaults(res *QuotaResource) {
+	setDefault := func(value *uint, bounds QuotaBounds) {
+		if *value == 0 {
+			*value = bounds.Default
+		}
+	}
+	setDefault(&res.CPU, quotaPolicy.CPU)
+	setDefault(&res.Memory, quotaPolicy.Memory)
+	setDefault(&res.Storage, quotaPolicy.Storage)
+	setDefault(&res.Pods, quotaPolicy.Pods)
+	setDefault(&res.Services, quotaPolicy.Services)
+	setDefault(&res.PersistentVolumeClaims, quotaPolicy.PersistentVolumeClaims)
+	setDefault(&res.ConfigMaps, quotaPolicy.ConfigMaps)
+	setDefault(&res.Secrets, quotaPolicy.Secrets)
+	setDefault(&res.Ingresses, quotaPolicy.Ingresses)
+}
+
+// MergeQuotaResource sets storage and objects count limits not specified in res from old quota
+func MergeQuotaResource(res *QuotaResource, old QuotaResource) {
+	merge := func(value *uint, oldValue uint) {
+		if *value == 0 {
+			*value = oldValue
+		}
+	}
+	merge(&res.Storage, old.Storage)
+	merge(&res.Pods, old.Pods)
+	merge(&res.Services, old.Services)
+	merge(&res.PersistentVolumeClaims, old.PersistentVolumeClaims)
+	merge(&res.ConfigMaps, old.ConfigMaps)
+	merge(&res.Secrets, old.Secrets)
+	merge(&res.Ingresses, old.Ingresses)
+	if res.StorageClasses == nil {
+		res.StorageClasses = old.StorageClasses
+	}
+}
+
+func ValidateResourceQuota(res QuotaResource) []error {
+	var errs []error
+
+	if res.CPU < quotaPolicy.CPU.Min || res.CPU > quotaPolicy.CPU.Max {
+		errs = append(errs, fmt.Errorf(invalidCPUQuota, res.CPU, quotaPolicy.CPU.Min, quotaPolicy.CPU.Max))
+	}
+
+	if res.Memory < quotaPolicy.Memory.Min || res.Memory > quotaPolicy.Memory.Max {
+		errs = append(errs, fmt.Errorf(invalidMemoryQuota, res.Memory, quotaPolicy.Memory.Min, quotaPolicy.Memory.Max))
+	}
+
+	checkBounds := func(name string, value uint, bounds QuotaBounds) {
+		// zero means no limit, it's allowed only if policy doesn't restrict resource
+		if value == 0 && bounds.Max == 0 {
+			return
+		}
+		if value != 0 && value >= bounds.Min && (value <= bounds.Max || bounds.Max == 0) {
+			return
+		}
+		errs = append(errs, fmt.Errorf(invalidQuota, name, value, bounds.Min, bounds.Max))
+	}
+	checkBounds("storage", res.Storage, quotaPolicy.Storage)
+	checkBounds("pods", res.Pods, quotaPolicy.Pods)
+	checkBounds("services", res.Services, quotaPolicy.Services)
+	checkBounds("persistent volume claims", res.PersistentVolumeClaims, quotaPolicy.PersistentVolumeClaims)
+	checkBounds("config maps", res.ConfigMaps, quotaPolicy.ConfigMaps)
+	checkBounds("secrets", res.Secrets, quotaPolicy.Secrets)
+	checkBounds("ingresses", res.Ingresses, quotaPolicy.Ingresses)
+
+	classes := make([]string, 0, len(res.StorageClasses))
+	for class := range res.StorageClasses {
+		classes = append(classes, class)
+	}
+	sort.Strings(classes)
+	for _, class := range classes {
+		if err := api_validation.IsDNS1123Subdomain(class); len(err) > 0 {
+			errs = append(errs, fmt.Errorf(invalidName, class, strings.Join(err, ",")))
+			continue
+		}
+		checkBounds("storage class "+class, res.StorageClasses[class], quotaPolicy.StorageClass)
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func makeQuotaResourceList(res QuotaResource) api_core.ResourceList {
+	cpuLim := api_resource.NewScaledQuantity(int64(res.CPU), api_resource.Milli)
+	memLim := api_resource.NewQuantity(int64(res.Memory)*1024*1024, api_resource.BinarySI)
+	//Requests is equal to Limits
+	cpuReq := cpuLim
+	memReq := memLim
+
+	list := api_core.ResourceList{
+		api_core.ResourceRequestsCPU:    *cpuReq,
+		api_core.ResourceLimitsCPU:      *cpuLim,
+		api_core.ResourceRequestsMemory: *memReq,
+		api_core.ResourceLimitsMemory:   *memLim,
+	}
+
+	setStorage := func(name api_core.ResourceName, value uint) {
+		if value > 0 {
+			list[name] = *api_resource.NewQuantity(int64(value)*1024*1024*1024, api_resource.BinarySI)
+		}
+	}
+	setStorage(api_core.ResourceRequestsStorage, res.Storage)
+	for class, value := range res.StorageClasses {
+		setStorage(api_core.ResourceName(class+storageClassQuotaSuffix), value)
+	}
+
+	setCount := func(name api_core.ResourceName, value uint) {
+		if value > 0 {
+			list[name] = *api_resource.NewQuantity(int64(value), api_resource.DecimalSI)
+		}
+	}
+	setCount(api_core.ResourcePods, res.Pods)
+	setCount(api_core.ResourceServices, res.Services)
+	setCount(api_core.ResourcePersistentVolumeClaims, res.PersistentVolumeClaims)
+	setCount(api_core.ResourceConfigMaps, res.ConfigMaps)
+	setCount(api_core.ResourceSecrets, res.Secrets)
+	for _, name := range ingressesQuotas {
+		setCount(name, res.Ingresses)
+	}
+
+	return list
+}
+
+func parseQuotaResourceList(list api_core.ResourceList, cpuName, memoryName api_core.ResourceName) QuotaResource {
+	cpu := list[cpuName]
+	memory := list[memoryName]
+	storage := list[api_core.ResourceRequestsStorage]
+
+	res := QuotaResource{
+		Resource: kube_types.Resource{
+			CPU:    uint(cpu.ScaledValue(api_resource.Milli)),
+			Memory: uint(memory.Value() / 1024 / 1024),
+		},
+		Storage: uint(storage.Value() / 1024 / 1024 / 1024),
+	}
+
+	for name, value := range list {
+		if strings.HasSuffix(string(name), storageClassQuotaSuffix) {
+			if res.StorageClasses == nil {
+				res.StorageClasses = make(map[string]uint)
+			}
+			res.StorageClasses[strings.TrimSuffix(string(name), storageClassQuotaSuffix)] = uint(value.Value() / 1024 / 1024 / 1024)
+		}
+	}
+
+	count := func(name api_core.ResourceName) uint {
+		value := list[name]
+		return uint(value.Value())
+	}
+	res.Pods = count(api_core.ResourcePods)
+	res.Services = count(api_core.ResourceServices)
+	res.PersistentVolumeClaims = count(api_core.ResourcePersistentVolumeClaims)
+	res.ConfigMaps = count(api_core.ResourceConfigMaps)
+	res.Secrets = count(api_core.ResourceSecrets)
+	for _, name := range ingressesQuotas {
+		if ingresses := count(name); ingresses > res.Ingresses {
+			res.Ingresses = ingresses
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,114 @@
+package model
+
+import (
+	"testing"
+
+	kube_types "github.com/containerum/kube-client/pkg/model"
+	api_core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func testQuotaPolicy() QuotaPolicy {
+	policy := DefaultQuotaPolicy()
+	policy.Storage = QuotaBounds{Min: 1, Max: 2048, Default: 20}
+	policy.StorageClass = QuotaBounds{Min: 1, Max: 2048}
+	policy.Pods = QuotaBounds{Min: 1, Max: 500, Default: 50}
+	policy.Services = QuotaBounds{Min: 1, Max: 100, Default: 20}
+	policy.ConfigMaps = QuotaBounds{Min: 1, Max: 500, Default: 50}
+	policy.Ingresses = QuotaBounds{Min: 1, Max: 100, Default: 20}
+	return policy
+}
+
+func TestMakeResourceQuota(t *testing.T) {
+	defer SetQuotaPolicy(DefaultQuotaPolicy())
+	SetQuotaPolicy(testQuotaPolicy())
+
+	quota, errs := MakeResourceQuota("test", nil, QuotaResource{
+		Resource:       kube_types.Resource{CPU: 1000, Memory: 512},
+		Storage:        50,
+		StorageClasses: map[string]uint{"ssd": 10},
+		Pods:           30,
+	})
+	if errs != nil {
+		t.Fatal(errs)
+	}
+
+	expected := map[api_core.ResourceName]string{
+		api_core.ResourceLimitsCPU:                         "1",
+		api_core.ResourceRequestsStorage:                   "50Gi",
+		"ssd.storageclass.storage.k8s.io/requests.storage": "10Gi",
+		api_core.ResourcePods:                              "30",
+		// defaults from policy
+		api_core.ResourceServices:           "20",
+		"count/ingresses.extensions":        "20",
+		"count/ingresses.networking.k8s.io": "20",
+	}
+	for name, value := range expected {
+		actual := quota.Spec.Hard[name]
+		if actual.Cmp(resource.MustParse(value)) != 0 {
+			t.Errorf("%v: expected %v, got %v", name, value, actual.String())
+		}
+	}
+
+	quota.Status.Used = api_core.ResourceList{
+		api_core.ResourceLimitsCPU:          resource.MustParse("500m"),
+		api_core.ResourcePods:               resource.MustParse("3"),
+		"count/ingresses.networking.k8s.io": resource.MustParse("2"),
+	}
+	ns, err := ParseKubeResourceQuota(quota)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hard := ns.Resources.Hard
+	if hard.CPU != 1000 || hard.Memory != 512 || hard.Storage != 50 || hard.StorageClasses["ssd"] != 10 ||
+		hard.Pods != 30 || hard.ConfigMaps != 50 {
+		t.Errorf("unexpected hard quota: %+v", hard)
+	}
+	if used := ns.Resources.Used; used.CPU != 500 || used.Pods != 3 || used.Ingresses != 2 {
+		t.Errorf("unexpected used quota: %+v", used)
+	}
+}
+
+func TestDefaultQuotaPolicy(t *testing.T) {
+	res := QuotaResource{Resource: kube_types.Resource{CPU: 1000, Memory: 512}}
+	ApplyQuotaDefaults(&res)
+	if res.Storage != 0 || res.Pods != 0 || res.Ingresses != 0 {
+		t.Fatalf("objects count should not be limited by default: %+v", res)
+	}
+	if errs := ValidateResourceQuota(res); errs != nil {
+		t.Fatal(errs)
+	}
+}
+
+func TestValidateResourceQuota(t *testing.T) {
+	defer SetQuotaPolicy(DefaultQuotaPolicy())
+	SetQuotaPolicy(testQuotaPolicy())
+
+	valid := QuotaResource{Resource: kube_types.Resource{CPU: 1000, Memory: 512}}
+	ApplyQuotaDefaults(&valid)
+	if errs := ValidateResourceQuota(valid); errs != nil {
+		t.Fatal(errs)
+	}
+
+	invalid := valid
+	invalid.Pods = 100000
+	invalid.StorageClasses = map[string]uint{"Bad_Class": 1, "hdd": 100000}
+	if errs := ValidateResourceQuota(invalid); len(errs) != 3 {
+		t.Fatalf("expected 3 errors, got %v", errs)
+	}
+
+	policy := testQuotaPolicy()
+	policy.Pods = QuotaBounds{}
+	SetQuotaPolicy(policy)
+	unlimited := valid
+	unlimited.Pods = 0
+	if errs := ValidateResourceQuota(unlimited); errs != nil {
+		t.Fatalf("pods number should not be limited: %v", errs)
+	}
+
+	policy.Pods = QuotaBounds{Max: 100}
+	SetQuotaPolicy(policy)
+	if errs := ValidateResourceQuota(unlimited); len(errs) != 1 {
+		t.Fatalf("unlimited pods number should be rejected if policy limits it, got %v", errs)
+	}
+}
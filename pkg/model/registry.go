@@ -0,0 +1,138 @@
+package model
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	kube_types "github.com/containerum/kube-client/pkg/model"
+	api_core "k8s.io/api/core/v1"
+)
+
+var (
+	ErrInvalidDockerConfig = errors.New("invalid .dockerconfigjson")
+	ErrNoRegistries        = errors.New("no registries in .dockerconfigjson")
+)
+
+// DockerSecret -- docker registry secret with .dockerconfigjson in data or registry credentials
+//
+// swagger:model
+type DockerSecret struct {
+	// swagger: allOf
+	kube_types.Secret
+	// registry server, e.g. registry.example.com:5000
+	Registry string `json:"registry,omitempty"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	Email    string `json:"email,omitempty"`
+}
+
+type dockerConfigJSON struct {
+	Auths map[string]dockerConfigEntry `json:"auths"`
+}
+
+type dockerConfigEntry struct {
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	Email    string `json:"email,omitempty"`
+	Auth     string `json:"auth,omitempty"`
+}
+
+// MakeSecret returns secret with .dockerconfigjson generated from registry credentials.
+// If credentials are not set, .dockerconfigjson from data is used.
+func (secret *DockerSecret) MakeSecret() (*SecretKubeAPI, []error) {
+	newSecret := SecretKubeAPI(secret.Secret)
+	if secret.Registry == "" && secret.Username == "" && secret.Password == "" && secret.Email == "" {
+		return &newSecret, nil
+	}
+
+	var errs []error
+	if _, hasConfig := secret.Data[api_core.DockerConfigJsonKey]; hasConfig {
+		errs = append(errs, fmt.Errorf(exclusiveFields, "data."+api_core.DockerConfigJsonKey, "registry"))
+	}
+	if secret.Registry == "" {
+		errs = append(errs, fmt.Errorf(fieldShouldExist, "registry"))
+	}
+	if secret.Username == "" {
+		errs = append(errs, fmt.Errorf(fieldShouldExist, "username"))
+	}
+	if secret.Password == "" {
+		errs = append(errs, fmt.Errorf(fieldShouldExist, "password"))
+	}
+	if errs != nil {
+		return nil, errs
+	}
+
+	config, err := json.Marshal(dockerConfigJSON{Auths: map[string]dockerConfigEntry{
+		secret.Registry: {
+			Username: secret.Username,
+			Password: secret.Password,
+			Email:    secret.Email,
+			Auth:     base64.StdEncoding.EncodeToString([]byte(secret.Username + ":" + secret.Password)),
+		},
+	}})
+	if err != nil {
+		return nil, []error{err}
+	}
+	newSecret.Data = map[string]string{api_core.DockerConfigJsonKey: string(config)}
+	return &newSecret, nil
+}
+
+// NormalizeDockerConfigJSON checks that value is .dockerconfigjson with credentials of at least one registry.
+// Value may be base64 encoded, decoded value is returned.
+func NormalizeDockerConfigJSON(value string) (string, error) {
+	config, err := parseDockerConfigJSON([]byte(value))
+	if err != nil {
+		decoded, decodeErr := base64.StdEncoding.DecodeString(value)
+		if decodeErr != nil {
+			return "", err
+		}
+		if config, err = parseDockerConfigJSON(decoded); err != nil {
+			return "", err
+		}
+		value = string(decoded)
+	}
+
+	if len(config.Auths) == 0 {
+		return "", ErrNoRegistries
+	}
+	for registry, entry := range config.Auths {
+		if registry == "" {
+			return "", fmt.Errorf("%v: empty registry", ErrInvalidDockerConfig)
+		}
+		if entry.Auth != "" {
+			auth, err := base64.StdEncoding.DecodeString(entry.Auth)
+			if err != nil || !strings.Contains(string(auth), ":") {
+				return "", fmt.Errorf("%v: invalid auth of registry %v", ErrInvalidDockerConfig, registry)
+			}
+		} else if entry.Username == "" || entry.Password == "" {
+			return "", fmt.Errorf("%v: no credentials of registry %v", ErrInvalidDockerConfig, registry)
+		}
+	}
+	return value, nil
+}
+
+func parseDockerConfigJSON(value []byte) (*dockerConfigJSON, error) {
+	var config dockerConfigJSON
+	if err := json.Unmarshal(value, &config); err != nil {
+		return nil, fmt.Errorf("%v: %v", ErrInvalidDockerConfig, err)
+	}
+	return &config, nil
+}
+
+// getDockerRegistries returns registries from .dockerconfigjson
+func getDockerRegistries(value []byte) []string {
+	config, err := parseDockerConfigJSON(value)
+	if err != nil {
+		return nil
+	}
+	registries := make([]string, 0, len(config.Auths))
+	for registry := range config.Auths {
+		registries = append(registries, registry)
+	}
+	sort.Strings(registries)
+	return registries
+}
@@ -0,0 +1,73 @@
+package model
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	kube_types "github.com/containerum/kube-client/pkg/model"
+	api_core "k8s.io/api/core/v1"
+)
+
+func TestDockerSecretFromCredentials(t *testing.T) {
+	req := DockerSecret{
+		Secret:   kube_types.Secret{Name: "registry"},
+		Registry: "registry.example.com:5000",
+		Username: "user",
+		Password: "pa$$word",
+		Email:    "user@example.com",
+	}
+	secret, errs := req.MakeSecret()
+	if errs != nil {
+		t.Fatal(errs)
+	}
+	native, errs := secret.ToKube("ns", "", map[string]string{}, api_core.SecretTypeDockerConfigJson)
+	if errs != nil {
+		t.Fatal(errs)
+	}
+	config := string(native.Data[api_core.DockerConfigJsonKey])
+	auth := base64.StdEncoding.EncodeToString([]byte("user:pa$$word"))
+	if !strings.Contains(config, `"registry.example.com:5000"`) || !strings.Contains(config, `"auth":"`+auth+`"`) {
+		t.Errorf("unexpected .dockerconfigjson: %v", config)
+	}
+
+	parsed, err := ParseKubeSecretWithType(native, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parsed.Data) != 0 || strings.Join(parsed.Registries, ",") != "registry.example.com:5000" {
+		t.Errorf("unexpected parsed secret: %+v", parsed)
+	}
+
+	req.Password = ""
+	req.Data = map[string]string{api_core.DockerConfigJsonKey: config}
+	if _, errs := req.MakeSecret(); len(errs) != 2 {
+		t.Errorf("expected 2 errors, got %v", errs)
+	}
+}
+
+func TestNormalizeDockerConfigJSON(t *testing.T) {
+	config := `{"auths":{"docker.io":{"auth":"dXNlcjpwYXNz"},"quay.io":{"username":"user","password":"pass"}}}`
+	if normalized, err := NormalizeDockerConfigJSON(config); err != nil || normalized != config {
+		t.Errorf("unexpected result: %v %v", normalized, err)
+	}
+	encoded := base64.StdEncoding.EncodeToString([]byte(config))
+	if normalized, err := NormalizeDockerConfigJSON(encoded); err != nil || normalized != config {
+		t.Errorf("base64 config is not decoded: %v %v", normalized, err)
+	}
+	if registries := getDockerRegistries([]byte(config)); strings.Join(registries, ",") != "docker.io,quay.io" {
+		t.Errorf("unexpected registries: %v", registries)
+	}
+
+	for _, invalid := range []string{
+		"not json",
+		`{"auths":{}}`,
+		`{"auths":{"docker.io":{"auth":"not base64"}}}`,
+		`{"auths":{"docker.io":{"username":"user"}}}`,
+		`{"auths":{"":{"auth":"dXNlcjpwYXNz"}}}`,
+	} {
+		if _, err := NormalizeDockerConfigJSON(invalid); err == nil {
+			t.Errorf("expected error for %v", invalid)
+		}
+	}
+}
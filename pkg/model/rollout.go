@@ -0,0 +1,73 @@
+package model
+
+import (
+	"time"
+
+	"github.com/containerum/cherry"
+	api_apps "k8s.io/api/apps/v1"
+)
+
+// changing pod template annotation makes deployment controller replace pods
+const restartedAtAnnotation = "containerum.io/restartedAt"
+
+// ConfigMapWithRollout -- updated config map and results of restarting deployments using it
+//
+// swagger:model
+type ConfigMapWithRollout struct {
+	// swagger: allOf
+	ConfigMapWithBinaryData
+	// restarted deployments, set if rollout was requested
+	Rollout []ApplyResult `json:"rollout,omitempty"`
+	// reason why dependent deployments were not restarted
+	RolloutError *cherry.Err `json:"rollout_error,omitempty"`
+}
+
+// SecretWithRollout -- updated secret and results of restarting deployments using it
+//
+// swagger:model
+type SecretWithRollout struct {
+	// swagger: allOf
+	SecretWithType
+	// restarted deployments, set if rollout was requested
+	Rollout []ApplyResult `json:"rollout,omitempty"`
+	// reason why dependent deployments were not restarted
+	RolloutError *cherry.Err `json:"rollout_error,omitempty"`
+}
+
+// GetDependentDeployments returns deployments which mount config map or secret as volume
+// or reference it in container environment
+func GetDependentDeployments(deployments interface{}, kind, name string) []api_apps.Deployment {
+	deployList := deployments.(*api_apps.DeploymentList)
+
+	var dependents []api_apps.Deployment
+	for _, deploy := range deployList.Items {
+		if deploymentUsesObject(&deploy, kind, name) {
+			dependents = append(dependents, deploy)
+		}
+	}
+	return dependents
+}
+
+func deploymentUsesObject(deploy *api_apps.Deployment, kind, name string) bool {
+	if kind == configMapKind {
+		for _, v := range deploy.Spec.Template.Spec.Volumes {
+			if v.ConfigMap != nil && v.ConfigMap.Name == name {
+				return true
+			}
+		}
+	}
+	for _, ref := range GetObjectReferences(deploy) {
+		if ref.Kind == kind && ref.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// RestartDeployment changes pod template annotation, so pods are replaced according to deployment strategy
+func RestartDeployment(deploy *api_apps.Deployment, now time.Time) {
+	if deploy.Spec.Template.Annotations == nil {
+		deploy.Spec.Template.Annotations = make(map[string]string)
+	}
+	deploy.Spec.Template.Annotations[restartedAtAnnotation] = now.UTC().Format(time.RFC3339Nano)
+}
@@ -0,0 +1,59 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	kube_types "github.com/containerum/kube-client/pkg/model"
+	api_apps "k8s.io/api/apps/v1"
+)
+
+func TestGetDependentDeployments(t *testing.T) {
+	withConfig := DeploymentKubeAPI(testSolution().Deployments[0])
+	withConfig.Name = "with-config"
+	withConfig.Containers[0].ConfigMaps = []kube_types.ContainerVolume{{Name: "blog-config", MountPath: "/etc/blog"}}
+
+	withEnv := DeploymentKubeAPI(testSolution().Deployments[0])
+	withEnv.Name = "with-env"
+	withEnv.Containers[0].Env = []EnvVar{
+		{Name: "DB_PASSWORD", ValueFrom: &EnvValueFrom{SecretKeyRef: &EnvKeyRef{Name: "blog-db", Key: "password"}}},
+	}
+
+	unrelated := DeploymentKubeAPI(testSolution().Deployments[0])
+	unrelated.Name = "unrelated"
+
+	list := &api_apps.DeploymentList{}
+	for _, deploy := range []DeploymentKubeAPI{withConfig, withEnv, unrelated} {
+		native, errs := deploy.ToKube("ns", map[string]string{})
+		if errs != nil {
+			t.Fatal(errs)
+		}
+		list.Items = append(list.Items, *native)
+	}
+
+	for _, tc := range []struct {
+		kind, name string
+		expected   string
+	}{
+		{configMapKind, "blog-config", "with-config"},
+		{secretKind, "blog-db", "with-env"},
+		{secretKind, "blog-config", ""},
+	} {
+		dependents := GetDependentDeployments(list, tc.kind, tc.name)
+		switch {
+		case tc.expected == "" && len(dependents) != 0:
+			t.Errorf("%v %v: unexpected dependents %v", tc.kind, tc.name, dependents)
+		case tc.expected != "" && (len(dependents) != 1 || dependents[0].Name != tc.expected):
+			t.Errorf("%v %v: expected %v, got %v", tc.kind, tc.name, tc.expected, dependents)
+		}
+	}
+}
+
+func TestRestartDeployment(t *testing.T) {
+	deploy := api_apps.Deployment{}
+	now := time.Date(2018, 7, 1, 12, 0, 0, 0, time.UTC)
+	RestartDeployment(&deploy, now)
+	if deploy.Spec.Template.Annotations[restartedAtAnnotation] != "2018-07-01T12:00:00Z" {
+		t.Errorf("unexpected annotations: %v", deploy.Spec.Template.Annotations)
+	}
+}
@@ -1,8 +1,10 @@
 package model
 
 import (
+	"encoding/base64"
 	"fmt"
 	"strings"
+	"unicode/utf8"
 
 	"time"
 
@@ -18,8 +20,117 @@ type SecretKubeAPI kube_types.Secret
 const (
 	secretKind       = "Secret"
 	secretAPIVersion = "v1"
+
+	// SecretEncodingPlain -- secret values are plain strings
+	SecretEncodingPlain = "plain"
+	// SecretEncodingBase64 -- secret values are base64 encoded, used for binary values
+	SecretEncodingBase64 = "base64"
 )
 
+// SecretWithTypeList -- model for secrets list with secret types
+//
+// swagger:model
+type SecretWithTypeList struct {
+	Secrets []SecretWithType `json:"secrets"`
+}
+
+// SecretWithType -- model for secret with type and encoding of values
+//
+// swagger:model
+type SecretWithType struct {
+	// swagger: allOf
+	kube_types.Secret
+	// secret type: Opaque, kubernetes.io/tls, kubernetes.io/dockerconfigjson, etc.
+	Type string `json:"type,omitempty"`
+	// encoding of data values: plain (default) or base64
+	Encoding string `json:"encoding,omitempty"`
+	// metadata of TLS certificate, private key is not returned
+	Certificate *CertificateInfo `json:"certificate,omitempty"`
+	// registries of docker registry secret, credentials are not returned
+	Registries []string `json:"registries,omitempty"`
+}
+
+// ParseKubeSecretWithTypeList parses kubernetes v1.SecretList of any types
+func ParseKubeSecretWithTypeList(secreti interface{}, parseforuser bool) (*SecretWithTypeList, error) {
+	nativeSecrets := secreti.(*api_core.SecretList)
+	if nativeSecrets == nil {
+		return nil, ErrUnableConvertSecretList
+	}
+
+	secrets := make([]SecretWithType, 0, len(nativeSecrets.Items))
+	for i := range nativeSecrets.Items {
+		newSecret, err := ParseKubeSecretWithType(&nativeSecrets.Items[i], parseforuser)
+		if err != nil {
+			return nil, err
+		}
+		secrets = append(secrets, *newSecret)
+	}
+	return &SecretWithTypeList{Secrets: secrets}, nil
+}
+
+// ParseKubeSecretWithType parses kubernetes v1.Secret with its type.
+// If any value is binary, all values are base64 encoded.
+func ParseKubeSecretWithType(secreti interface{}, parseforuser bool) (*SecretWithType, error) {
+	secret, err := ParseKubeSecret(secreti, parseforuser)
+	if err != nil {
+		return nil, err
+	}
+	native := secreti.(*api_core.Secret)
+
+	newSecret := SecretWithType{
+		Secret:   *secret,
+		Type:     string(native.Type),
+		Encoding: SecretEncodingPlain,
+	}
+	if native.Type == api_core.SecretTypeTLS {
+		if cert, err := ParseCertificateChain(native.Data[api_core.TLSCertKey]); err == nil {
+			newSecret.Certificate = ParseCertificateInfo(cert)
+		}
+		delete(newSecret.Data, api_core.TLSPrivateKeyKey)
+	}
+	if native.Type == api_core.SecretTypeDockerConfigJson {
+		newSecret.Registries = getDockerRegistries(native.Data[api_core.DockerConfigJsonKey])
+		delete(newSecret.Data, api_core.DockerConfigJsonKey)
+	}
+	for _, v := range native.Data {
+		if !utf8.Valid(v) {
+			newSecret.Encoding = SecretEncodingBase64
+			break
+		}
+	}
+	if newSecret.Encoding == SecretEncodingBase64 {
+		for k := range newSecret.Data {
+			newSecret.Data[k] = base64.StdEncoding.EncodeToString(native.Data[k])
+		}
+	}
+	return &newSecret, nil
+}
+
+// DecodeData returns secret with decoded values. Keys are validated with SecretKubeAPI.Validate by ToKube.
+// Values which can't be decoded are omitted, so the rest of secret still can be validated.
+func (secret *SecretWithType) DecodeData() (*SecretKubeAPI, []error) {
+	decoded := SecretKubeAPI(secret.Secret)
+	switch secret.Encoding {
+	case "", SecretEncodingPlain:
+		return &decoded, nil
+	case SecretEncodingBase64:
+	default:
+		return nil, []error{fmt.Errorf("invalid encoding %q, expected %q or %q", secret.Encoding, SecretEncodingPlain, SecretEncodingBase64)}
+	}
+
+	var errs []error
+	decoded.Data = make(map[string]string, len(secret.Data))
+	for k, v := range secret.Data {
+		value, err := base64.StdEncoding.DecodeString(v)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid base64 value of %v: %v", k, err))
+			continue
+		}
+		decoded.Data[k] = string(value)
+	}
+	return &decoded, errs
+}
+
 // ParseKubeSecretList parses kubernetes v1.SecretList to more convenient []Secret struct.
 func ParseKubeSecretList(secreti interface{}, parseforuser bool) (*kube_types.SecretsList, error) {
 	nativeSecrets := secreti.(*api_core.SecretList)
@@ -66,8 +177,9 @@ func ParseKubeSecret(secreti interface{}, parseforuser bool) (*kube_types.Secret
 
 }
 
-// ToKube creates kubernetes v1.Secret from Secret struct and namespace labels
-func (secret *SecretKubeAPI) ToKube(nsName string, labels map[string]string, secretType api_core.SecretType) (*api_core.Secret, []error) {
+// ToKube creates kubernetes v1.Secret from Secret struct and namespace labels.
+// If solutionID is not empty, secret is labeled as part of solution.
+func (secret *SecretKubeAPI) ToKube(nsName string, solutionID string, labels map[string]string, secretType api_core.SecretType) (*api_core.Secret, []error) {
 	err := secret.Validate()
 	if err != nil {
 		return nil, err
@@ -76,9 +188,29 @@ func (secret *SecretKubeAPI) ToKube(nsName string, labels map[string]string, sec
 	if labels == nil {
 		return nil, []error{kubeerrors.ErrInternalError().AddDetails("invalid project labels")}
 	}
+	if solutionID != "" {
+		labels[solutionLabel] = solutionID
+	}
 
-	if secretType == api_core.SecretTypeDockerConfigJson && secret.Data[".dockerconfigjson"] == "" {
-		return nil, []error{kubeerrors.ErrRequestValidationFailed().AddDetails("field '.dockerconfigjson' is required")}
+	data := secret.Data
+	if secretType == api_core.SecretTypeDockerConfigJson {
+		if secret.Data[api_core.DockerConfigJsonKey] == "" {
+			return nil, []error{kubeerrors.ErrRequestValidationFailed().AddDetails("field '.dockerconfigjson' is required")}
+		}
+		config, err := NormalizeDockerConfigJSON(secret.Data[api_core.DockerConfigJsonKey])
+		if err != nil {
+			return nil, []error{err}
+		}
+		data = make(map[string]string, len(secret.Data))
+		for k, v := range secret.Data {
+			data[k] = v
+		}
+		data[api_core.DockerConfigJsonKey] = config
+	}
+	if secretType == api_core.SecretTypeTLS {
+		if errs := ValidateTLSData(secret.Data, time.Now()); errs != nil {
+			return nil, errs
+		}
 	}
 
 	newSecret := api_core.Secret{
@@ -91,7 +223,7 @@ func (secret *SecretKubeAPI) ToKube(nsName string, labels map[string]string, sec
 			Name:      secret.Name,
 			Namespace: nsName,
 		},
-		Data: makeSecretData(secret.Data),
+		Data: makeSecretData(data),
 		Type: secretType,
 	}
 
@@ -0,0 +1,72 @@
+package model
+
+import (
+	"testing"
+
+	kube_types "github.com/containerum/kube-client/pkg/model"
+	api_core "k8s.io/api/core/v1"
+	api_meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSecretWithTypeEncoding(t *testing.T) {
+	secret := SecretWithType{
+		Secret: kube_types.Secret{
+			Name: "keys",
+			Data: map[string]string{"key.bin": "AP8=", "password": "cXdlcnR5"},
+		},
+		Encoding: SecretEncodingBase64,
+	}
+	decoded, errs := secret.DecodeData()
+	if errs != nil {
+		t.Fatal(errs)
+	}
+	native, errs := decoded.ToKube("ns", "", map[string]string{}, api_core.SecretTypeOpaque)
+	if errs != nil {
+		t.Fatal(errs)
+	}
+	if string(native.Data["key.bin"]) != "\x00\xff" || string(native.Data["password"]) != "qwerty" {
+		t.Errorf("unexpected data: %v", native.Data)
+	}
+
+	// binary values are returned base64 encoded
+	parsed, err := ParseKubeSecretWithType(native, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Type != "Opaque" || parsed.Encoding != SecretEncodingBase64 || parsed.Data["key.bin"] != "AP8=" || parsed.Data["password"] != "cXdlcnR5" {
+		t.Errorf("unexpected parsed secret: %+v", parsed)
+	}
+
+	delete(native.Data, "key.bin")
+	if parsed, _ = ParseKubeSecretWithType(native, true); parsed.Encoding != SecretEncodingPlain || parsed.Data["password"] != "qwerty" {
+		t.Errorf("unexpected parsed secret: %+v", parsed)
+	}
+
+	secret.Data["password"] = "not base64"
+	partial, errs := secret.DecodeData()
+	if len(errs) != 1 {
+		t.Errorf("expected invalid value error, got %v", errs)
+	}
+	if _, hasPassword := partial.Data["password"]; hasPassword || len(partial.Data) != len(secret.Data)-1 {
+		t.Errorf("only invalid value should be omitted: %+v", partial.Data)
+	}
+	secret.Encoding = "hex"
+	if _, errs := secret.DecodeData(); errs == nil {
+		t.Error("expected invalid encoding error")
+	}
+}
+
+func TestParseKubeSecretWithTypeList(t *testing.T) {
+	secrets := &api_core.SecretList{Items: []api_core.Secret{
+		{ObjectMeta: api_meta.ObjectMeta{Name: "db"}, Type: api_core.SecretTypeOpaque},
+		{ObjectMeta: api_meta.ObjectMeta{Name: "registry"}, Type: api_core.SecretTypeDockerConfigJson},
+		{ObjectMeta: api_meta.ObjectMeta{Name: "cert"}, Type: api_core.SecretTypeTLS},
+	}}
+	list, err := ParseKubeSecretWithTypeList(secrets, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Secrets) != 3 || list.Secrets[1].Type != "kubernetes.io/dockerconfigjson" || list.Secrets[2].Type != "kubernetes.io/tls" {
+		t.Errorf("unexpected secrets: %+v", list.Secrets)
+	}
+}
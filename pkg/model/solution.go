@@ -0,0 +1,220 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	kube_types "github.com/containerum/kube-client/pkg/model"
+	api_core "k8s.io/api/core/v1"
+	api_meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	api_validation "k8s.io/apimachinery/pkg/util/validation"
+)
+
+// SolutionKubeAPI -- resources of solution which are created together
+//
+// swagger:model
+type SolutionKubeAPI struct {
+	Deployments []DeploymentWithRefs      `json:"deployments,omitempty"`
+	Services    []kube_types.Service      `json:"services,omitempty"`
+	ConfigMaps  []ConfigMapWithBinaryData `json:"config_maps,omitempty"`
+	Ingresses   []IngressWithOptions      `json:"ingresses,omitempty"`
+	Volumes     []kube_types.Volume       `json:"volumes,omitempty"`
+	Secrets     []kube_types.Secret       `json:"secrets,omitempty"`
+}
+
+// ValidateSolutionID checks that solution ID can be used as label value
+func ValidateSolutionID(solutionID string) []error {
+	if solutionID == "" {
+		return []error{fmt.Errorf(fieldShouldExist, "solution")}
+	}
+	if err := api_validation.IsValidLabelValue(solutionID); len(err) > 0 {
+		return []error{fmt.Errorf(invalidName, solutionID, strings.Join(err, ","))}
+	}
+	return nil
+}
+
+// ParseSolutionID returns ID of solution which object belongs to or empty string
+func ParseSolutionID(obj api_meta.Object) string {
+	return obj.GetLabels()[solutionLabel]
+}
+
+// ToKube creates kubernetes objects of solution in order of creation:
+// volumes, config maps and secrets are created before deployments using them, ingresses are created after services.
+// All objects are labeled with solution ID. Errors are prefixed with kind and name of object.
+func (solution *SolutionKubeAPI) ToKube(nsName string, solutionID string, nsLabels map[string]string) ([]ManifestObject, []error) {
+	errs := ValidateSolutionID(solutionID)
+	if errs != nil {
+		return nil, errs
+	}
+
+	var objects []ManifestObject
+	add := func(kind, name string, makeObj func(labels map[string]string) (runtime.Object, []error)) {
+		// ToKube adds object labels to map
+		labels := make(map[string]string, len(nsLabels))
+		for k, v := range nsLabels {
+			labels[k] = v
+		}
+		obj, objErrs := makeObj(labels)
+		for _, err := range objErrs {
+			errs = append(errs, fmt.Errorf("%v %v: %v", kind, name, err))
+		}
+		if objErrs == nil {
+			objects = append(objects, ManifestObject{
+				Kind:      kind,
+				Name:      name,
+				Namespace: nsName,
+				Object:    obj,
+			})
+		}
+	}
+
+	for i := range solution.Volumes {
+		volume := VolumeKubeAPI(solution.Volumes[i])
+		add(pvcKind, volume.Name, func(labels map[string]string) (runtime.Object, []error) {
+			obj, errs := volume.ToKube(nsName, solutionID, labels)
+			if errs != nil {
+				return nil, errs
+			}
+			return obj, nil
+		})
+	}
+	for i := range solution.ConfigMaps {
+		cm := ConfigMapKubeAPI(solution.ConfigMaps[i])
+		add(configMapKind, cm.Name, func(labels map[string]string) (runtime.Object, []error) {
+			obj, errs := cm.ToKube(nsName, solutionID, labels)
+			if errs != nil {
+				return nil, errs
+			}
+			return obj, nil
+		})
+	}
+	for i := range solution.Secrets {
+		secret := SecretKubeAPI(solution.Secrets[i])
+		add(secretKind, secret.Name, func(labels map[string]string) (runtime.Object, []error) {
+			obj, errs := secret.ToKube(nsName, solutionID, labels, api_core.SecretTypeOpaque)
+			if errs != nil {
+				return nil, errs
+			}
+			return obj, nil
+		})
+	}
+	for i := range solution.Deployments {
+		deploy := DeploymentKubeAPI(solution.Deployments[i])
+		deploy.SolutionID = solutionID
+		add(deploymentKind, deploy.Name, func(labels map[string]string) (runtime.Object, []error) {
+			obj, errs := deploy.ToKube(nsName, labels)
+			if errs != nil {
+				return nil, errs
+			}
+			return obj, nil
+		})
+	}
+	for i := range solution.Services {
+		svc := ServiceWithParam{Service: &solution.Services[i]}
+		svc.SolutionID = solutionID
+		add(serviceKind, svc.Name, func(labels map[string]string) (runtime.Object, []error) {
+			obj, errs := svc.ToKube(nsName, labels)
+			if errs != nil {
+				return nil, errs
+			}
+			return obj, nil
+		})
+	}
+	for i := range solution.Ingresses {
+		ingress := IngressKubeAPI(solution.Ingresses[i])
+		add(ingressKind, ingress.Name, func(labels map[string]string) (runtime.Object, []error) {
+			obj, errs := ingress.ToKube(nsName, solutionID, labels)
+			if errs != nil {
+				return nil, errs
+			}
+			return obj, nil
+		})
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	if len(objects) == 0 {
+		return nil, []error{fmt.Errorf(fieldShouldExist, "deployments")}
+	}
+	return objects, nil
+}
+
+// ParseSolutionObjects parses kubernetes objects of solution grouped by kind
+func ParseSolutionObjects(objects interface{}, parseforuser bool) (*SolutionKubeAPI, error) {
+	objs := objects.(*NamespaceObjects)
+
+	deploys, err := ParseKubeDeploymentList(objs.Deployments, parseforuser)
+	if err != nil {
+		return nil, err
+	}
+	services, err := ParseKubeServiceList(objs.Services, parseforuser)
+	if err != nil {
+		return nil, err
+	}
+	configMaps, err := ParseKubeConfigMapList(objs.ConfigMaps, parseforuser)
+	if err != nil {
+		return nil, err
+	}
+	ingresses, err := ParseKubeIngressList(objs.Ingresses, parseforuser)
+	if err != nil {
+		return nil, err
+	}
+	volumes, err := ParseKubePersistentVolumeClaimList(objs.PersistentVolumeClaims, parseforuser)
+	if err != nil {
+		return nil, err
+	}
+	secrets, err := ParseKubeSecretList(objs.Secrets, parseforuser)
+	if err != nil {
+		return nil, err
+	}
+
+	solution := SolutionKubeAPI{
+		Deployments: deploys.Deployments,
+		ConfigMaps:  configMaps.ConfigMaps,
+		Ingresses:   ingresses.Ingress,
+		Volumes:     volumes.Volumes,
+		Secrets:     secrets.Secrets,
+	}
+	for _, svc := range services.Services {
+		solution.Services = append(solution.Services, *svc.Service)
+	}
+	return &solution, nil
+}
+
+// MakeSolutionObjects returns kubernetes objects of solution in the same order as ToKube does
+func MakeSolutionObjects(objects interface{}) []ManifestObject {
+	objs := objects.(*NamespaceObjects)
+
+	var list []ManifestObject
+	add := func(kind string, obj runtime.Object, meta api_meta.Object) {
+		list = append(list, ManifestObject{
+			Kind:      kind,
+			Name:      meta.GetName(),
+			Namespace: meta.GetNamespace(),
+			Object:    obj,
+		})
+	}
+	for i := range objs.PersistentVolumeClaims.Items {
+		add(pvcKind, &objs.PersistentVolumeClaims.Items[i], &objs.PersistentVolumeClaims.Items[i])
+	}
+	for i := range objs.ConfigMaps.Items {
+		add(configMapKind, &objs.ConfigMaps.Items[i], &objs.ConfigMaps.Items[i])
+	}
+	if objs.Secrets != nil {
+		for i := range objs.Secrets.Items {
+			add(secretKind, &objs.Secrets.Items[i], &objs.Secrets.Items[i])
+		}
+	}
+	for i := range objs.Deployments.Items {
+		add(deploymentKind, &objs.Deployments.Items[i], &objs.Deployments.Items[i])
+	}
+	for i := range objs.Services.Items {
+		add(serviceKind, &objs.Services.Items[i], &objs.Services.Items[i])
+	}
+	for i := range objs.Ingresses.Items {
+		add(ingressKind, &objs.Ingresses.Items[i], &objs.Ingresses.Items[i])
+	}
+	return list
+}
@@ -0,0 +1,129 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	kube_types "github.com/containerum/kube-client/pkg/model"
+	api_apps "k8s.io/api/apps/v1"
+	api_core "k8s.io/api/core/v1"
+	api_extensions "k8s.io/api/extensions/v1beta1"
+	api_meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func testSolution() SolutionKubeAPI {
+	port := 80
+	return SolutionKubeAPI{
+		Ingresses: []IngressWithOptions{{Ingress: kube_types.Ingress{
+			Name:  "blog",
+			Rules: []kube_types.Rule{{Host: "blog.example.com", Path: []kube_types.Path{{Path: "/", ServiceName: "blog", ServicePort: 80}}}},
+		}}},
+		Services: []kube_types.Service{{
+			Name:   "blog",
+			Deploy: "blog",
+			Ports:  []kube_types.ServicePort{{Name: "http", Port: &port, TargetPort: 8080, Protocol: kube_types.TCP}},
+		}},
+		Deployments: []DeploymentWithRefs{{
+			Deployment: kube_types.Deployment{Name: "blog", Replicas: 1},
+			Containers: []ContainerWithRefs{{Container: kube_types.Container{
+				Name:         "blog",
+				Image:        "ghost",
+				Limits:       kube_types.Resource{CPU: 100, Memory: 128},
+				VolumeMounts: []kube_types.ContainerVolume{{Name: "blog-data", MountPath: "/var/lib/ghost"}},
+			}}},
+		}},
+		Volumes:    []kube_types.Volume{{Name: "blog-data", Capacity: 1, StorageName: "default"}},
+		ConfigMaps: []ConfigMapWithBinaryData{{ConfigMap: kube_types.ConfigMap{Name: "blog-config", Data: kube_types.ConfigMapData{"config.json": "e30="}}}},
+		Secrets:    []kube_types.Secret{{Name: "blog-db", Data: map[string]string{"password": "secret"}}},
+	}
+}
+
+func TestSolutionToKube(t *testing.T) {
+	solution := testSolution()
+	nsLabels := map[string]string{ownerLabel: "user"}
+	objects, errs := solution.ToKube("ns", "blog-1", nsLabels)
+	if errs != nil {
+		t.Fatal(errs)
+	}
+
+	var order []string
+	for _, obj := range objects {
+		order = append(order, obj.Kind)
+	}
+	if strings.Join(order, " ") != "PersistentVolumeClaim ConfigMap Secret Deployment Service Ingress" {
+		t.Errorf("unexpected creation order: %v", order)
+	}
+
+	for _, obj := range objects {
+		labels := obj.Object.(api_meta.Object).GetLabels()
+		if labels[solutionLabel] != "blog-1" || labels[ownerLabel] != "user" {
+			t.Errorf("unexpected %v labels: %v", obj.Kind, labels)
+		}
+	}
+	if secret := objects[2].Object.(*api_core.Secret); secret.Type != api_core.SecretTypeOpaque {
+		t.Errorf("unexpected secret type %v", secret.Type)
+	}
+	if len(nsLabels) != 1 {
+		t.Errorf("namespace labels are modified: %v", nsLabels)
+	}
+}
+
+func TestSolutionToKubeInvalid(t *testing.T) {
+	solution := testSolution()
+	solution.Deployments[0].Containers[0].Limits.CPU = 0
+	solution.Services[0].Deploy = ""
+	_, errs := solution.ToKube("ns", "blog", map[string]string{})
+	var msgs []string
+	for _, err := range errs {
+		msgs = append(msgs, err.Error())
+	}
+	joined := strings.Join(msgs, "; ")
+	if !strings.Contains(joined, "Deployment blog: ") || !strings.Contains(joined, "Service blog: ") {
+		t.Errorf("expected errors of all invalid resources, got %q", joined)
+	}
+
+	if _, errs := solution.ToKube("ns", "invalid solution", map[string]string{}); errs == nil {
+		t.Error("expected invalid solution ID error")
+	}
+	if _, errs := (&SolutionKubeAPI{}).ToKube("ns", "blog", map[string]string{}); errs == nil {
+		t.Error("expected empty solution error")
+	}
+}
+
+func TestSolutionObjects(t *testing.T) {
+	meta := api_meta.ObjectMeta{Name: "blog", Namespace: "ns", Labels: map[string]string{solutionLabel: "blog-1", ownerLabel: "user"}}
+	objects := &NamespaceObjects{
+		Deployments: &api_apps.DeploymentList{Items: []api_apps.Deployment{{ObjectMeta: meta}}},
+		Services:    &api_core.ServiceList{Items: []api_core.Service{{ObjectMeta: meta}}},
+		Ingresses:   &api_extensions.IngressList{Items: []api_extensions.Ingress{{ObjectMeta: meta}}},
+		ConfigMaps:  &api_core.ConfigMapList{Items: []api_core.ConfigMap{{ObjectMeta: meta}}},
+		PersistentVolumeClaims: &api_core.PersistentVolumeClaimList{Items: []api_core.PersistentVolumeClaim{{
+			ObjectMeta: meta,
+			Spec:       api_core.PersistentVolumeClaimSpec{AccessModes: []api_core.PersistentVolumeAccessMode{api_core.ReadWriteMany}},
+		}}},
+		Secrets: &api_core.SecretList{Items: []api_core.Secret{{ObjectMeta: meta}}},
+	}
+
+	var order []string
+	for _, obj := range MakeSolutionObjects(objects) {
+		if obj.Name != "blog" || obj.Namespace != "ns" {
+			t.Errorf("unexpected object %+v", obj)
+		}
+		order = append(order, obj.Kind)
+	}
+	if strings.Join(order, " ") != "PersistentVolumeClaim ConfigMap Secret Deployment Service Ingress" {
+		t.Errorf("unexpected order: %v", order)
+	}
+
+	solution, err := ParseSolutionObjects(objects, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(solution.Deployments) != 1 || len(solution.Services) != 1 || len(solution.Ingresses) != 1 ||
+		len(solution.ConfigMaps) != 1 || len(solution.Volumes) != 1 || len(solution.Secrets) != 1 {
+		t.Errorf("unexpected solution %+v", solution)
+	}
+	if solution.Deployments[0].SolutionID != "blog-1" {
+		t.Errorf("unexpected deployment solution %q", solution.Deployments[0].SolutionID)
+	}
+}
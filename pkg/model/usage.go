@@ -0,0 +1,122 @@
+package model
+
+import (
+	"time"
+
+	"git.containerum.net/ch/kube-api/pkg/model/metrics"
+	kube_types "github.com/containerum/kube-client/pkg/model"
+	api_core "k8s.io/api/core/v1"
+	api_resource "k8s.io/apimachinery/pkg/api/resource"
+)
+
+// ContainerUsage -- current resources usage of container
+//
+// swagger:model
+type ContainerUsage struct {
+	Name  string              `json:"name"`
+	Usage kube_types.Resource `json:"usage"`
+}
+
+// PodUsage -- current resources usage of pod
+//
+// swagger:model
+type PodUsage struct {
+	Name string `json:"name"`
+	// time of measurement in RFC3339 format
+	Timestamp  string              `json:"timestamp,omitempty"`
+	Usage      kube_types.Resource `json:"usage"`
+	Containers []ContainerUsage    `json:"containers"`
+}
+
+// DeploymentUsage -- current resources usage of deployment pods
+//
+// swagger:model
+type DeploymentUsage struct {
+	Name  string              `json:"name"`
+	Usage kube_types.Resource `json:"usage"`
+	Pods  []PodUsage          `json:"pods"`
+}
+
+// NamespaceUsage -- namespace quota with current resources usage of all pods
+//
+// swagger:model
+type NamespaceUsage struct {
+	// swagger: allOf
+	NamespaceWithQuota
+	Usage kube_types.Resource `json:"usage"`
+}
+
+// ParsePodMetrics parses pod metrics to PodUsage struct
+func ParsePodMetrics(podMetrics interface{}) PodUsage {
+	usage, _, _ := parsePodMetrics(podMetrics.(*metrics.PodMetrics))
+	return usage
+}
+
+func parsePodMetrics(obj *metrics.PodMetrics) (usage PodUsage, cpu, mem api_resource.Quantity) {
+	containers := make([]ContainerUsage, 0, len(obj.Containers))
+	for _, c := range obj.Containers {
+		containerCPU := c.Usage[api_core.ResourceCPU]
+		containerMem := c.Usage[api_core.ResourceMemory]
+		cpu.Add(containerCPU)
+		mem.Add(containerMem)
+		containers = append(containers, ContainerUsage{
+			Name:  c.Name,
+			Usage: makeUsage(containerCPU, containerMem),
+		})
+	}
+
+	usage = PodUsage{
+		Name:       obj.GetName(),
+		Usage:      makeUsage(cpu, mem),
+		Containers: containers,
+	}
+	if !obj.Timestamp.IsZero() {
+		usage.Timestamp = obj.Timestamp.UTC().Format(time.RFC3339)
+	}
+	return usage, cpu, mem
+}
+
+// ParsePodMetricsList parses pod metrics list to []PodUsage and returns total usage
+func ParsePodMetricsList(podMetrics interface{}) ([]PodUsage, kube_types.Resource) {
+	list := podMetrics.(*metrics.PodMetricsList)
+
+	var cpu, mem api_resource.Quantity
+	pods := make([]PodUsage, 0, len(list.Items))
+	for i := range list.Items {
+		pod, podCPU, podMem := parsePodMetrics(&list.Items[i])
+		cpu.Add(podCPU)
+		mem.Add(podMem)
+		pods = append(pods, pod)
+	}
+	return pods, makeUsage(cpu, mem)
+}
+
+// ParseDeploymentUsage parses deployment pods metrics list to DeploymentUsage struct
+func ParseDeploymentUsage(deployment string, podMetrics interface{}) DeploymentUsage {
+	pods, total := ParsePodMetricsList(podMetrics)
+	return DeploymentUsage{
+		Name:  deployment,
+		Usage: total,
+		Pods:  pods,
+	}
+}
+
+// ParseNamespaceUsage parses namespace resource quota and metrics of namespace pods to NamespaceUsage struct
+func ParseNamespaceUsage(quota interface{}, podMetrics interface{}) (*NamespaceUsage, error) {
+	ns, err := ParseKubeResourceQuota(quota)
+	if err != nil {
+		return nil, err
+	}
+	_, total := ParsePodMetricsList(podMetrics)
+	return &NamespaceUsage{
+		NamespaceWithQuota: *ns,
+		Usage:              total,
+	}, nil
+}
+
+func makeUsage(cpu, mem api_resource.Quantity) kube_types.Resource {
+	return kube_types.Resource{
+		CPU:    uint(cpu.ScaledValue(api_resource.Milli)),
+		Memory: uint(mem.Value() / 1024 / 1024),
+	}
+}
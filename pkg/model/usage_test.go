@@ -0,0 +1,79 @@
+package model
+
+import (
+	"testing"
+
+	"git.containerum.net/ch/kube-api/pkg/model/metrics"
+	api_core "k8s.io/api/core/v1"
+	api_resource "k8s.io/apimachinery/pkg/api/resource"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func testPodMetrics(name string, usage ...string) metrics.PodMetrics {
+	podMetrics := metrics.PodMetrics{
+		ObjectMeta: meta_v1.ObjectMeta{Name: name},
+	}
+	for i := 0; i+1 < len(usage); i += 2 {
+		podMetrics.Containers = append(podMetrics.Containers, metrics.ContainerMetrics{
+			Name: name + "-container",
+			Usage: api_core.ResourceList{
+				api_core.ResourceCPU:    api_resource.MustParse(usage[i]),
+				api_core.ResourceMemory: api_resource.MustParse(usage[i+1]),
+			},
+		})
+	}
+	return podMetrics
+}
+
+func TestParsePodMetrics(t *testing.T) {
+	podMetrics := testPodMetrics("web", "250m", "64Mi", "5m", "16Mi")
+	usage := ParsePodMetrics(&podMetrics)
+	if usage.Usage.CPU != 255 || usage.Usage.Memory != 80 {
+		t.Errorf("unexpected pod usage: %+v", usage.Usage)
+	}
+	if len(usage.Containers) != 2 || usage.Containers[1].Usage.CPU != 5 {
+		t.Errorf("unexpected containers usage: %+v", usage.Containers)
+	}
+}
+
+func TestParseDeploymentUsage(t *testing.T) {
+	// memory of single pod is less than 1Mi, total should not be lost by rounding
+	list := metrics.PodMetricsList{
+		Items: []metrics.PodMetrics{
+			testPodMetrics("web-1", "1", "768Ki"),
+			testPodMetrics("web-2", "500m", "768Ki"),
+		},
+	}
+	usage := ParseDeploymentUsage("web", &list)
+	if usage.Usage.CPU != 1500 || usage.Usage.Memory != 1 {
+		t.Errorf("unexpected deployment usage: %+v", usage.Usage)
+	}
+	if len(usage.Pods) != 2 {
+		t.Errorf("expected 2 pods, got %v", len(usage.Pods))
+	}
+}
+
+func TestParseNamespaceUsage(t *testing.T) {
+	quota := api_core.ResourceQuota{
+		ObjectMeta: meta_v1.ObjectMeta{Name: "quota", Namespace: "test"},
+		Spec: api_core.ResourceQuotaSpec{
+			Hard: api_core.ResourceList{
+				api_core.ResourceLimitsCPU:    api_resource.MustParse("2"),
+				api_core.ResourceLimitsMemory: api_resource.MustParse("1Gi"),
+			},
+		},
+	}
+	list := metrics.PodMetricsList{
+		Items: []metrics.PodMetrics{testPodMetrics("web-1", "100m", "100Mi")},
+	}
+	usage, err := ParseNamespaceUsage(&quota, &list)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usage.ID != "test" || usage.Resources.Hard.CPU != 2000 || usage.Resources.Hard.Memory != 1024 {
+		t.Errorf("unexpected namespace quota: %+v", usage.NamespaceWithQuota)
+	}
+	if usage.Usage.CPU != 100 || usage.Usage.Memory != 100 {
+		t.Errorf("unexpected namespace usage: %+v", usage.Usage)
+	}
+}
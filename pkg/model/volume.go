@@ -67,8 +67,9 @@ func ParseKubePersistentVolumeClaim(pvci interface{}, parseforuser bool) (*kube_
 	return &pvc, nil
 }
 
-// ToKube creates kubernetes v1.Service from Service struct and namespace labels
-func (pvc *VolumeKubeAPI) ToKube(nsName string, labels map[string]string) (*api_core.PersistentVolumeClaim, []error) {
+// ToKube creates kubernetes v1.PersistentVolumeClaim from Volume struct and namespace labels.
+// If solutionID is not empty, volume is labeled as part of solution.
+func (pvc *VolumeKubeAPI) ToKube(nsName string, solutionID string, labels map[string]string) (*api_core.PersistentVolumeClaim, []error) {
 	//TODO Maybe we should use different access modes
 	pvc.AccessMode = kube_types.ReadWriteMany
 	err := pvc.Validate()
@@ -79,6 +80,9 @@ func (pvc *VolumeKubeAPI) ToKube(nsName string, labels map[string]string) (*api_
 	if labels == nil {
 		return nil, []error{kubeerrors.ErrInternalError().AddDetails("invalid project labels")}
 	}
+	if solutionID != "" {
+		labels[solutionLabel] = solutionID
+	}
 
 	memsize := api_resource.NewQuantity(int64(pvc.Capacity)*1024*1024*1024, api_resource.BinarySI)
 
@@ -0,0 +1,341 @@
+package reconciler
+
+import (
+	"expvar"
+	"fmt"
+	"time"
+
+	"git.containerum.net/ch/kube-api/pkg/kubernetes"
+	"git.containerum.net/ch/kube-api/pkg/model"
+	log "github.com/sirupsen/logrus"
+	api_core "k8s.io/api/core/v1"
+	api_errors "k8s.io/apimachinery/pkg/api/errors"
+	api_meta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+const (
+	ownerLabel = "owner"
+
+	quotaKind      = "ResourceQuota"
+	limitRangeKind = "LimitRange"
+
+	problemMissing = "missing"
+	problemLabels  = "labels"
+	problemSpec    = "spec"
+	problemInvalid = "invalid"
+
+	actionCorrected = "corrected"
+	actionReported  = "reported"
+	actionFailed    = "failed"
+
+	queueSize      = 100
+	watchRetryTime = 5 * time.Second
+)
+
+// metrics are exported with expvar, counters are keyed by "Kind:problem"
+var (
+	driftsMetric      = expvar.NewMap("reconciler_drifts")
+	correctionsMetric = expvar.NewMap("reconciler_corrections")
+	errorsMetric      = expvar.NewInt("reconciler_errors")
+	runsMetric        = expvar.NewInt("reconciler_namespaces_checked")
+)
+
+// Drift -- difference between namespace object and its expected state
+type Drift struct {
+	Namespace string
+	Kind      string
+	Problem   string
+}
+
+// Reconciler makes sure that namespaces created by kube-api have quota and limit range matching expected spec.
+// Namespaces are checked on namespace changes, on quota and limit range deletion and periodically.
+type Reconciler struct {
+	kube       *kubernetes.Kube
+	reportOnly bool
+	resync     time.Duration
+}
+
+// NewReconciler creates reconciler. If reportOnly is true, drifts are logged and counted but not corrected.
+func NewReconciler(kube *kubernetes.Kube, reportOnly bool, resync time.Duration) *Reconciler {
+	return &Reconciler{
+		kube:       kube,
+		reportOnly: reportOnly,
+		resync:     resync,
+	}
+}
+
+// Run checks namespaces until stop channel is closed
+func (r *Reconciler) Run(stop <-chan struct{}) {
+	log.WithFields(log.Fields{
+		"ReportOnly": r.reportOnly,
+		"Resync":     r.resync,
+	}).Info("Starting namespace reconciler")
+
+	queue := make(chan string, queueSize)
+	go r.watch(stop, queue, "Namespace", r.kube.WatchNamespaces, watch.Added, watch.Modified)
+	go r.watch(stop, queue, quotaKind, r.kube.WatchNamespaceQuotas, watch.Deleted)
+	go r.watch(stop, queue, limitRangeKind, r.kube.WatchLimitRanges, watch.Deleted)
+
+	ticker := time.NewTicker(r.resync)
+	defer ticker.Stop()
+	r.resyncAll()
+	for {
+		select {
+		case <-stop:
+			log.Info("Stopping namespace reconciler")
+			return
+		case <-ticker.C:
+			r.resyncAll()
+		case ns := <-queue:
+			r.ReconcileNamespace(ns)
+		}
+	}
+}
+
+func (r *Reconciler) resyncAll() {
+	namespaces, err := r.kube.GetNamespaceList("")
+	if err != nil {
+		errorsMetric.Add(1)
+		return
+	}
+	for i := range namespaces.Items {
+		r.reconcile(&namespaces.Items[i])
+	}
+}
+
+// watch sends namespace of changed object to queue, watch is restarted if it's closed by apiserver
+func (r *Reconciler) watch(stop <-chan struct{}, queue chan<- string, kind string,
+	start func(resourceVersion string) (watch.Interface, error), events ...watch.EventType) {
+	for {
+		watcher, err := start("")
+		if err != nil {
+			log.WithField("Kind", kind).WithError(err).Warn("Unable to watch objects")
+			errorsMetric.Add(1)
+			select {
+			case <-stop:
+				return
+			case <-time.After(watchRetryTime):
+				continue
+			}
+		}
+		r.handleEvents(stop, watcher, queue, kind, events)
+		watcher.Stop()
+		select {
+		case <-stop:
+			return
+		default:
+		}
+	}
+}
+
+func (r *Reconciler) handleEvents(stop <-chan struct{}, watcher watch.Interface, queue chan<- string, kind string, events []watch.EventType) {
+	for {
+		select {
+		case <-stop:
+			return
+		case event, ok := <-watcher.ResultChan():
+			if !ok {
+				return
+			}
+			if !containsEvent(events, event.Type) {
+				continue
+			}
+			obj, err := api_meta.Accessor(event.Object)
+			if err != nil {
+				continue
+			}
+			ns := obj.GetNamespace()
+			if kind == "Namespace" {
+				ns = obj.GetName()
+			}
+			select {
+			case queue <- ns:
+			default:
+				// namespace will be checked on resync
+				log.WithField("Namespace", ns).Debug("Reconciler queue is full")
+			}
+		}
+	}
+}
+
+func containsEvent(events []watch.EventType, event watch.EventType) bool {
+	for _, e := range events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// ReconcileNamespace checks namespace by name. Namespaces without owner label are ignored.
+func (r *Reconciler) ReconcileNamespace(nsName string) []Drift {
+	ns, err := r.kube.GetNamespace(nsName)
+	if err != nil {
+		if !api_errors.IsNotFound(err) {
+			errorsMetric.Add(1)
+		}
+		return nil
+	}
+	return r.reconcile(ns)
+}
+
+func (r *Reconciler) reconcile(ns *api_core.Namespace) []Drift {
+	if _, hasOwner := ns.Labels[ownerLabel]; !hasOwner || ns.Status.Phase == api_core.NamespaceTerminating {
+		return nil
+	}
+	runsMetric.Add(1)
+
+	var drifts []Drift
+	drifts = append(drifts, r.reconcileQuota(ns)...)
+	drifts = append(drifts, r.reconcileLimitRange(ns)...)
+	return drifts
+}
+
+func (r *Reconciler) reconcileQuota(ns *api_core.Namespace) []Drift {
+	current, err := r.kube.GetNamespaceQuota(ns.Name)
+	switch {
+	case api_errors.IsNotFound(err):
+		current = nil
+	case err != nil:
+		errorsMetric.Add(1)
+		return nil
+	}
+
+	expected, errs := model.ExpectedResourceQuota(ns, current)
+	if errs != nil {
+		// limits are unknown or out of policy bounds, they are not changed automatically
+		drift := Drift{Namespace: ns.Name, Kind: quotaKind, Problem: problemInvalid}
+		if current == nil {
+			drift.Problem = problemMissing
+		}
+		r.report(drift, actionReported, log.Fields{"Errors": fmt.Sprint(errs)})
+		return []Drift{drift}
+	}
+
+	if current == nil {
+		drift := Drift{Namespace: ns.Name, Kind: quotaKind, Problem: problemMissing}
+		r.correct([]Drift{drift}, func() error {
+			_, err := r.kube.CreateNamespaceQuota(ns.Name, expected)
+			return err
+		})
+		return []Drift{drift}
+	}
+
+	problems := quotaDrift(current, expected)
+	if len(problems) == 0 {
+		return nil
+	}
+	drifts := make([]Drift, 0, len(problems))
+	for _, problem := range problems {
+		drifts = append(drifts, Drift{Namespace: ns.Name, Kind: quotaKind, Problem: problem})
+	}
+	expected.ResourceVersion = current.ResourceVersion
+	r.correct(drifts, func() error {
+		_, err := r.kube.UpdateNamespaceQuota(ns.Name, expected)
+		return err
+	})
+	return drifts
+}
+
+func (r *Reconciler) reconcileLimitRange(ns *api_core.Namespace) []Drift {
+	expected := kubernetes.MakeLimitRange(ns.Name)
+	current, err := r.kube.GetLimitRange(ns.Name)
+	switch {
+	case api_errors.IsNotFound(err):
+		drift := Drift{Namespace: ns.Name, Kind: limitRangeKind, Problem: problemMissing}
+		r.correct([]Drift{drift}, func() error {
+			return r.kube.CreateLimitRange(ns.Name)
+		})
+		return []Drift{drift}
+	case err != nil:
+		errorsMetric.Add(1)
+		return nil
+	}
+
+	if limitRangeMatches(current, expected) {
+		return nil
+	}
+	drift := Drift{Namespace: ns.Name, Kind: limitRangeKind, Problem: problemSpec}
+	expected.ResourceVersion = current.ResourceVersion
+	r.correct([]Drift{drift}, func() error {
+		return r.kube.UpdateLimitRange(expected)
+	})
+	return []Drift{drift}
+}
+
+// correct applies fix for drifts fixed by the same call, in report-only mode drifts are only reported
+func (r *Reconciler) correct(drifts []Drift, fix func() error) {
+	if r.reportOnly {
+		for _, d := range drifts {
+			r.report(d, actionReported, nil)
+		}
+		return
+	}
+	if err := fix(); err != nil {
+		errorsMetric.Add(1)
+		for _, d := range drifts {
+			r.report(d, actionFailed, log.Fields{"Error": err.Error()})
+		}
+		return
+	}
+	for _, d := range drifts {
+		correctionsMetric.Add(d.Kind+":"+d.Problem, 1)
+		r.report(d, actionCorrected, nil)
+	}
+}
+
+func (r *Reconciler) report(drift Drift, action string, fields log.Fields) {
+	driftsMetric.Add(drift.Kind+":"+drift.Problem, 1)
+	log.WithFields(log.Fields{
+		"Namespace": drift.Namespace,
+		"Kind":      drift.Kind,
+		"Problem":   drift.Problem,
+		"Action":    action,
+	}).WithFields(fields).Warn("Namespace drift")
+}
+
+// quotaDrift returns differences between current and expected quota.
+// Only owner label is compared, because apiserver may add own labels to namespace.
+func quotaDrift(current, expected *api_core.ResourceQuota) []string {
+	var problems []string
+	if current.Labels[ownerLabel] != expected.Labels[ownerLabel] {
+		problems = append(problems, problemLabels)
+	}
+	if !resourceListsEqual(current.Spec.Hard, expected.Spec.Hard) {
+		problems = append(problems, problemSpec)
+	}
+	return problems
+}
+
+// limitRangeMatches checks that limit range has expected limits
+func limitRangeMatches(current, expected *api_core.LimitRange) bool {
+	if len(current.Spec.Limits) != len(expected.Spec.Limits) {
+		return false
+	}
+	for i, limit := range current.Spec.Limits {
+		expectedLimit := expected.Spec.Limits[i]
+		if limit.Type != expectedLimit.Type ||
+			!resourceListsEqual(limit.Default, expectedLimit.Default) ||
+			!resourceListsEqual(limit.DefaultRequest, expectedLimit.DefaultRequest) ||
+			!resourceListsEqual(limit.Max, expectedLimit.Max) ||
+			!resourceListsEqual(limit.Min, expectedLimit.Min) ||
+			!resourceListsEqual(limit.MaxLimitRequestRatio, expectedLimit.MaxLimitRequestRatio) {
+			return false
+		}
+	}
+	return true
+}
+
+func resourceListsEqual(a, b api_core.ResourceList) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for name, quantity := range a {
+		other, ok := b[name]
+		if !ok || quantity.Cmp(other) != 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,94 @@
+package reconciler
+
+import (
+	"strings"
+	"testing"
+
+	"git.containerum.net/ch/kube-api/pkg/kubernetes"
+	"git.containerum.net/ch/kube-api/pkg/model"
+	api_core "k8s.io/api/core/v1"
+	api_resource "k8s.io/apimachinery/pkg/api/resource"
+	api_meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestQuotaDrift(t *testing.T) {
+	ns := &api_core.Namespace{
+		ObjectMeta: api_meta.ObjectMeta{Name: "ns", Labels: map[string]string{ownerLabel: "user"}},
+	}
+	if _, errs := model.ExpectedResourceQuota(ns, nil); errs == nil {
+		t.Error("expected error for quota without stored limits")
+	}
+
+	res := model.QuotaResource{}
+	res.CPU = 500
+	res.Memory = 512
+	live, errs := model.MakeResourceQuota("ns", map[string]string{ownerLabel: "user"}, res)
+	if errs != nil {
+		t.Fatal(errs)
+	}
+	live.Spec.Hard["count/pods"] = api_resource.MustParse("10")
+	if expected, errs := model.ExpectedResourceQuota(ns, live); errs != nil || quotaDrift(live, expected) != nil {
+		t.Errorf("quota of namespace without annotation is changed: %v", errs)
+	}
+
+	res = model.QuotaResource{}
+	res.CPU = 1000
+	res.Memory = 1024
+	created, errs := model.MakeResourceQuota("ns", map[string]string{ownerLabel: "user"}, res)
+	if errs != nil {
+		t.Fatal(errs)
+	}
+	if err := model.SetQuotaAnnotation(ns, created); err != nil {
+		t.Fatal(err)
+	}
+	if restored, errs := model.ExpectedResourceQuota(ns, nil); errs != nil || quotaDrift(restored, created) != nil {
+		t.Errorf("quota is not restored from annotation: %v", errs)
+	}
+
+	current := created.DeepCopy()
+	expected, errs := model.ExpectedResourceQuota(ns, current)
+	if errs != nil {
+		t.Fatal(errs)
+	}
+	if problems := quotaDrift(current, expected); problems != nil {
+		t.Errorf("unexpected drift of created quota: %v", problems)
+	}
+
+	// quantities in other format are the same
+	current.Spec.Hard[api_core.ResourceLimitsCPU] = api_resource.MustParse("1")
+	if problems := quotaDrift(current, expected); problems != nil {
+		t.Errorf("unexpected drift of reformatted quota: %v", problems)
+	}
+
+	current.Labels = map[string]string{ownerLabel: "other"}
+	delete(current.Spec.Hard, api_core.ResourceRequestsMemory)
+	expected, errs = model.ExpectedResourceQuota(ns, current)
+	if errs != nil {
+		t.Fatal(errs)
+	}
+	if problems := quotaDrift(current, expected); strings.Join(problems, ",") != "labels,spec" {
+		t.Errorf("unexpected drift: %v", problems)
+	}
+	if expected.Labels[ownerLabel] != "user" || !resourceListsEqual(expected.Spec.Hard, created.Spec.Hard) {
+		t.Errorf("quota is not restored: %+v", expected)
+	}
+}
+
+func TestLimitRangeMatches(t *testing.T) {
+	expected := kubernetes.MakeLimitRange("ns")
+	current := expected.DeepCopy()
+	if !limitRangeMatches(current, expected) {
+		t.Error("equal limit ranges don't match")
+	}
+
+	current.Spec.Limits[0].Default[api_core.ResourceCPU] = api_resource.MustParse("1")
+	if limitRangeMatches(current, expected) {
+		t.Error("changed default limits match")
+	}
+
+	current = expected.DeepCopy()
+	current.Spec.Limits = append(current.Spec.Limits, api_core.LimitRangeItem{Type: api_core.LimitTypePod})
+	if limitRangeMatches(current, expected) {
+		t.Error("limit range with additional item matches")
+	}
+}
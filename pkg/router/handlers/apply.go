@@ -0,0 +1,282 @@
+package handlers
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"git.containerum.net/ch/kube-api/pkg/kubeerrors"
+	"git.containerum.net/ch/kube-api/pkg/kubernetes"
+	"git.containerum.net/ch/kube-api/pkg/model"
+	m "git.containerum.net/ch/kube-api/pkg/router/midlleware"
+	"github.com/containerum/cherry"
+	"github.com/containerum/cherry/adaptors/gonic"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+	api_apps "k8s.io/api/apps/v1"
+	api_core "k8s.io/api/core/v1"
+	api_extensions "k8s.io/api/extensions/v1beta1"
+	api_errors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const manifestsSizeMax = 1 << 20
+
+// swagger:operation POST /namespaces/{namespace}/apply Namespace ApplyManifests
+// Create or update objects from kubernetes manifests.
+// Supported kinds are apps/v1 Deployment, v1 Service, Ingress of any version served by cluster, v1 ConfigMap, v1 Secret and v1 PersistentVolumeClaim.
+// Objects are converted to kube-api models, so the same validation and labels as in create endpoints are applied.
+// Ingress annotations are accepted only if they are translated to routing options.
+// Nothing is applied if any object is invalid or deployment uses volumes, secrets or config maps which neither exist nor are in manifests.
+//
+// ---
+// x-method-visibility: private
+// consumes:
+//  - application/x-yaml
+//  - application/json
+// parameters:
+//  - $ref: '#/parameters/UserIDHeader'
+//  - $ref: '#/parameters/UserRoleHeader'
+//  - $ref: '#/parameters/UserNamespaceHeader'
+//  - $ref: '#/parameters/DryRunQuery'
+//  - name: namespace
+//    in: path
+//    type: string
+//    required: true
+//  - name: body
+//    in: body
+//    description: multi-document YAML or JSON
+//    schema:
+//      type: string
+// responses:
+//  '200':
+//    description: all objects applied
+//    schema:
+//      $ref: '#/definitions/ApplyResultList'
+//  '207':
+//    description: some objects are not applied
+//    schema:
+//      $ref: '#/definitions/ApplyResultList'
+//  default:
+//    $ref: '#/responses/error'
+func ApplyManifests(ctx *gin.Context) {
+	namespace := ctx.Param(namespaceParam)
+	log.WithFields(log.Fields{
+		"Namespace": namespace,
+	}).Debug("Apply manifests Call")
+
+	kube := ctx.MustGet(m.KubeClient).(*kubernetes.Kube)
+
+	data, err := ioutil.ReadAll(&limitedReader{rd: ctx.Request.Body, n: manifestsSizeMax})
+	if err != nil {
+		if err == errFilesTooLarge {
+			gonic.Gonic(kubeerrors.ErrRequestValidationFailed().AddDetailF("maximum manifests size is %v bytes", manifestsSizeMax), ctx)
+			return
+		}
+		ctx.Error(err)
+		gonic.Gonic(kubeerrors.ErrRequestValidationFailed(), ctx)
+		return
+	}
+
+	objects, err := model.DecodeManifests(data)
+	if err != nil {
+		gonic.Gonic(kubeerrors.ErrRequestValidationFailed().AddDetailsErr(err), ctx)
+		return
+	}
+
+	quota, err := kube.GetNamespaceQuota(namespace)
+	if err != nil {
+		gonic.Gonic(model.ParseKubernetesResourceError(err, kubeerrors.ErrUnableCreateResource()), ctx)
+		return
+	}
+
+	kubeObjects := make([]runtime.Object, 0, len(objects))
+	var details []string
+	for _, obj := range objects {
+		kubeObj, errs := model.ManifestToKube(obj, namespace, quota.Labels)
+		for _, err := range errs {
+			details = append(details, fmt.Sprintf("%v %v: %v", obj.Kind, obj.Name, err))
+		}
+		kubeObjects = append(kubeObjects, kubeObj)
+	}
+	if len(details) > 0 {
+		gonic.Gonic(kubeerrors.ErrRequestValidationFailed().AddDetails(details...), ctx)
+		return
+	}
+
+	// objects of manifests are not checked as deployment dependencies,
+	// because they are applied together with deployments and aren't persisted in dry run mode
+	manifestObjects := make(map[string]bool)
+	for _, obj := range objects {
+		manifestObjects[objectKey(obj.Kind, obj.Name)] = true
+	}
+	for i, obj := range objects {
+		deploy, isDeploy := kubeObjects[i].(*api_apps.Deployment)
+		if !isDeploy {
+			continue
+		}
+		errs, cherryErr := checkAppliedDeployment(kube, deploy, manifestObjects)
+		if cherryErr != nil {
+			gonic.Gonic(cherryErr, ctx)
+			return
+		}
+		for _, err := range errs {
+			details = append(details, fmt.Sprintf("%v %v: %v", obj.Kind, obj.Name, err))
+		}
+	}
+	if len(details) > 0 {
+		gonic.Gonic(kubeerrors.ErrRequestValidationFailed().AddDetails(details...), ctx)
+		return
+	}
+
+	status := http.StatusOK
+	results := make([]model.ApplyResult, 0, len(objects))
+	for i, obj := range objects {
+		result := model.ApplyResult{
+			Kind: obj.Kind,
+			Name: obj.Name,
+		}
+		result.Status, result.Error = applyObject(kube, namespace, kubeObjects[i])
+		if result.Error != nil {
+			result.Status = model.ApplyStatusFailed
+			status = http.StatusMultiStatus
+		}
+		results = append(results, result)
+	}
+
+	ctx.JSON(status, model.ApplyResultList{Objects: results})
+}
+
+// applyObject creates object or updates existing one keeping its immutable fields like update endpoints do
+func applyObject(kube *kubernetes.Kube, namespace string, obj runtime.Object) (string, *cherry.Err) {
+	created := func(err error) (string, *cherry.Err) {
+		if err != nil {
+			return "", parseResourceError(err, kubeerrors.ErrUnableCreateResource())
+		}
+		return model.ApplyStatusCreated, nil
+	}
+	updated := func(err error) (string, *cherry.Err) {
+		if err != nil {
+			return "", parseResourceError(err, kubeerrors.ErrUnableUpdateResource())
+		}
+		return model.ApplyStatusUpdated, nil
+	}
+
+	switch newObj := obj.(type) {
+	case *api_apps.Deployment:
+		oldDeploy, err := kube.GetDeployment(namespace, newObj.Name)
+		switch {
+		case api_errors.IsNotFound(err):
+			_, err = kube.CreateDeployment(newObj)
+			return created(err)
+		case err != nil:
+			return updated(err)
+		}
+		//Ensure that immutable selectors wouldn't change
+		newObj.Spec.Selector = oldDeploy.Spec.Selector
+		newObj.Spec.Template.Labels = oldDeploy.Spec.Template.Labels
+		_, err = kube.UpdateDeployment(newObj)
+		return updated(err)
+	case *api_core.Service:
+		oldSvc, err := kube.GetService(namespace, newObj.Name)
+		switch {
+		case api_errors.IsNotFound(err):
+			_, err = kube.CreateService(newObj)
+			return created(err)
+		case err != nil:
+			return updated(err)
+		}
+		newObj.ResourceVersion = oldSvc.ResourceVersion
+		newObj.Spec.ClusterIP = oldSvc.Spec.ClusterIP
+		newObj.Labels = oldSvc.Labels
+		newObj.Spec.Selector = oldSvc.Spec.Selector
+		_, err = kube.UpdateService(newObj)
+		return updated(err)
+	case *api_extensions.Ingress:
+		_, err := kube.GetIngress(namespace, newObj.Name)
+		switch {
+		case api_errors.IsNotFound(err):
+			_, err = kube.CreateIngress(newObj)
+			return created(err)
+		case err != nil:
+			return updated(err)
+		}
+		_, err = kube.UpdateIngress(newObj)
+		return updated(err)
+	case *api_core.ConfigMap:
+		_, err := kube.GetConfigMap(namespace, newObj.Name)
+		switch {
+		case api_errors.IsNotFound(err):
+			_, err = kube.CreateConfigMap(newObj)
+			return created(err)
+		case err != nil:
+			return updated(err)
+		}
+		_, err = kube.UpdateConfigMap(newObj)
+		return updated(err)
+	case *api_core.Secret:
+		_, err := kube.GetSecret(namespace, newObj.Name)
+		switch {
+		case api_errors.IsNotFound(err):
+			_, err = kube.CreateSecret(newObj)
+			return created(err)
+		case err != nil:
+			return updated(err)
+		}
+		_, err = kube.UpdateSecret(newObj)
+		return updated(err)
+	case *api_core.PersistentVolumeClaim:
+		oldPvc, err := kube.GetPersistentVolumeClaim(namespace, newObj.Name)
+		switch {
+		case api_errors.IsNotFound(err):
+			_, err = kube.CreatePersistentVolumeClaim(newObj)
+			return created(err)
+		case err != nil:
+			return updated(err)
+		}
+		// only capacity of existing volume can be changed
+		capacity := newObj.Spec.Resources.Requests[api_core.ResourceStorage]
+		if capacity.Cmp(oldPvc.Spec.Resources.Requests[api_core.ResourceStorage]) == 0 {
+			return model.ApplyStatusUnchanged, nil
+		}
+		volume := model.VolumeKubeAPI{Capacity: uint(capacity.Value() / 1024 / 1024 / 1024)}
+		resizedPvc, err := volume.Resize(oldPvc)
+		if err != nil {
+			return updated(err)
+		}
+		_, err = kube.UpdatePersistentVolumeClaim(resizedPvc)
+		return updated(err)
+	default:
+		return "", kubeerrors.ErrRequestValidationFailed().AddDetailF("unsupported object %T", obj)
+	}
+}
+
+// checkAppliedDeployment returns problems with objects used by deployment.
+// Like create and update endpoints do, volumes are checked only for new deployment and references are checked for any.
+// Objects from skip are not checked. Skip is keyed by "Kind/name".
+func checkAppliedDeployment(kube *kubernetes.Kube, deploy *api_apps.Deployment, skip map[string]bool) ([]error, *cherry.Err) {
+	_, err := kube.GetDeployment(deploy.Namespace, deploy.Name)
+	switch {
+	case api_errors.IsNotFound(err):
+		volumeErrs, cherryErr := checkDeploymentVolumes(kube, deploy, skip)
+		if cherryErr != nil {
+			return nil, cherryErr
+		}
+		referenceErrs, cherryErr := checkDeploymentReferences(kube, deploy, skip)
+		if cherryErr != nil {
+			return nil, cherryErr
+		}
+		return append(volumeErrs, referenceErrs...), nil
+	case err != nil:
+		return nil, model.ParseKubernetesResourceError(err, kubeerrors.ErrUnableGetResource())
+	}
+	return checkDeploymentReferences(kube, deploy, skip)
+}
+
+// parseResourceError passes cherry errors as is and converts kubernetes errors
+func parseResourceError(err error, defaultErr *cherry.Err) *cherry.Err {
+	if cherryErr, isCherryErr := err.(*cherry.Err); isCherryErr {
+		return cherryErr
+	}
+	return model.ParseKubernetesResourceError(err, defaultErr)
+}
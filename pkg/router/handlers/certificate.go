@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"expvar"
+	"net/http"
+	"sync"
+	"time"
+
+	"git.containerum.net/ch/kube-api/pkg/kubeerrors"
+	"git.containerum.net/ch/kube-api/pkg/kubernetes"
+	"git.containerum.net/ch/kube-api/pkg/model"
+	m "git.containerum.net/ch/kube-api/pkg/router/midlleware"
+	"github.com/containerum/cherry/adaptors/gonic"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
+)
+
+// certificateExpiryMetric is expiry time of certificates as unix timestamp keyed by "namespace/secret", nil if metrics are disabled
+var certificateExpiryMetric *expvar.Map
+
+// EnableCertificateMetrics exports expiry of certificates found by certificate report with expvar
+func EnableCertificateMetrics() {
+	certificateExpiryMetric = expvar.NewMap("certificate_expiry_timestamp_seconds")
+}
+
+// swagger:operation GET /certificates Certificate GetCertificateExpiryList
+// Get certificates of TLS secrets from all namespaces sorted by time to expiry.
+// Users get certificates from their namespaces.
+// Certificates found by the last admin request are exported as metrics if they are enabled.
+//
+// ---
+// x-method-visibility: private
+// parameters:
+//  - $ref: '#/parameters/UserIDHeader'
+//  - $ref: '#/parameters/UserRoleHeader'
+//  - $ref: '#/parameters/UserNamespaceHeader'
+// responses:
+//  '200':
+//    description: certificates list
+//    schema:
+//      $ref: '#/definitions/CertificateExpiryList'
+//  default:
+//    $ref: '#/responses/error'
+func GetCertificateExpiryList(ctx *gin.Context) {
+	log.Debug("Get certificate expiry list Call")
+
+	kube := ctx.MustGet(m.KubeClient).(*kubernetes.Kube)
+	now := time.Now()
+
+	role := ctx.MustGet(m.UserRole).(string)
+	if role == m.RoleUser {
+		certificates := make([]model.CertificateExpiry, 0)
+		nsList := ctx.MustGet(m.UserNamespaces).(*model.UserHeaderDataMap)
+		var g errgroup.Group
+		var mutex = &sync.Mutex{}
+		for _, n := range *nsList {
+			currentNs := n
+			g.Go(func() error {
+				nsCertificates, err := getCertificateExpiries(kube, currentNs.ID, now)
+				if err != nil {
+					return err
+				}
+				mutex.Lock()
+				certificates = append(certificates, nsCertificates...)
+				mutex.Unlock()
+				return nil
+			})
+		}
+		if err := g.Wait(); err != nil {
+			ctx.Error(err)
+			gonic.Gonic(kubeerrors.ErrUnableGetResourcesList(), ctx)
+			return
+		}
+		ctx.JSON(http.StatusOK, model.NewCertificateExpiryList(certificates))
+		return
+	}
+
+	// empty namespace selects all namespaces
+	certificates, err := getCertificateExpiries(kube, "", now)
+	if err != nil {
+		gonic.Gonic(model.ParseKubernetesResourceError(err, kubeerrors.ErrUnableGetResourcesList()), ctx)
+		return
+	}
+
+	// metrics are updated only from full scans, so user requests don't remove certificates of other namespaces
+	if certificateExpiryMetric != nil {
+		// removed and renewed certificates must not stay in metrics
+		certificateExpiryMetric.Init()
+		for _, cert := range certificates {
+			notAfter, err := time.Parse(time.RFC3339, cert.NotAfter)
+			if err != nil {
+				ctx.Error(err)
+				continue
+			}
+			value := new(expvar.Int)
+			value.Set(notAfter.Unix())
+			certificateExpiryMetric.Set(cert.Namespace+"/"+cert.Secret, value)
+		}
+	}
+
+	ctx.JSON(http.StatusOK, model.NewCertificateExpiryList(certificates))
+}
+
+func getCertificateExpiries(kube *kubernetes.Kube, namespace string, now time.Time) ([]model.CertificateExpiry, error) {
+	secrets, err := kube.GetTLSSecretList(namespace)
+	if err != nil {
+		return nil, err
+	}
+	ingresses, err := kube.GetIngressList(namespace)
+	if err != nil {
+		return nil, err
+	}
+	return model.ParseCertificateExpiries(secrets, ingresses, now), nil
+}
@@ -1,7 +1,17 @@
 package handlers
 
 import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
 	"net/http"
+	"path"
+	"sort"
+	"time"
 
 	"sync"
 
@@ -9,18 +19,28 @@ import (
 	"git.containerum.net/ch/kube-api/pkg/kubernetes"
 	"git.containerum.net/ch/kube-api/pkg/model"
 	m "git.containerum.net/ch/kube-api/pkg/router/midlleware"
+	"github.com/containerum/cherry"
 	"github.com/containerum/cherry/adaptors/gonic"
-	kube_types "github.com/containerum/kube-client/pkg/model"
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
 	log "github.com/sirupsen/logrus"
 	"golang.org/x/sync/errgroup"
+	api_core "k8s.io/api/core/v1"
+	api_validation "k8s.io/apimachinery/pkg/util/validation"
 )
 
 const (
 	configMapParam = "configmap"
+	keyQuery       = "key"
+	nameField      = "name"
+
+	configMapNameMax = 253
+	// multipart form overhead is allowed in addition to config map size
+	configMapUploadMax = model.ConfigMapSizeMax + 64<<10
 )
 
+var errNoFiles = errors.New("no files in multipart form")
+
 // swagger:operation GET /namespaces/{namespace}/configmaps ConfigMap GetConfigMapList
 // Get config maps list.
 //
@@ -38,7 +58,7 @@ const (
 //  '200':
 //    description: config maps list
 //    schema:
-//      $ref: '#/definitions/ConfigMapsList'
+//      $ref: '#/definitions/ConfigMapWithBinaryDataList'
 //  default:
 //    $ref: '#/responses/error'
 func GetConfigMapList(ctx *gin.Context) {
@@ -93,7 +113,7 @@ func GetConfigMapList(ctx *gin.Context) {
 //  '200':
 //    description: config map
 //    schema:
-//      $ref: '#/definitions/ConfigMap'
+//      $ref: '#/definitions/ConfigMapWithBinaryData'
 //  default:
 //    $ref: '#/responses/error'
 func GetConfigMap(ctx *gin.Context) {
@@ -138,6 +158,7 @@ func GetConfigMap(ctx *gin.Context) {
 //  - $ref: '#/parameters/UserIDHeader'
 //  - $ref: '#/parameters/UserRoleHeader'
 //  - $ref: '#/parameters/UserNamespaceHeader'
+//  - $ref: '#/parameters/DryRunQuery'
 //  - name: namespace
 //    in: path
 //    type: string
@@ -145,12 +166,12 @@ func GetConfigMap(ctx *gin.Context) {
 //  - name: body
 //    in: body
 //    schema:
-//      $ref: '#/definitions/ConfigMap'
+//      $ref: '#/definitions/ConfigMapWithBinaryData'
 // responses:
 //  '201':
 //    description: config map created
 //    schema:
-//      $ref: '#/definitions/ConfigMap'
+//      $ref: '#/definitions/ConfigMapWithBinaryData'
 //  default:
 //    $ref: '#/responses/error'
 func CreateConfigMap(ctx *gin.Context) {
@@ -174,7 +195,7 @@ func CreateConfigMap(ctx *gin.Context) {
 		return
 	}
 
-	cm, errs := cmReq.ToKube(namespace, ns.Labels)
+	cm, errs := cmReq.ToKube(namespace, "", ns.Labels)
 	if errs != nil {
 		gonic.Gonic(kubeerrors.ErrRequestValidationFailed().AddDetailsErr(errs...), ctx)
 		return
@@ -198,6 +219,7 @@ func CreateConfigMap(ctx *gin.Context) {
 
 // swagger:operation PUT /namespaces/{namespace}/configmaps/{configmap} ConfigMap UpdateConfigMap
 // Update config map.
+// Deployments using config map are restarted if rollout query is true.
 //
 // ---
 // x-method-visibility: public
@@ -205,6 +227,7 @@ func CreateConfigMap(ctx *gin.Context) {
 //  - $ref: '#/parameters/UserIDHeader'
 //  - $ref: '#/parameters/UserRoleHeader'
 //  - $ref: '#/parameters/UserNamespaceHeader'
+//  - $ref: '#/parameters/DryRunQuery'
 //  - name: namespace
 //    in: path
 //    type: string
@@ -213,15 +236,24 @@ func CreateConfigMap(ctx *gin.Context) {
 //    in: path
 //    type: string
 //    required: true
+//  - name: rollout
+//    in: query
+//    type: boolean
+//    description: restart deployments using config map, in dry run mode they are reported as skipped
+//    required: false
 //  - name: body
 //    in: body
 //    schema:
-//      $ref: '#/definitions/ConfigMap'
+//      $ref: '#/definitions/ConfigMapWithBinaryData'
 // responses:
 //  '202':
 //    description: config map updated
 //    schema:
-//      $ref: '#/definitions/ConfigMap'
+//      $ref: '#/definitions/ConfigMapWithRollout'
+//  '207':
+//    description: config map updated, rollout or restart of some deployments failed
+//    schema:
+//      $ref: '#/definitions/ConfigMapWithRollout'
 //  default:
 //    $ref: '#/responses/error'
 func UpdateConfigMap(ctx *gin.Context) {
@@ -234,6 +266,12 @@ func UpdateConfigMap(ctx *gin.Context) {
 
 	kube := ctx.MustGet(m.KubeClient).(*kubernetes.Kube)
 
+	rollout, cherr := parseRolloutQuery(ctx)
+	if cherr != nil {
+		gonic.Gonic(cherr, ctx)
+		return
+	}
+
 	var cmReq model.ConfigMapKubeAPI
 	if err := ctx.ShouldBindWith(&cmReq, binding.JSON); err != nil {
 		ctx.Error(err)
@@ -256,7 +294,7 @@ func UpdateConfigMap(ctx *gin.Context) {
 	cmReq.Name = configMap
 	cmReq.Owner = oldCm.GetObjectMeta().GetLabels()[ownerQuery]
 
-	newCm, errs := cmReq.ToKube(namespace, ns.Labels)
+	newCm, errs := cmReq.ToKube(namespace, model.ParseSolutionID(oldCm), ns.Labels)
 	if errs != nil {
 		gonic.Gonic(kubeerrors.ErrRequestValidationFailed().AddDetailsErr(errs...), ctx)
 		return
@@ -268,13 +306,7 @@ func UpdateConfigMap(ctx *gin.Context) {
 		return
 	}
 
-	role := ctx.MustGet(m.UserRole).(string)
-	ret, err := model.ParseKubeConfigMap(cmAfter, role == m.RoleUser)
-	if err != nil {
-		ctx.Error(err)
-	}
-
-	ctx.JSON(http.StatusAccepted, ret)
+	respondConfigMapUpdated(ctx, kube, cmAfter, rollout)
 }
 
 // swagger:operation DELETE /namespaces/{namespace}/configmaps/{configmap} ConfigMap DeleteConfigMap
@@ -286,6 +318,7 @@ func UpdateConfigMap(ctx *gin.Context) {
 //  - $ref: '#/parameters/UserIDHeader'
 //  - $ref: '#/parameters/UserRoleHeader'
 //  - $ref: '#/parameters/UserNamespaceHeader'
+//  - $ref: '#/parameters/DryRunQuery'
 //  - name: namespace
 //    in: path
 //    type: string
@@ -337,7 +370,7 @@ func DeleteConfigMap(ctx *gin.Context) {
 //  '200':
 //    description: config maps list from all users namespaces
 //    schema:
-//      $ref: '#/definitions/SelectedConfigMapsList'
+//      $ref: '#/definitions/SelectedConfigMapsWithBinaryDataList'
 //  default:
 //    $ref: '#/responses/error'
 func GetSelectedConfigMaps(ctx *gin.Context) {
@@ -345,7 +378,7 @@ func GetSelectedConfigMaps(ctx *gin.Context) {
 
 	kube := ctx.MustGet(m.KubeClient).(*kubernetes.Kube)
 
-	ret := make(kube_types.SelectedConfigMapsList)
+	ret := make(model.SelectedConfigMapsWithBinaryDataList)
 
 	role := ctx.MustGet(m.UserRole).(string)
 	if role == m.RoleUser {
@@ -378,3 +411,354 @@ func GetSelectedConfigMaps(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, ret)
 }
+
+// swagger:operation POST /namespaces/{namespace}/configmaps/files ConfigMap CreateConfigMapFromFiles
+// Create config map from files uploaded as multipart form.
+// Each file becomes a key named after the file, UTF-8 files are stored in data and other files in binary data.
+//
+// ---
+// x-method-visibility: public
+// consumes:
+//  - multipart/form-data
+// parameters:
+//  - $ref: '#/parameters/UserIDHeader'
+//  - $ref: '#/parameters/UserRoleHeader'
+//  - $ref: '#/parameters/UserNamespaceHeader'
+//  - $ref: '#/parameters/DryRunQuery'
+//  - name: namespace
+//    in: path
+//    type: string
+//    required: true
+//  - name: name
+//    in: formData
+//    type: string
+//    required: true
+//  - name: file
+//    in: formData
+//    type: file
+//    required: true
+// responses:
+//  '201':
+//    description: config map created
+//    schema:
+//      $ref: '#/definitions/ConfigMapWithBinaryData'
+//  default:
+//    $ref: '#/responses/error'
+func CreateConfigMapFromFiles(ctx *gin.Context) {
+	namespace := ctx.Param(namespaceParam)
+	log.WithFields(log.Fields{
+		"Namespace": namespace,
+	}).Debug("Create config map from files Call")
+
+	kube := ctx.MustGet(m.KubeClient).(*kubernetes.Kube)
+
+	name, files, err := readConfigMapFiles(ctx)
+	if err != nil {
+		ctx.Error(err)
+		gonic.Gonic(parseConfigMapFilesError(err), ctx)
+		return
+	}
+
+	ns, err := kube.GetNamespace(namespace)
+	if err != nil {
+		gonic.Gonic(model.ParseKubernetesResourceError(err, kubeerrors.ErrUnableCreateResource()), ctx)
+		return
+	}
+
+	cm, errs := model.ConfigMapFromFiles(name, files).ToKube(namespace, "", ns.Labels)
+	if errs != nil {
+		gonic.Gonic(kubeerrors.ErrRequestValidationFailed().AddDetailsErr(errs...), ctx)
+		return
+	}
+
+	cmAfter, err := kube.CreateConfigMap(cm)
+	if err != nil {
+		ctx.Error(err)
+		gonic.Gonic(model.ParseKubernetesResourceError(err, kubeerrors.ErrUnableCreateResource()), ctx)
+		return
+	}
+
+	role := ctx.MustGet(m.UserRole).(string)
+	ret, err := model.ParseKubeConfigMap(cmAfter, role == m.RoleUser)
+	if err != nil {
+		ctx.Error(err)
+	}
+
+	ctx.JSON(http.StatusCreated, ret)
+}
+
+// swagger:operation PUT /namespaces/{namespace}/configmaps/{configmap}/files ConfigMap UpdateConfigMapFromFiles
+// Replace config map data with files uploaded as multipart form.
+// Each file becomes a key named after the file, UTF-8 files are stored in data and other files in binary data.
+// Deployments using config map are restarted if rollout query is true.
+//
+// ---
+// x-method-visibility: public
+// consumes:
+//  - multipart/form-data
+// parameters:
+//  - $ref: '#/parameters/UserIDHeader'
+//  - $ref: '#/parameters/UserRoleHeader'
+//  - $ref: '#/parameters/UserNamespaceHeader'
+//  - $ref: '#/parameters/DryRunQuery'
+//  - name: namespace
+//    in: path
+//    type: string
+//    required: true
+//  - name: configmap
+//    in: path
+//    type: string
+//    required: true
+//  - name: rollout
+//    in: query
+//    type: boolean
+//    description: restart deployments using config map, in dry run mode they are reported as skipped
+//    required: false
+//  - name: file
+//    in: formData
+//    type: file
+//    required: true
+// responses:
+//  '202':
+//    description: config map updated
+//    schema:
+//      $ref: '#/definitions/ConfigMapWithRollout'
+//  '207':
+//    description: config map updated, rollout or restart of some deployments failed
+//    schema:
+//      $ref: '#/definitions/ConfigMapWithRollout'
+//  default:
+//    $ref: '#/responses/error'
+func UpdateConfigMapFromFiles(ctx *gin.Context) {
+	namespace := ctx.Param(namespaceParam)
+	configMap := ctx.Param(configMapParam)
+	log.WithFields(log.Fields{
+		"Namespace": namespace,
+		"ConfigMap": configMap,
+	}).Debug("Update config map from files Call")
+
+	kube := ctx.MustGet(m.KubeClient).(*kubernetes.Kube)
+
+	rollout, cherr := parseRolloutQuery(ctx)
+	if cherr != nil {
+		gonic.Gonic(cherr, ctx)
+		return
+	}
+
+	_, files, err := readConfigMapFiles(ctx)
+	if err != nil {
+		ctx.Error(err)
+		gonic.Gonic(parseConfigMapFilesError(err), ctx)
+		return
+	}
+
+	ns, err := kube.GetNamespace(namespace)
+	if err != nil {
+		gonic.Gonic(model.ParseKubernetesResourceError(err, kubeerrors.ErrUnableUpdateResource()), ctx)
+		return
+	}
+
+	oldCm, err := kube.GetConfigMap(namespace, configMap)
+	if err != nil {
+		gonic.Gonic(model.ParseKubernetesResourceError(err, kubeerrors.ErrUnableUpdateResource()), ctx)
+		return
+	}
+
+	newCm, errs := model.ConfigMapFromFiles(configMap, files).ToKube(namespace, model.ParseSolutionID(oldCm), ns.Labels)
+	if errs != nil {
+		gonic.Gonic(kubeerrors.ErrRequestValidationFailed().AddDetailsErr(errs...), ctx)
+		return
+	}
+
+	cmAfter, err := kube.UpdateConfigMap(newCm)
+	if err != nil {
+		gonic.Gonic(model.ParseKubernetesResourceError(err, kubeerrors.ErrUnableUpdateResource()), ctx)
+		return
+	}
+
+	respondConfigMapUpdated(ctx, kube, cmAfter, rollout)
+}
+
+// swagger:operation GET /namespaces/{namespace}/configmaps/{configmap}/files ConfigMap DownloadConfigMapFiles
+// Download config map key as file or all keys as tar archive.
+//
+// ---
+// x-method-visibility: public
+// produces:
+//  - application/octet-stream
+//  - application/x-tar
+// parameters:
+//  - $ref: '#/parameters/UserIDHeader'
+//  - $ref: '#/parameters/UserRoleHeader'
+//  - $ref: '#/parameters/UserNamespaceHeader'
+//  - name: namespace
+//    in: path
+//    type: string
+//    required: true
+//  - name: configmap
+//    in: path
+//    type: string
+//    required: true
+//  - name: key
+//    in: query
+//    type: string
+//    description: key to download, all keys are downloaded as tar archive if not set
+//    required: false
+// responses:
+//  '200':
+//    description: file or tar archive
+//  default:
+//    $ref: '#/responses/error'
+func DownloadConfigMapFiles(ctx *gin.Context) {
+	namespace := ctx.Param(namespaceParam)
+	configMap := ctx.Param(configMapParam)
+	key := ctx.Query(keyQuery)
+	log.WithFields(log.Fields{
+		"Namespace": namespace,
+		"ConfigMap": configMap,
+		"Key":       key,
+	}).Debug("Download config map files Call")
+
+	kube := ctx.MustGet(m.KubeClient).(*kubernetes.Kube)
+
+	_, err := kube.GetNamespace(namespace)
+	if err != nil {
+		gonic.Gonic(model.ParseKubernetesResourceError(err, kubeerrors.ErrUnableGetResource()), ctx)
+		return
+	}
+
+	cm, err := kube.GetConfigMap(namespace, configMap)
+	if err != nil {
+		gonic.Gonic(model.ParseKubernetesResourceError(err, kubeerrors.ErrUnableGetResource()), ctx)
+		return
+	}
+
+	files := model.GetConfigMapFiles(cm)
+	if key != "" {
+		data, ok := files[key]
+		if !ok {
+			gonic.Gonic(kubeerrors.ErrResourceNotExist().AddDetailF("key '%v' is not found in config map %v", key, configMap), ctx)
+			return
+		}
+		ctx.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": key}))
+		ctx.Data(http.StatusOK, "application/octet-stream", data)
+		return
+	}
+
+	var archive bytes.Buffer
+	if err := configMapTar(files, &archive); err != nil {
+		ctx.Error(err)
+		gonic.Gonic(kubeerrors.ErrInternalError(), ctx)
+		return
+	}
+	ctx.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": configMap + ".tar"}))
+	ctx.Data(http.StatusOK, "application/x-tar", archive.Bytes())
+}
+
+// respondConfigMapUpdated restarts deployments using updated config map if rollout is requested
+// and sends config map with rollout results
+func respondConfigMapUpdated(ctx *gin.Context, kube *kubernetes.Kube, cm *api_core.ConfigMap, rollout bool) {
+	ret := model.ConfigMapWithRollout{}
+	role := ctx.MustGet(m.UserRole).(string)
+	if parsed, err := model.ParseKubeConfigMap(cm, role == m.RoleUser); err != nil {
+		ctx.Error(err)
+	} else {
+		ret.ConfigMapWithBinaryData = *parsed
+	}
+
+	status := http.StatusAccepted
+	if rollout {
+		// config map is already updated, so rollout failure is reported with it
+		ret.Rollout, ret.RolloutError = rolloutDependentDeployments(kube, cm.Namespace, "ConfigMap", cm.Name)
+		status = rolloutStatus(ret.Rollout, ret.RolloutError)
+	}
+
+	ctx.JSON(status, ret)
+}
+
+// readConfigMapFiles reads "name" field and files from multipart form.
+// Total size of files is limited by config map size limit.
+func readConfigMapFiles(ctx *gin.Context) (string, map[string][]byte, error) {
+	if ctx.Request.ContentLength > configMapUploadMax {
+		return "", nil, errFilesTooLarge
+	}
+	reader, err := ctx.Request.MultipartReader()
+	if err != nil {
+		return "", nil, err
+	}
+
+	var name string
+	files := make(map[string][]byte)
+	left := int64(model.ConfigMapSizeMax)
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", nil, err
+		}
+		if part.FileName() == "" {
+			if part.FormName() == nameField {
+				value, err := ioutil.ReadAll(&limitedReader{rd: part, n: configMapNameMax})
+				if err == errFilesTooLarge {
+					return "", nil, fmt.Errorf("invalid name: %v", api_validation.MaxLenError(configMapNameMax))
+				}
+				if err != nil {
+					return "", nil, err
+				}
+				name = string(value)
+			}
+			continue
+		}
+
+		key := path.Base(part.FileName())
+		if _, exists := files[key]; exists {
+			return "", nil, fmt.Errorf("duplicate file %v", key)
+		}
+		data, err := ioutil.ReadAll(&limitedReader{rd: part, n: left})
+		if err != nil {
+			return "", nil, err
+		}
+		left -= int64(len(data))
+		files[key] = data
+	}
+	if len(files) == 0 {
+		return "", nil, errNoFiles
+	}
+	return name, files, nil
+}
+
+func parseConfigMapFilesError(err error) *cherry.Err {
+	if err == errFilesTooLarge {
+		return kubeerrors.ErrFilesTooLarge().AddDetailF("maximum config map size is %v bytes", model.ConfigMapSizeMax)
+	}
+	return kubeerrors.ErrRequestValidationFailed().AddDetailsErr(err)
+}
+
+// configMapTar writes tar archive with file per config map key sorted by name
+func configMapTar(files map[string][]byte, out io.Writer) error {
+	keys := make([]string, 0, len(files))
+	for k := range files {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	now := time.Now()
+	tw := tar.NewWriter(out)
+	for _, k := range keys {
+		if err := tw.WriteHeader(&tar.Header{
+			Name:     k,
+			Mode:     0644,
+			Size:     int64(len(files[k])),
+			ModTime:  now,
+			Typeflag: tar.TypeReg,
+		}); err != nil {
+			return err
+		}
+		if _, err := tw.Write(files[k]); err != nil {
+			return err
+		}
+	}
+	return tw.Close()
+}
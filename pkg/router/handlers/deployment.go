@@ -1,22 +1,30 @@
 package handlers
 
 import (
+	"fmt"
 	"net/http"
+	"strconv"
+	"time"
 
 	"git.containerum.net/ch/kube-api/pkg/kubeerrors"
 	"git.containerum.net/ch/kube-api/pkg/kubernetes"
 	"git.containerum.net/ch/kube-api/pkg/model"
 	m "git.containerum.net/ch/kube-api/pkg/router/midlleware"
+	"github.com/containerum/cherry"
 	"github.com/containerum/cherry/adaptors/gonic"
 	kube_types "github.com/containerum/kube-client/pkg/model"
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
 	log "github.com/sirupsen/logrus"
+	api_apps "k8s.io/api/apps/v1"
+	api_core "k8s.io/api/core/v1"
+	api_errors "k8s.io/apimachinery/pkg/api/errors"
 )
 
 const (
 	deploymentParam = "deployment"
 	solutionParam   = "solution"
+	rolloutQuery    = "rollout"
 )
 
 // swagger:operation GET /namespaces/{namespace}/deployments Deployment GetDeploymentList
@@ -40,7 +48,7 @@ const (
 //  '200':
 //    description: deployments list
 //    schema:
-//      $ref: '#/definitions/DeploymentsList'
+//      $ref: '#/definitions/DeploymentWithRefsList'
 //  default:
 //    $ref: '#/responses/error'
 func GetDeploymentList(ctx *gin.Context) {
@@ -97,7 +105,7 @@ func GetDeploymentList(ctx *gin.Context) {
 //  '200':
 //    description: deployments list
 //    schema:
-//      $ref: '#/definitions/DeploymentsList'
+//      $ref: '#/definitions/DeploymentWithRefsList'
 //  default:
 //    $ref: '#/responses/error'
 func GetDeploymentSolutionList(ctx *gin.Context) {
@@ -155,7 +163,7 @@ func GetDeploymentSolutionList(ctx *gin.Context) {
 //  '200':
 //    description: deployment
 //    schema:
-//      $ref: '#/definitions/Deployment'
+//      $ref: '#/definitions/DeploymentWithRefs'
 //  default:
 //    $ref: '#/responses/error'
 func GetDeployment(ctx *gin.Context) {
@@ -200,6 +208,7 @@ func GetDeployment(ctx *gin.Context) {
 //  - $ref: '#/parameters/UserIDHeader'
 //  - $ref: '#/parameters/UserRoleHeader'
 //  - $ref: '#/parameters/UserNamespaceHeader'
+//  - $ref: '#/parameters/DryRunQuery'
 //  - name: namespace
 //    in: path
 //    type: string
@@ -207,12 +216,12 @@ func GetDeployment(ctx *gin.Context) {
 //  - name: body
 //    in: body
 //    schema:
-//      $ref: '#/definitions/Deployment'
+//      $ref: '#/definitions/DeploymentWithRefs'
 // responses:
 //  '201':
 //    description: deployment created
 //    schema:
-//      $ref: '#/definitions/Deployment'
+//      $ref: '#/definitions/DeploymentWithRefs'
 //  default:
 //    $ref: '#/responses/error'
 func CreateDeployment(ctx *gin.Context) {
@@ -241,16 +250,9 @@ func CreateDeployment(ctx *gin.Context) {
 		gonic.Gonic(kubeerrors.ErrRequestValidationFailed().AddDetailsErr(errs...), ctx)
 		return
 	}
-	for _, v := range deploy.Spec.Template.Spec.Volumes {
-		if v.PersistentVolumeClaim != nil {
-			if pvc, err := kube.GetPersistentVolumeClaim(namespace, v.PersistentVolumeClaim.ClaimName); err != nil {
-				gonic.Gonic(model.ParseKubernetesResourceError(err, kubeerrors.ErrUnableGetResource()), ctx)
-				return
-			} else if pvc.Status.Phase != "Bound" {
-				gonic.Gonic(kubeerrors.ErrVolumeNotReady().AddDetailF("Volume status: %v", pvc.Status.Phase), ctx)
-				return
-			}
-		}
+	if err := checkDeploymentDependencies(kube, deploy, nil); err != nil {
+		gonic.Gonic(err, ctx)
+		return
 	}
 	deployAfter, err := kube.CreateDeployment(deploy)
 	if err != nil {
@@ -275,6 +277,7 @@ func CreateDeployment(ctx *gin.Context) {
 //  - $ref: '#/parameters/UserIDHeader'
 //  - $ref: '#/parameters/UserRoleHeader'
 //  - $ref: '#/parameters/UserNamespaceHeader'
+//  - $ref: '#/parameters/DryRunQuery'
 //  - name: namespace
 //    in: path
 //    type: string
@@ -286,12 +289,12 @@ func CreateDeployment(ctx *gin.Context) {
 //  - name: body
 //    in: body
 //    schema:
-//      $ref: '#/definitions/Deployment'
+//      $ref: '#/definitions/DeploymentWithRefs'
 // responses:
 //  '202':
 //    description: deployment updated
 //    schema:
-//      $ref: '#/definitions/Deployment'
+//      $ref: '#/definitions/DeploymentWithRefs'
 //  default:
 //    $ref: '#/responses/error'
 func UpdateDeployment(ctx *gin.Context) {
@@ -335,6 +338,14 @@ func UpdateDeployment(ctx *gin.Context) {
 		return
 	}
 
+	if errs, err := checkDeploymentReferences(kube, deploy, nil); err != nil {
+		gonic.Gonic(err, ctx)
+		return
+	} else if err := validationError(errs); err != nil {
+		gonic.Gonic(err, ctx)
+		return
+	}
+
 	//Ensure that immutable selectors wouldn't change
 	deploy.Spec.Selector = oldDeploy.Spec.Selector
 	deploy.Spec.Template.Labels = oldDeploy.Spec.Template.Labels
@@ -364,6 +375,7 @@ func UpdateDeployment(ctx *gin.Context) {
 //  - $ref: '#/parameters/UserIDHeader'
 //  - $ref: '#/parameters/UserRoleHeader'
 //  - $ref: '#/parameters/UserNamespaceHeader'
+//  - $ref: '#/parameters/DryRunQuery'
 //  - name: namespace
 //    in: path
 //    type: string
@@ -380,7 +392,7 @@ func UpdateDeployment(ctx *gin.Context) {
 //  '202':
 //    description: deployment updated
 //    schema:
-//      $ref: '#/definitions/Deployment'
+//      $ref: '#/definitions/DeploymentWithRefs'
 //  default:
 //    $ref: '#/responses/error'
 func UpdateDeploymentReplicas(ctx *gin.Context) {
@@ -439,6 +451,7 @@ func UpdateDeploymentReplicas(ctx *gin.Context) {
 //  - $ref: '#/parameters/UserIDHeader'
 //  - $ref: '#/parameters/UserRoleHeader'
 //  - $ref: '#/parameters/UserNamespaceHeader'
+//  - $ref: '#/parameters/DryRunQuery'
 //  - name: namespace
 //    in: path
 //    type: string
@@ -455,7 +468,7 @@ func UpdateDeploymentReplicas(ctx *gin.Context) {
 //  '202':
 //    description: deployment updated
 //    schema:
-//      $ref: '#/definitions/Deployment'
+//      $ref: '#/definitions/DeploymentWithRefs'
 //  default:
 //    $ref: '#/responses/error'
 func UpdateDeploymentImage(ctx *gin.Context) {
@@ -518,6 +531,7 @@ func UpdateDeploymentImage(ctx *gin.Context) {
 //  - $ref: '#/parameters/UserIDHeader'
 //  - $ref: '#/parameters/UserRoleHeader'
 //  - $ref: '#/parameters/UserNamespaceHeader'
+//  - $ref: '#/parameters/DryRunQuery'
 //  - name: namespace
 //    in: path
 //    type: string
@@ -565,6 +579,7 @@ func DeleteDeployment(ctx *gin.Context) {
 //  - $ref: '#/parameters/UserIDHeader'
 //  - $ref: '#/parameters/UserRoleHeader'
 //  - $ref: '#/parameters/UserNamespaceHeader'
+//  - $ref: '#/parameters/DryRunQuery'
 //  - name: namespace
 //    in: path
 //    type: string
@@ -602,3 +617,160 @@ func DeleteDeploymentsSolution(ctx *gin.Context) {
 
 	ctx.Status(http.StatusAccepted)
 }
+
+// checkDeploymentDependencies checks volumes, secrets and config maps used by deployment.
+// All found problems are reported at once as validation error.
+func checkDeploymentDependencies(kube *kubernetes.Kube, deploy *api_apps.Deployment, skip map[string]bool) *cherry.Err {
+	volumeErrs, err := checkDeploymentVolumes(kube, deploy, skip)
+	if err != nil {
+		return err
+	}
+	referenceErrs, err := checkDeploymentReferences(kube, deploy, skip)
+	if err != nil {
+		return err
+	}
+	return validationError(append(volumeErrs, referenceErrs...))
+}
+
+// checkDeploymentVolumes checks that persistent volume claims used by deployment exist and are bound.
+// In dry run mode all problems are returned as validation errors.
+func checkDeploymentVolumes(kube *kubernetes.Kube, deploy *api_apps.Deployment, skip map[string]bool) ([]error, *cherry.Err) {
+	var errs []error
+	for _, v := range deploy.Spec.Template.Spec.Volumes {
+		if v.PersistentVolumeClaim == nil || skip[objectKey("PersistentVolumeClaim", v.PersistentVolumeClaim.ClaimName)] {
+			continue
+		}
+		pvc, err := kube.GetPersistentVolumeClaim(deploy.Namespace, v.PersistentVolumeClaim.ClaimName)
+		switch {
+		case err != nil && !kube.IsDryRun():
+			return nil, model.ParseKubernetesResourceError(err, kubeerrors.ErrUnableGetResource())
+		case err != nil:
+			errs = append(errs, fmt.Errorf("volume %v: %v", v.PersistentVolumeClaim.ClaimName, err))
+		case pvc.Status.Phase != api_core.ClaimBound && !kube.IsDryRun():
+			return nil, kubeerrors.ErrVolumeNotReady().AddDetailF("Volume status: %v", pvc.Status.Phase)
+		case pvc.Status.Phase != api_core.ClaimBound:
+			errs = append(errs, fmt.Errorf("volume %v is not ready, status: %v", v.PersistentVolumeClaim.ClaimName, pvc.Status.Phase))
+		}
+	}
+	return errs, nil
+}
+
+// checkDeploymentReferences checks that secrets and config maps used in containers environment and volumes exist and have referenced keys.
+// Objects from skip are not checked. Skip is keyed by "Kind/name".
+func checkDeploymentReferences(kube *kubernetes.Kube, deploy *api_apps.Deployment, skip map[string]bool) ([]error, *cherry.Err) {
+	// keys of checked objects, nil if object doesn't exist
+	keys := make(map[string]map[string]bool)
+	var errs []error
+	for _, ref := range model.GetObjectReferences(deploy) {
+		key := objectKey(ref.Kind, ref.Name)
+		if skip[key] {
+			continue
+		}
+		objKeys, checked := keys[key]
+		if !checked {
+			var err error
+			objKeys, err = getReferencedKeys(kube, deploy.Namespace, ref)
+			switch {
+			case api_errors.IsNotFound(err):
+				errs = append(errs, fmt.Errorf("%v %v is not found", ref.Kind, ref.Name))
+			case err != nil:
+				return nil, model.ParseKubernetesResourceError(err, kubeerrors.ErrUnableGetResource())
+			}
+			keys[key] = objKeys
+		}
+		if objKeys != nil && ref.Key != "" && !objKeys[ref.Key] {
+			errs = append(errs, fmt.Errorf("key %v is not found in %v %v", ref.Key, ref.Kind, ref.Name))
+		}
+	}
+	return errs, nil
+}
+
+// validationError returns validation error with all errs or nil if there are no errors
+func validationError(errs []error) *cherry.Err {
+	if len(errs) == 0 {
+		return nil
+	}
+	return kubeerrors.ErrRequestValidationFailed().AddDetailsErr(errs...)
+}
+
+func getReferencedKeys(kube *kubernetes.Kube, namespace string, ref model.ObjectReference) (map[string]bool, error) {
+	keys := make(map[string]bool)
+	if ref.IsSecret() {
+		secret, err := kube.GetSecret(namespace, ref.Name)
+		if err != nil {
+			return nil, err
+		}
+		for k := range secret.Data {
+			keys[k] = true
+		}
+		return keys, nil
+	}
+	cm, err := kube.GetConfigMap(namespace, ref.Name)
+	if err != nil {
+		return nil, err
+	}
+	for k := range cm.Data {
+		keys[k] = true
+	}
+	for k := range cm.BinaryData {
+		keys[k] = true
+	}
+	return keys, nil
+}
+
+func objectKey(kind, name string) string {
+	return kind + "/" + name
+}
+
+func parseRolloutQuery(ctx *gin.Context) (bool, *cherry.Err) {
+	value, ok := ctx.GetQuery(rolloutQuery)
+	if !ok {
+		return false, nil
+	}
+	rollout, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, kubeerrors.ErrRequestValidationFailed().AddDetailF("invalid %v query: %v", rolloutQuery, value)
+	}
+	return rollout, nil
+}
+
+// rolloutDependentDeployments restarts deployments which use config map or secret, so pods get new data.
+// Deployments which failed to restart are reported as failed results.
+// In dry run mode deployments are not restarted, so they are reported as skipped.
+func rolloutDependentDeployments(kube *kubernetes.Kube, namespace, kind, name string) ([]model.ApplyResult, *cherry.Err) {
+	deployments, err := kube.GetDeploymentList(namespace, "")
+	if err != nil {
+		return nil, model.ParseKubernetesResourceError(err, kubeerrors.ErrUnableGetResourcesList())
+	}
+
+	restarted := model.ApplyStatusUpdated
+	if kube.IsDryRun() {
+		restarted = model.ApplyStatusSkipped
+	}
+
+	results := make([]model.ApplyResult, 0)
+	now := time.Now()
+	for _, deploy := range model.GetDependentDeployments(deployments, kind, name) {
+		model.RestartDeployment(&deploy, now)
+		result := model.ApplyResult{Kind: "Deployment", Name: deploy.Name, Status: restarted}
+		if _, err := kube.UpdateDeployment(&deploy); err != nil {
+			result.Status = model.ApplyStatusFailed
+			result.Error = model.ParseKubernetesResourceError(err, kubeerrors.ErrUnableUpdateResource())
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// rolloutStatus returns 207 Multi-Status if rollout failed or some deployments failed to restart
+func rolloutStatus(results []model.ApplyResult, rolloutErr *cherry.Err) int {
+	if rolloutErr != nil {
+		return http.StatusMultiStatus
+	}
+	for _, result := range results {
+		if result.Status == model.ApplyStatusFailed {
+			return http.StatusMultiStatus
+		}
+	}
+	return http.StatusAccepted
+}
@@ -1,14 +1,18 @@
 package handlers
 
 import (
+	"bytes"
 	"io"
+	"strconv"
 	"time"
 
+	"git.containerum.net/ch/kube-api/pkg/kubeerrors"
 	"git.containerum.net/ch/kube-api/pkg/kubernetes"
 	"git.containerum.net/ch/kube-api/pkg/utils/terminal"
 	"git.containerum.net/ch/kube-api/pkg/utils/timeoutreader"
 	"git.containerum.net/ch/kube-api/pkg/utils/wsutils"
 	"git.containerum.net/ch/kube-api/proto"
+	"github.com/containerum/cherry"
 	"github.com/gin-gonic/gin"
 	"github.com/gogo/protobuf/proto"
 	"github.com/gorilla/websocket"
@@ -41,25 +45,25 @@ func receiveExecCommand(conn *websocket.Conn) (cmdMessage kubeProto.ExecCommand,
 
 func makeExecOptions(ctx *gin.Context, cmdMessage kubeProto.ExecCommand) (opts *kubernetes.ExecOptions, pipes *execPipes, tsQueue *terminal.SizeQueue) {
 	command := append([]string{cmdMessage.GetCommand()}, cmdMessage.GetArgs()...)
+	return makeStreamOptions(ctx, command)
+}
+
+func makeAttachOptions(ctx *gin.Context) (opts *kubernetes.ExecOptions, pipes *execPipes, tsQueue *terminal.SizeQueue) {
+	return makeStreamOptions(ctx, nil)
+}
 
+func makeStreamOptions(ctx *gin.Context, command []string) (opts *kubernetes.ExecOptions, pipes *execPipes, tsQueue *terminal.SizeQueue) {
 	_, tty := ctx.GetQuery(ttyQuery)
 	_, interactive := ctx.GetQuery(interactiveQuery)
 
 	var (
 		stderrIn, stderrOut = io.Pipe()
 		stdoutIn, stdoutOut = io.Pipe()
-		stdinIn             *io.PipeReader
-		stdinOut            *io.PipeWriter
 	)
 
-	if interactive {
-		stdinIn, stdinOut = io.Pipe()
-	}
-
 	pipes = &execPipes{
 		StdoutPipe: stdoutIn,
 		StderrPipe: stderrIn,
-		StdinPipe:  stdinOut,
 	}
 
 	tsQueue = terminal.NewSizeQueue(20)
@@ -71,7 +75,13 @@ func makeExecOptions(ctx *gin.Context, cmdMessage kubeProto.ExecCommand) (opts *
 		TerminalSizeQueue: tsQueue,
 		Stderr:            stderrOut,
 		Stdout:            stdoutOut,
-		Stdin:             stdinIn,
+	}
+
+	// assign only non-nil pipes, interface holding nil pointer is not nil
+	if interactive {
+		stdinIn, stdinOut := io.Pipe()
+		pipes.StdinPipe = stdinOut
+		opts.Stdin = stdinIn
 	}
 
 	return opts, pipes, tsQueue
@@ -197,3 +207,48 @@ func execToClient(conn *websocket.Conn, pipes *execPipes, closeAll func()) {
 		}
 	}
 }
+
+// cappedBuffer stores first limit bytes written to it and silently drops the rest
+type cappedBuffer struct {
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func newCappedBuffer(limit int) *cappedBuffer {
+	return &cappedBuffer{limit: limit}
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	if free := b.limit - b.buf.Len(); len(p) > free {
+		b.truncated = true
+		b.buf.Write(p[:free])
+	} else {
+		b.buf.Write(p)
+	}
+	// report full length to not interrupt stream
+	return len(p), nil
+}
+
+func (b *cappedBuffer) String() string {
+	return b.buf.String()
+}
+
+func (b *cappedBuffer) Truncated() bool {
+	return b.truncated
+}
+
+func parseExecTimeout(ctx *gin.Context) (time.Duration, *cherry.Err) {
+	value, ok := ctx.GetQuery(timeoutQuery)
+	if !ok {
+		return execTimeoutDefault, nil
+	}
+	timeout, err := strconv.Atoi(value)
+	if err != nil || timeout <= 0 {
+		return 0, kubeerrors.ErrRequestValidationFailed().AddDetailF("invalid %v query: %v", timeoutQuery, value)
+	}
+	if t := time.Duration(timeout) * time.Second; t < execTimeoutMax {
+		return t, nil
+	}
+	return execTimeoutMax, nil
+}
@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"archive/tar"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+
+	"git.containerum.net/ch/kube-api/pkg/kubeerrors"
+	"git.containerum.net/ch/kube-api/pkg/kubernetes"
+	"git.containerum.net/ch/kube-api/pkg/model"
+	m "git.containerum.net/ch/kube-api/pkg/router/midlleware"
+	"github.com/containerum/cherry/adaptors/gonic"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	solutionQuery = "solution"
+	secretsQuery  = "secrets"
+	formatQuery   = "format"
+
+	exportFormatYAML = "yaml"
+	exportFormatTar  = "tar"
+)
+
+// swagger:operation GET /namespaces/{namespace}/export Namespace ExportNamespace
+// Export namespace deployments, services, ingresses, configmaps, volumes and optionally secrets as kubernetes manifests.
+// Status and server-managed fields are stripped, so manifests can be applied to another cluster.
+// Ingresses are exported in Ingress API version served by cluster, raw ingress annotations are stripped for users.
+//
+// ---
+// x-method-visibility: public
+// produces:
+//  - application/x-yaml
+//  - application/x-tar
+// parameters:
+//  - $ref: '#/parameters/UserIDHeader'
+//  - $ref: '#/parameters/UserRoleHeader'
+//  - $ref: '#/parameters/UserNamespaceHeader'
+//  - name: namespace
+//    in: path
+//    type: string
+//    required: true
+//  - name: solution
+//    in: query
+//    type: string
+//    required: false
+//    description: export only objects of solution
+//  - name: secrets
+//    in: query
+//    type: boolean
+//    required: false
+//    description: include secrets, requires write access to namespace
+//  - name: format
+//    in: query
+//    type: string
+//    enum: [yaml, tar]
+//    required: false
+//    description: multi-document YAML (default) or tar archive with file per object
+// responses:
+//  '200':
+//    description: namespace manifests
+//    schema:
+//      type: file
+//  default:
+//    $ref: '#/responses/error'
+func ExportNamespace(ctx *gin.Context) {
+	namespace := ctx.Param(namespaceParam)
+	solution := ctx.Query(solutionQuery)
+	log.WithFields(log.Fields{
+		"Namespace": namespace,
+		"Solution":  solution,
+	}).Debug("Export namespace Call")
+
+	format := ctx.DefaultQuery(formatQuery, exportFormatYAML)
+	if format != exportFormatYAML && format != exportFormatTar {
+		gonic.Gonic(kubeerrors.ErrRequestValidationFailed().AddDetailF("invalid %v query value: %v", formatQuery, format), ctx)
+		return
+	}
+
+	var withSecrets bool
+	if value := ctx.Query(secretsQuery); value != "" {
+		var err error
+		if withSecrets, err = strconv.ParseBool(value); err != nil {
+			gonic.Gonic(kubeerrors.ErrRequestValidationFailed().AddDetailF("invalid %v query value: %v", secretsQuery, value), ctx)
+			return
+		}
+	}
+	if withSecrets {
+		// secrets are masked for users with read access
+		if m.WriteAccess(ctx); ctx.IsAborted() {
+			return
+		}
+	}
+
+	kube := ctx.MustGet(m.KubeClient).(*kubernetes.Kube)
+
+	if _, err := kube.GetNamespace(namespace); err != nil {
+		gonic.Gonic(model.ParseKubernetesResourceError(err, kubeerrors.ErrUnableGetResource()), ctx)
+		return
+	}
+
+	objects, err := kube.GetNamespaceObjects(namespace, solution, withSecrets)
+	if err != nil {
+		gonic.Gonic(model.ParseKubernetesResourceError(err, kubeerrors.ErrUnableGetResource()), ctx)
+		return
+	}
+
+	role := ctx.MustGet(m.UserRole).(string)
+	manifests, err := model.MakeNamespaceManifests((*model.NamespaceObjects)(objects), role == m.RoleUser)
+	if err != nil {
+		ctx.Error(err)
+		gonic.Gonic(kubeerrors.ErrUnableGetResource(), ctx)
+		return
+	}
+
+	filename := namespace
+	if solution != "" {
+		filename += "-" + solution
+	}
+
+	switch format {
+	case exportFormatTar:
+		ctx.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename + ".tar"}))
+		ctx.Header("Content-Type", "application/x-tar")
+		ctx.Status(http.StatusOK)
+		if err := writeManifestsTar(ctx.Writer, manifests); err != nil {
+			log.WithError(err).Warn("Unable to write namespace export archive")
+		}
+	default:
+		ctx.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename + ".yaml"}))
+		ctx.Data(http.StatusOK, "application/x-yaml", model.JoinManifests(manifests))
+	}
+}
+
+func writeManifestsTar(w http.ResponseWriter, manifests []model.Manifest) error {
+	tw := tar.NewWriter(w)
+	now := time.Now()
+	for _, manifest := range manifests {
+		err := tw.WriteHeader(&tar.Header{
+			Name:    manifest.FileName(),
+			Mode:    0644,
+			Size:    int64(len(manifest.Data)),
+			ModTime: now,
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := tw.Write(manifest.Data); err != nil {
+			return err
+		}
+	}
+	return tw.Close()
+}
@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"git.containerum.net/ch/kube-api/pkg/kubeerrors"
+	"git.containerum.net/ch/kube-api/pkg/model"
+	"github.com/containerum/cherry"
+	"github.com/gin-gonic/gin"
+	utilexec "k8s.io/client-go/util/exec"
+)
+
+var errFilesTooLarge = errors.New("files size exceeds limit")
+
+// limitedReader works like io.LimitedReader but returns errFilesTooLarge if source has more data
+type limitedReader struct {
+	rd io.Reader
+	n  int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.n <= 0 {
+		var probe [1]byte
+		if n, _ := l.rd.Read(probe[:]); n > 0 {
+			return 0, errFilesTooLarge
+		}
+		return 0, io.EOF
+	}
+	if int64(len(p)) > l.n {
+		p = p[:l.n]
+	}
+	n, err := l.rd.Read(p)
+	l.n -= int64(n)
+	return n, err
+}
+
+// tarResponseWriter sends response headers on first write,
+// so errors occurred before any data was received can be returned as usual response.
+// It never returns error to not block remote command, data is discarded after first failure
+// and stop is closed to terminate remote command.
+type tarResponseWriter struct {
+	ctx      *gin.Context
+	filename string
+	limit    int64
+	written  int64
+	started  bool
+	err      error
+	stop     chan struct{}
+}
+
+func (w *tarResponseWriter) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return len(p), nil
+	}
+	if w.written+int64(len(p)) > w.limit {
+		w.fail(errFilesTooLarge)
+		return len(p), nil
+	}
+	if !w.started {
+		w.ctx.Header("Content-Type", "application/x-tar")
+		w.ctx.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": w.filename}))
+		w.ctx.Status(http.StatusOK)
+		w.started = true
+	}
+	n, err := w.ctx.Writer.Write(p)
+	w.written += int64(n)
+	if err != nil {
+		w.fail(err)
+	}
+	return len(p), nil
+}
+
+func (w *tarResponseWriter) fail(err error) {
+	w.err = err
+	if w.stop != nil {
+		close(w.stop)
+	}
+}
+
+// abortResponse closes client connection without finishing chunked response,
+// so client sees that already sent archive is truncated instead of successful response
+func abortResponse(ctx *gin.Context) {
+	ctx.Abort()
+	conn, _, err := ctx.Writer.Hijack()
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	conn.Close()
+}
+
+func isTarContentType(contentType string) bool {
+	switch contentType {
+	case "application/x-tar", "application/tar":
+		return true
+	default:
+		return false
+	}
+}
+
+func archiveName(containerPath string) string {
+	base := path.Base(path.Clean(containerPath))
+	if base == "/" {
+		base = "root"
+	}
+	return base + ".tar"
+}
+
+// sanitizeTar copies tar archive from in to out checking that no entry will be extracted outside of destination directory
+func sanitizeTar(in io.Reader, out io.Writer) error {
+	tr := tar.NewReader(in)
+	tw := tar.NewWriter(out)
+	// entries can't be written through symlinks from the same archive
+	symlinks := make(map[string]bool)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := model.ValidateArchivePath(hdr.Name); err != nil {
+			return err
+		}
+		name := path.Clean(hdr.Name)
+		for parent := path.Dir(name); parent != "." && parent != "/"; parent = path.Dir(parent) {
+			if symlinks[parent] {
+				return fmt.Errorf("tar entry %v is inside of symlink %v", hdr.Name, parent)
+			}
+		}
+		switch hdr.Typeflag {
+		case tar.TypeReg, tar.TypeRegA, tar.TypeDir:
+			// pass
+		case tar.TypeSymlink:
+			if path.IsAbs(hdr.Linkname) {
+				return fmt.Errorf("symlink %v points to absolute path %v", hdr.Name, hdr.Linkname)
+			}
+			if err := model.ValidateArchivePath(path.Join(path.Dir(name), hdr.Linkname)); err != nil {
+				return err
+			}
+			symlinks[name] = true
+		case tar.TypeLink:
+			if err := model.ValidateArchivePath(hdr.Linkname); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unsupported type of tar entry %v", hdr.Name)
+		}
+
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if _, err := io.Copy(tw, tr); err != nil {
+			return err
+		}
+	}
+	return tw.Close()
+}
+
+// singleFileTar writes tar archive with one file read from in to out.
+// If size is unknown (negative) whole file is read to memory first.
+func singleFileTar(in io.Reader, size int64, name string, out io.Writer) error {
+	if size < 0 {
+		data, err := ioutil.ReadAll(in)
+		if err != nil {
+			return err
+		}
+		in, size = bytes.NewReader(data), int64(len(data))
+	}
+
+	tw := tar.NewWriter(out)
+	if err := tw.WriteHeader(&tar.Header{
+		Name:     name,
+		Mode:     0644,
+		Size:     size,
+		ModTime:  time.Now(),
+		Typeflag: tar.TypeReg,
+	}); err != nil {
+		return err
+	}
+	if _, err := io.CopyN(tw, in, size); err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+func parseCopyFilesError(err error, stderr *cappedBuffer) *cherry.Err {
+	if cherryErr, isCherryErr := err.(*cherry.Err); isCherryErr && cherryErr.Equals(kubeerrors.ErrExecTimeout()) {
+		return kubeerrors.ErrExecTimeout().AddDetailF("files were copied longer than %v", filesTimeout)
+	}
+	if err == errFilesTooLarge {
+		return kubeerrors.ErrFilesTooLarge().AddDetailF("maximum size is %v bytes", filesSizeMax)
+	}
+	if _, isExitErr := err.(utilexec.ExitError); isExitErr {
+		return kubeerrors.ErrRequestValidationFailed().AddDetails(strings.TrimSpace(stderr.String()))
+	}
+	if cherryErr, isCherryErr := err.(*cherry.Err); isCherryErr {
+		return cherryErr
+	}
+	return model.ParseKubernetesResourceError(err, kubeerrors.ErrUnableCopyFiles().AddDetailsErr(err))
+}
@@ -10,7 +10,6 @@ import (
 	"git.containerum.net/ch/kube-api/pkg/model"
 	m "git.containerum.net/ch/kube-api/pkg/router/midlleware"
 	"github.com/containerum/cherry/adaptors/gonic"
-	kube_types "github.com/containerum/kube-client/pkg/model"
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
 	log "github.com/sirupsen/logrus"
@@ -38,7 +37,7 @@ const (
 //  '200':
 //    description: ingresses list
 //    schema:
-//      $ref: '#/definitions/IngressesList'
+//      $ref: '#/definitions/IngressWithOptionsList'
 //  default:
 //    $ref: '#/responses/error'
 func GetIngressList(ctx *gin.Context) {
@@ -93,7 +92,7 @@ func GetIngressList(ctx *gin.Context) {
 //  '200':
 //    description: ingresses
 //    schema:
-//      $ref: '#/definitions/Ingress'
+//      $ref: '#/definitions/IngressWithOptions'
 //  default:
 //    $ref: '#/responses/error'
 func GetIngress(ctx *gin.Context) {
@@ -131,6 +130,7 @@ func GetIngress(ctx *gin.Context) {
 
 // swagger:operation POST /namespaces/{namespace}/ingresses Ingress CreateIngress
 // Create ingress.
+// Routing options are translated to ingress controller annotations, raw annotations can be set only by admin.
 //
 // ---
 // x-method-visibility: private
@@ -138,6 +138,7 @@ func GetIngress(ctx *gin.Context) {
 //  - $ref: '#/parameters/UserIDHeader'
 //  - $ref: '#/parameters/UserRoleHeader'
 //  - $ref: '#/parameters/UserNamespaceHeader'
+//  - $ref: '#/parameters/DryRunQuery'
 //  - name: namespace
 //    in: path
 //    type: string
@@ -145,12 +146,12 @@ func GetIngress(ctx *gin.Context) {
 //  - name: body
 //    in: body
 //    schema:
-//      $ref: '#/definitions/Ingress'
+//      $ref: '#/definitions/IngressWithOptions'
 // responses:
 //  '201':
 //    description: ingress created
 //    schema:
-//      $ref: '#/definitions/Ingress'
+//      $ref: '#/definitions/IngressWithOptions'
 //  default:
 //    $ref: '#/responses/error'
 func CreateIngress(ctx *gin.Context) {
@@ -174,7 +175,10 @@ func CreateIngress(ctx *gin.Context) {
 		return
 	}
 
-	newIngress, errs := ingressReq.ToKube(namespace, quota.Labels)
+	newIngress, errs := ingressReq.ToKube(namespace, "", quota.Labels)
+	if ctx.MustGet(m.UserRole).(string) == m.RoleUser {
+		errs = append(ingressReq.ValidateUserInput(), errs...)
+	}
 	if errs != nil {
 		gonic.Gonic(kubeerrors.ErrRequestValidationFailed().AddDetailsErr(errs...), ctx)
 		return
@@ -197,6 +201,7 @@ func CreateIngress(ctx *gin.Context) {
 
 // swagger:operation PUT /namespaces/{namespace}/ingresses/{ingress} Ingress UpdateIngress
 // Update ingress.
+// Routing options are translated to ingress controller annotations, raw annotations can be set only by admin.
 //
 // ---
 // x-method-visibility: private
@@ -204,6 +209,7 @@ func CreateIngress(ctx *gin.Context) {
 //  - $ref: '#/parameters/UserIDHeader'
 //  - $ref: '#/parameters/UserRoleHeader'
 //  - $ref: '#/parameters/UserNamespaceHeader'
+//  - $ref: '#/parameters/DryRunQuery'
 //  - name: namespace
 //    in: path
 //    type: string
@@ -215,12 +221,12 @@ func CreateIngress(ctx *gin.Context) {
 //  - name: body
 //    in: body
 //    schema:
-//      $ref: '#/definitions/Ingress'
+//      $ref: '#/definitions/IngressWithOptions'
 // responses:
 //  '201':
 //    description: ingress updated
 //    schema:
-//      $ref: '#/definitions/Ingress'
+//      $ref: '#/definitions/IngressWithOptions'
 //  default:
 //    $ref: '#/responses/error'
 func UpdateIngress(ctx *gin.Context) {
@@ -255,7 +261,14 @@ func UpdateIngress(ctx *gin.Context) {
 	ingressReq.Name = ingr
 	ingressReq.Owner = oldIngress.GetObjectMeta().GetLabels()[ownerQuery]
 
-	newIngress, errs := ingressReq.ToKube(namespace, ns.Labels)
+	role := ctx.MustGet(m.UserRole).(string)
+	var errs []error
+	if role == m.RoleUser {
+		errs = ingressReq.ValidateUserInput()
+		ingressReq.CopyUnmanagedAnnotations(oldIngress)
+	}
+	newIngress, toKubeErrs := ingressReq.ToKube(namespace, model.ParseSolutionID(oldIngress), ns.Labels)
+	errs = append(errs, toKubeErrs...)
 	if errs != nil {
 		gonic.Gonic(kubeerrors.ErrRequestValidationFailed().AddDetailsErr(errs...), ctx)
 		return
@@ -267,7 +280,6 @@ func UpdateIngress(ctx *gin.Context) {
 		return
 	}
 
-	role := ctx.MustGet(m.UserRole).(string)
 	ret, err := model.ParseKubeIngress(ingressAfter, role == m.RoleUser)
 	if err != nil {
 		ctx.Error(err)
@@ -285,6 +297,7 @@ func UpdateIngress(ctx *gin.Context) {
 //  - $ref: '#/parameters/UserIDHeader'
 //  - $ref: '#/parameters/UserRoleHeader'
 //  - $ref: '#/parameters/UserNamespaceHeader'
+//  - $ref: '#/parameters/DryRunQuery'
 //  - name: namespace
 //    in: path
 //    type: string
@@ -336,7 +349,7 @@ func DeleteIngress(ctx *gin.Context) {
 //  '200':
 //    description: ingresses list from all users namespaces
 //    schema:
-//      $ref: '#/definitions/SelectedIngressesList'
+//      $ref: '#/definitions/SelectedIngressesWithOptionsList'
 //  default:
 //    $ref: '#/responses/error'
 func GetSelectedIngresses(ctx *gin.Context) {
@@ -344,7 +357,7 @@ func GetSelectedIngresses(ctx *gin.Context) {
 
 	kube := ctx.MustGet(m.KubeClient).(*kubernetes.Kube)
 
-	ingresses := make(kube_types.SelectedIngressesList)
+	ingresses := make(model.SelectedIngressesWithOptionsList)
 
 	role := ctx.MustGet(m.UserRole).(string)
 	if role == m.RoleUser {
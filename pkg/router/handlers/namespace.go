@@ -2,6 +2,8 @@ package handlers
 
 import (
 	"net/http"
+	"strconv"
+	"time"
 
 	"git.containerum.net/ch/kube-api/pkg/kubeerrors"
 	"git.containerum.net/ch/kube-api/pkg/kubernetes"
@@ -12,13 +14,23 @@ import (
 	"github.com/gin-gonic/gin"
 	log "github.com/sirupsen/logrus"
 
+	"github.com/containerum/cherry"
 	"github.com/containerum/cherry/adaptors/gonic"
 	"github.com/gin-gonic/gin/binding"
+	api_core "k8s.io/api/core/v1"
+	api_errors "k8s.io/apimachinery/pkg/api/errors"
+	api_meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 )
 
 const (
 	ownerQuery     = "owner"
 	namespaceParam = "namespace"
+	operationParam = "operation"
+	waitQuery      = "wait"
+
+	namespaceDeletionTimeout      = time.Minute
+	namespaceDeletionPollInterval = 2 * time.Second
 )
 
 // swagger:operation GET /namespaces Namespace GetNamespaceList
@@ -38,7 +50,7 @@ const (
 //  '200':
 //    description: ingresses list
 //    schema:
-//      $ref: '#/definitions/NamespacesList'
+//      $ref: '#/definitions/NamespaceWithQuotaList'
 //  default:
 //    $ref: '#/responses/error'
 func GetNamespaceList(ctx *gin.Context) {
@@ -87,7 +99,7 @@ func GetNamespaceList(ctx *gin.Context) {
 //  '200':
 //    description: namespace
 //    schema:
-//      $ref: '#/definitions/Namespace'
+//      $ref: '#/definitions/NamespaceWithQuota'
 //  default:
 //    $ref: '#/responses/error'
 func GetNamespace(ctx *gin.Context) {
@@ -129,15 +141,16 @@ func GetNamespace(ctx *gin.Context) {
 //  - $ref: '#/parameters/UserIDHeader'
 //  - $ref: '#/parameters/UserRoleHeader'
 //  - $ref: '#/parameters/UserNamespaceHeader'
+//  - $ref: '#/parameters/DryRunQuery'
 //  - name: body
 //    in: body
 //    schema:
-//      $ref: '#/definitions/Namespace'
+//      $ref: '#/definitions/NamespaceWithQuota'
 // responses:
 //  '201':
 //    description: namespace created
 //    schema:
-//      $ref: '#/definitions/Namespace'
+//      $ref: '#/definitions/NamespaceWithQuota'
 //  default:
 //    $ref: '#/responses/error'
 func CreateNamespace(ctx *gin.Context) {
@@ -152,41 +165,111 @@ func CreateNamespace(ctx *gin.Context) {
 		return
 	}
 
+	quota, cherryErr := provisionNamespace(kube, &ns)
+	if cherryErr != nil {
+		gonic.Gonic(cherryErr, ctx)
+		return
+	}
+
+	ret, err := model.ParseKubeResourceQuota(quota)
+	if err != nil {
+		ctx.Error(err)
+	}
+
+	ctx.JSON(http.StatusCreated, ret)
+}
+
+// provisionNamespace creates namespace with quota and limit range.
+// If quota or limit range can't be created, namespace is deleted.
+// In dry run mode only namespace is submitted and quota which would be created is returned.
+func provisionNamespace(kube *kubernetes.Kube, ns *model.NamespaceKubeAPI) (*api_core.ResourceQuota, *cherry.Err) {
 	newNs, errs := ns.ToKube()
 	if errs != nil {
-		gonic.Gonic(kubeerrors.ErrRequestValidationFailed().AddDetailsErr(errs...), ctx)
-		return
+		return nil, kubeerrors.ErrRequestValidationFailed().AddDetailsErr(errs...)
 	}
 
 	newQuota, errs := model.MakeResourceQuota(ns.ID, newNs.Labels, ns.Resources.Hard)
 	if errs != nil {
-		gonic.Gonic(kubeerrors.ErrRequestValidationFailed().AddDetailsErr(errs...), ctx)
-		return
+		return nil, kubeerrors.ErrRequestValidationFailed().AddDetailsErr(errs...)
+	}
+
+	if err := model.SetQuotaAnnotation(newNs, newQuota); err != nil {
+		return nil, kubeerrors.ErrInternalError().AddDetailsErr(err)
 	}
 
 	_, err := kube.CreateNamespace(newNs)
+	nsCreated := err == nil
+	if api_errors.IsAlreadyExists(err) {
+		if oldNs, getErr := kube.GetNamespace(ns.ID); getErr == nil && oldNs.Status.Phase == api_core.NamespaceTerminating {
+			return nil, kubeerrors.ErrResourceAlreadyExists().AddDetailF("namespace %v is being deleted, deletion operation: %v",
+				ns.ID, model.NamespaceDeletionID(oldNs))
+		}
+	}
+	if api_errors.IsAlreadyExists(err) && !kube.IsDryRun() && isPartiallyProvisioned(kube, newNs) {
+		// previous attempt failed and wasn't rolled back, continue provisioning
+		log.WithField("Namespace", ns.ID).Warn("Namespace has no quota, resuming provisioning")
+		err = resumeNamespace(kube, newQuota)
+	}
 	if err != nil {
-		gonic.Gonic(model.ParseKubernetesResourceError(err, kubeerrors.ErrUnableCreateResource()), ctx)
-		return
+		return nil, model.ParseKubernetesResourceError(err, kubeerrors.ErrUnableCreateResource())
+	}
+
+	if kube.IsDryRun() {
+		// namespace is not created, so quota can't be submitted to it
+		return newQuota, nil
 	}
 
 	quotaCreated, err := kube.CreateNamespaceQuota(ns.ID, newQuota)
 	if err != nil {
-		gonic.Gonic(model.ParseKubernetesResourceError(err, kubeerrors.ErrUnableCreateResource()), ctx)
-		return
+		return nil, rollbackNamespace(kube, ns.ID, nsCreated, false, model.ParseKubernetesResourceError(err, kubeerrors.ErrUnableCreateResource()))
 	}
 
-	if err := kube.CreateLimitRange(ns.ID); err != nil {
-		gonic.Gonic(model.ParseKubernetesResourceError(err, kubeerrors.ErrUnableCreateResource()), ctx)
-		return
+	if err := kube.CreateLimitRange(ns.ID); err != nil && !api_errors.IsAlreadyExists(err) {
+		return nil, rollbackNamespace(kube, ns.ID, nsCreated, true, model.ParseKubernetesResourceError(err, kubeerrors.ErrUnableCreateResource()))
 	}
+	return quotaCreated, nil
+}
 
-	ret, err := model.ParseKubeResourceQuota(quotaCreated)
+// isPartiallyProvisioned checks if existing namespace has the same owner and has no quota
+func isPartiallyProvisioned(kube *kubernetes.Kube, newNs *api_core.Namespace) bool {
+	oldNs, err := kube.GetNamespace(newNs.Name)
+	if err != nil || oldNs.Status.Phase == api_core.NamespaceTerminating ||
+		model.NamespaceOwner(oldNs) != model.NamespaceOwner(newNs) {
+		return false
+	}
+	_, err = kube.GetNamespaceQuota(newNs.Name)
+	return api_errors.IsNotFound(err)
+}
+
+// resumeNamespace writes quota annotation of the new request to existing namespace
+func resumeNamespace(kube *kubernetes.Kube, quota *api_core.ResourceQuota) error {
+	oldNs, err := kube.GetNamespace(quota.Namespace)
 	if err != nil {
-		ctx.Error(err)
+		return err
 	}
+	if err := model.SetQuotaAnnotation(oldNs, quota); err != nil {
+		return err
+	}
+	_, err = kube.UpdateNamespace(oldNs)
+	return err
+}
 
-	ctx.JSON(http.StatusCreated, ret)
+// rollbackNamespace deletes objects created by failed provisioning request.
+// Namespace is deleted only if it was created by this request, otherwise only created quota is removed.
+// If objects can't be deleted, creation can be retried or namespace can be repaired.
+func rollbackNamespace(kube *kubernetes.Kube, namespace string, nsCreated, quotaCreated bool, cause *cherry.Err) *cherry.Err {
+	var err error
+	switch {
+	case nsCreated:
+		err = kube.DeleteNamespace(namespace)
+	case quotaCreated:
+		err = kube.DeleteNamespaceQuota(namespace)
+	}
+	if err != nil {
+		log.WithField("Namespace", namespace).WithError(err).Error("Unable to roll back namespace creation")
+		return cause.AddDetailF("namespace %v is partially provisioned", namespace)
+	}
+	return cause
 }
 
 // swagger:operation PUT /namespaces/{namespace} Namespace UpdateNamespace
@@ -198,6 +281,7 @@ func CreateNamespace(ctx *gin.Context) {
 //  - $ref: '#/parameters/UserIDHeader'
 //  - $ref: '#/parameters/UserRoleHeader'
 //  - $ref: '#/parameters/UserNamespaceHeader'
+//  - $ref: '#/parameters/DryRunQuery'
 //  - name: namespace
 //    in: path
 //    type: string
@@ -205,12 +289,12 @@ func CreateNamespace(ctx *gin.Context) {
 //  - name: body
 //    in: body
 //    schema:
-//      $ref: '#/definitions/Namespace'
+//      $ref: '#/definitions/NamespaceWithQuota'
 // responses:
 //  '201':
 //    description: namespace updated
 //    schema:
-//      $ref: '#/definitions/Namespace'
+//      $ref: '#/definitions/NamespaceWithQuota'
 //  default:
 //    $ref: '#/responses/error'
 func UpdateNamespace(ctx *gin.Context) {
@@ -235,14 +319,44 @@ func UpdateNamespace(ctx *gin.Context) {
 		return
 	}
 
+	nsOld, err := model.ParseKubeResourceQuota(quotaOld)
+	if err != nil {
+		ctx.Error(err)
+		gonic.Gonic(kubeerrors.ErrUnableUpdateResource(), ctx)
+		return
+	}
+	model.MergeQuotaResource(&res.Resources.Hard, nsOld.Resources.Hard)
+
 	quota, errs := model.MakeResourceQuota(quotaOld.Namespace, quotaOld.Labels, res.Resources.Hard)
 	if errs != nil {
 		gonic.Gonic(kubeerrors.ErrRequestValidationFailed().AddDetailsErr(errs...), ctx)
 		return
 	}
 
+	ns, err := kube.GetNamespace(namespace)
+	if err != nil {
+		gonic.Gonic(model.ParseKubernetesResourceError(err, kubeerrors.ErrUnableUpdateResource()), ctx)
+		return
+	}
+	oldAnnotations := ns.DeepCopy().Annotations
+	if err := model.SetQuotaAnnotation(ns, quota); err != nil {
+		ctx.Error(err)
+		gonic.Gonic(kubeerrors.ErrInternalError(), ctx)
+		return
+	}
+	// limits are stored in namespace first, so reconciler doesn't revert updated quota
+	nsAfter, err := kube.UpdateNamespace(ns)
+	if err != nil {
+		gonic.Gonic(model.ParseKubernetesResourceError(err, kubeerrors.ErrUnableUpdateResource()), ctx)
+		return
+	}
+
 	quotaAfter, err := kube.UpdateNamespaceQuota(namespace, quota)
 	if err != nil {
+		nsAfter.Annotations = oldAnnotations
+		if _, restoreErr := kube.UpdateNamespace(nsAfter); restoreErr != nil {
+			log.WithField("Namespace", namespace).WithError(restoreErr).Error("Unable to restore namespace quota annotation")
+		}
 		ctx.Error(err)
 		gonic.Gonic(model.ParseKubernetesResourceError(err, kubeerrors.ErrUnableUpdateResource()), ctx)
 		return
@@ -257,8 +371,237 @@ func UpdateNamespace(ctx *gin.Context) {
 
 }
 
+// swagger:operation POST /namespaces/{namespace}/repair Namespace RepairNamespace
+// Recreate missing quota and limit range of namespace.
+// Quota gets namespace labels and resources from request body.
+// If body is empty, resources stored in namespace on creation are used.
+// In dry run mode nothing is created and objects which would be created are returned.
+//
+// ---
+// x-method-visibility: private
+// parameters:
+//  - $ref: '#/parameters/UserIDHeader'
+//  - $ref: '#/parameters/UserRoleHeader'
+//  - $ref: '#/parameters/UserNamespaceHeader'
+//  - $ref: '#/parameters/DryRunQuery'
+//  - name: namespace
+//    in: path
+//    type: string
+//    required: true
+//  - name: body
+//    in: body
+//    required: false
+//    schema:
+//      $ref: '#/definitions/NamespaceWithQuota'
+// responses:
+//  '200':
+//    description: namespace repaired
+//    schema:
+//      $ref: '#/definitions/NamespaceRepairResult'
+//  default:
+//    $ref: '#/responses/error'
+func RepairNamespace(ctx *gin.Context) {
+	namespace := ctx.Param(namespaceParam)
+	log.WithFields(log.Fields{
+		"Namespace": namespace,
+	}).Debug("Repair namespace Call")
+
+	kube := ctx.MustGet(m.KubeClient).(*kubernetes.Kube)
+
+	var res *model.NamespaceKubeAPI
+	if ctx.Request.ContentLength != 0 {
+		res = new(model.NamespaceKubeAPI)
+		if err := ctx.ShouldBindWith(res, binding.JSON); err != nil {
+			ctx.Error(err)
+			gonic.Gonic(kubeerrors.ErrRequestValidationFailed(), ctx)
+			return
+		}
+	}
+
+	ns, err := kube.GetNamespace(namespace)
+	if err != nil {
+		gonic.Gonic(model.ParseKubernetesResourceError(err, kubeerrors.ErrUnableUpdateResource()), ctx)
+		return
+	}
+
+	ret := model.NamespaceRepairResult{
+		Namespace: namespace,
+		Created:   make([]string, 0),
+	}
+
+	_, err = kube.GetNamespaceQuota(namespace)
+	switch {
+	case api_errors.IsNotFound(err):
+		if res != nil {
+			if err := storeNamespaceQuota(kube, ns, res.Resources.Hard); err != nil {
+				gonic.Gonic(err, ctx)
+				return
+			}
+		}
+		quota, errs := model.ExpectedResourceQuota(ns, nil)
+		if errs != nil {
+			gonic.Gonic(kubeerrors.ErrRequestValidationFailed().AddDetailsErr(errs...), ctx)
+			return
+		}
+		if _, err := kube.CreateNamespaceQuota(namespace, quota); err != nil {
+			gonic.Gonic(model.ParseKubernetesResourceError(err, kubeerrors.ErrUnableCreateResource()), ctx)
+			return
+		}
+		ret.Created = append(ret.Created, "ResourceQuota")
+	case err != nil:
+		gonic.Gonic(model.ParseKubernetesResourceError(err, kubeerrors.ErrUnableGetResource()), ctx)
+		return
+	}
+
+	_, err = kube.GetLimitRange(namespace)
+	switch {
+	case api_errors.IsNotFound(err):
+		if err := kube.CreateLimitRange(namespace); err != nil {
+			gonic.Gonic(model.ParseKubernetesResourceError(err, kubeerrors.ErrUnableCreateResource()), ctx)
+			return
+		}
+		ret.Created = append(ret.Created, "LimitRange")
+	case err != nil:
+		gonic.Gonic(model.ParseKubernetesResourceError(err, kubeerrors.ErrUnableGetResource()), ctx)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, ret)
+}
+
+// storeNamespaceQuota saves quota resources in namespace annotation
+func storeNamespaceQuota(kube *kubernetes.Kube, ns *api_core.Namespace, resources model.QuotaResource) *cherry.Err {
+	quota, errs := model.MakeResourceQuota(ns.Name, ns.Labels, resources)
+	if errs != nil {
+		return kubeerrors.ErrRequestValidationFailed().AddDetailsErr(errs...)
+	}
+	if err := model.SetQuotaAnnotation(ns, quota); err != nil {
+		return kubeerrors.ErrInternalError().AddDetailsErr(err)
+	}
+	if _, err := kube.UpdateNamespace(ns); err != nil {
+		return model.ParseKubernetesResourceError(err, kubeerrors.ErrUnableUpdateResource())
+	}
+	return nil
+}
+
+// swagger:operation POST /namespaces/{namespace}/clone Namespace CloneNamespace
+// Create new namespace with deployments, services, ingresses, config maps, secrets and volumes of namespace.
+// Volumes are created empty or skipped, ingress hosts get suffix, external IPs of services are not copied.
+// Objects which can't be cloned are reported in response and don't stop cloning.
+// In dry run mode namespace isn't created, so objects are only validated and reported as skipped.
+//
+// ---
+// x-method-visibility: private
+// parameters:
+//  - $ref: '#/parameters/UserIDHeader'
+//  - $ref: '#/parameters/UserRoleHeader'
+//  - $ref: '#/parameters/UserNamespaceHeader'
+//  - $ref: '#/parameters/DryRunQuery'
+//  - name: namespace
+//    in: path
+//    type: string
+//    required: true
+//  - name: body
+//    in: body
+//    schema:
+//      $ref: '#/definitions/NamespaceCloneRequest'
+// responses:
+//  '201':
+//    description: namespace cloned
+//    schema:
+//      $ref: '#/definitions/NamespaceCloneResult'
+//  '207':
+//    description: namespace created, some objects are not cloned
+//    schema:
+//      $ref: '#/definitions/NamespaceCloneResult'
+//  default:
+//    $ref: '#/responses/error'
+func CloneNamespace(ctx *gin.Context) {
+	namespace := ctx.Param(namespaceParam)
+	log.WithFields(log.Fields{
+		"Namespace": namespace,
+	}).Debug("Clone namespace Call")
+
+	kube := ctx.MustGet(m.KubeClient).(*kubernetes.Kube)
+
+	var req model.NamespaceCloneRequest
+	if err := ctx.ShouldBindWith(&req, binding.JSON); err != nil {
+		ctx.Error(err)
+		gonic.Gonic(kubeerrors.ErrRequestValidationFailed(), ctx)
+		return
+	}
+	if errs := req.Validate(); errs != nil {
+		gonic.Gonic(kubeerrors.ErrRequestValidationFailed().AddDetailsErr(errs...), ctx)
+		return
+	}
+
+	objects, err := kube.GetNamespaceObjects(namespace, "", true)
+	if err != nil {
+		gonic.Gonic(model.ParseKubernetesResourceError(err, kubeerrors.ErrUnableGetResourcesList()), ctx)
+		return
+	}
+
+	newNs := model.NamespaceKubeAPI(req.Namespace)
+	quota, cherryErr := provisionNamespace(kube, &newNs)
+	if cherryErr != nil {
+		gonic.Gonic(cherryErr, ctx)
+		return
+	}
+	// quota has labels of created namespace
+	cloned, results := model.CloneNamespaceObjects((*model.NamespaceObjects)(objects), newNs.ID, quota.Labels, req)
+
+	// cloned volumes are not bound yet when deployments are created
+	clonedVolumes := make(map[string]bool)
+	for _, obj := range cloned {
+		if _, isVolume := obj.Object.(*api_core.PersistentVolumeClaim); isVolume {
+			clonedVolumes[objectKey(obj.Kind, obj.Name)] = true
+		}
+	}
+
+	status := http.StatusCreated
+	for _, obj := range cloned {
+		result := model.ApplyResult{
+			Kind:   obj.Kind,
+			Name:   obj.Name,
+			Status: model.ApplyStatusCreated,
+		}
+		if kube.IsDryRun() {
+			// namespace is not created, so objects can't be submitted to it
+			result.Status = model.ApplyStatusSkipped
+			results = append(results, result)
+			continue
+		}
+		if err := createSolutionObject(kube, obj.Object, clonedVolumes); err != nil {
+			log.WithFields(log.Fields{
+				"Namespace": newNs.ID,
+				"Kind":      obj.Kind,
+				"Name":      obj.Name,
+			}).WithError(err).Warn("Unable to clone object")
+			result.Status = model.ApplyStatusFailed
+			result.Error = err
+		}
+		results = append(results, result)
+	}
+	for _, result := range results {
+		if result.Status == model.ApplyStatusFailed {
+			status = http.StatusMultiStatus
+		}
+	}
+
+	ret := model.NamespaceCloneResult{Objects: results}
+	if parsed, err := model.ParseKubeResourceQuota(quota); err != nil {
+		ctx.Error(err)
+	} else {
+		ret.Namespace = *parsed
+	}
+
+	ctx.JSON(status, ret)
+}
+
 // swagger:operation DELETE /namespaces/{namespace} Namespace DeleteNamespace
 // Delete namespace.
+// Namespace is deleted asynchronously, deletion progress can be got by returned operation ID.
+// In dry run mode namespace isn't deleted and objects which would be deleted are returned.
 //
 // ---
 // x-method-visibility: private
@@ -266,13 +609,25 @@ func UpdateNamespace(ctx *gin.Context) {
 //  - $ref: '#/parameters/UserIDHeader'
 //  - $ref: '#/parameters/UserRoleHeader'
 //  - $ref: '#/parameters/UserNamespaceHeader'
+//  - $ref: '#/parameters/DryRunQuery'
 //  - name: namespace
 //    in: path
 //    type: string
 //    required: true
+//  - name: wait
+//    in: query
+//    type: boolean
+//    required: false
+//    description: wait up to 1 minute until namespace is deleted
 // responses:
+//  '200':
+//    description: namespace deleted, returned only if wait is set
+//    schema:
+//      $ref: '#/definitions/NamespaceDeletion'
 //  '202':
-//    description: namespace deleted
+//    description: namespace is being deleted, body with deletion progress was added in addition to status
+//    schema:
+//      $ref: '#/definitions/NamespaceDeletion'
 //  default:
 //    $ref: '#/responses/error'
 func DeleteNamespace(ctx *gin.Context) {
@@ -283,26 +638,63 @@ func DeleteNamespace(ctx *gin.Context) {
 
 	kube := ctx.MustGet(m.KubeClient).(*kubernetes.Kube)
 
-	err := kube.DeleteNamespace(namespace)
+	wait, cherryErr := parseWaitQuery(ctx)
+	if cherryErr != nil {
+		gonic.Gonic(cherryErr, ctx)
+		return
+	}
+	// namespace isn't deleted in dry run, so its current objects are reported
+	wait = wait && !kube.IsDryRun()
+
+	ns, err := kube.GetNamespace(namespace)
 	if err != nil {
 		gonic.Gonic(model.ParseKubernetesResourceError(err, kubeerrors.ErrUnableDeleteResource()), ctx)
 		return
 	}
 
-	ctx.Status(http.StatusAccepted)
+	if err := kube.DeleteNamespace(namespace); err != nil {
+		gonic.Gonic(model.ParseKubernetesResourceError(err, kubeerrors.ErrUnableDeleteResource()), ctx)
+		return
+	}
+
+	// progress is unknown if it can't be got, it can be got later by operation ID
+	deletions, done, _ := deleteNamespacesProgress(ctx, kube, []api_core.Namespace{*ns}, wait)
+	if wait && done {
+		ctx.JSON(http.StatusOK, deletions[0])
+		return
+	}
+	ctx.JSON(http.StatusAccepted, deletions[0])
 }
 
 // swagger:operation DELETE /namespaces Namespace DeleteUserNamespaces
 // Delete user namespaces.
+// Namespaces are deleted asynchronously, deletion progress can be got by returned operation IDs.
+// In dry run mode namespaces aren't deleted and objects which would be deleted are returned.
 //
 // ---
 // x-method-visibility: private
 // parameters:
 //  - $ref: '#/parameters/UserIDHeader'
 //  - $ref: '#/parameters/UserRoleHeader'
+//  - $ref: '#/parameters/DryRunQuery'
+//  - name: wait
+//    in: query
+//    type: boolean
+//    required: false
+//    description: wait up to 1 minute until namespaces are deleted
 // responses:
+//  '200':
+//    description: namespaces deleted, returned only if wait is set
+//    schema:
+//      $ref: '#/definitions/NamespaceDeletionList'
 //  '202':
-//    description: namespaces deleted
+//    description: namespaces are being deleted, body with deletion progress was added in addition to status
+//    schema:
+//      $ref: '#/definitions/NamespaceDeletionList'
+//  '207':
+//    description: deletion of some namespaces failed, they are returned with Failed phase and error
+//    schema:
+//      $ref: '#/definitions/NamespaceDeletionList'
 //  default:
 //    $ref: '#/responses/error'
 func DeleteUserNamespaces(ctx *gin.Context) {
@@ -310,18 +702,185 @@ func DeleteUserNamespaces(ctx *gin.Context) {
 
 	kube := ctx.MustGet(m.KubeClient).(*kubernetes.Kube)
 
+	wait, cherryErr := parseWaitQuery(ctx)
+	if cherryErr != nil {
+		gonic.Gonic(cherryErr, ctx)
+		return
+	}
+	// namespaces aren't deleted in dry run, so their current objects are reported
+	wait = wait && !kube.IsDryRun()
+
 	list, err := kube.GetNamespaceList(httputil.MustGetUserID(ctx.Request.Context()))
 	if err != nil {
 		gonic.Gonic(model.ParseKubernetesResourceError(err, kubeerrors.ErrUnableDeleteResource()), ctx)
 		return
 	}
 
+	deleted := make([]api_core.Namespace, 0, len(list.Items))
+	failed := make([]model.NamespaceDeletion, 0)
 	for _, n := range list.Items {
 		err = kube.DeleteNamespace(n.Name)
 		if err != nil {
-			log.WithError(err)
+			log.WithField("Namespace", n.Name).WithError(err).Error("Unable to delete namespace")
+			failed = append(failed, model.NamespaceDeletion{
+				OperationID: model.NamespaceDeletionID(&n),
+				Namespace:   n.Name,
+				Phase:       model.NamespaceDeletionFailed,
+				Error:       model.ParseKubernetesResourceError(err, kubeerrors.ErrUnableDeleteResource()),
+			})
+			continue
 		}
+		deleted = append(deleted, n)
 	}
 
-	ctx.Status(http.StatusAccepted)
+	// progress is unknown if it can't be got, it can be got later by operation IDs
+	deletions, done, _ := deleteNamespacesProgress(ctx, kube, deleted, wait)
+	ret := model.NamespaceDeletionList{Deletions: append(deletions, failed...)}
+	switch {
+	case len(failed) > 0:
+		ctx.JSON(http.StatusMultiStatus, ret)
+	case wait && done:
+		ctx.JSON(http.StatusOK, ret)
+	default:
+		ctx.JSON(http.StatusAccepted, ret)
+	}
+}
+
+// swagger:operation GET /operations/{operation} Namespace GetNamespaceDeletion
+// Get namespace deletion progress.
+// Phase, not deleted objects and finalizers blocking deletion are returned.
+// User must have delete access to namespace.
+//
+// ---
+// x-method-visibility: private
+// parameters:
+//  - $ref: '#/parameters/UserIDHeader'
+//  - $ref: '#/parameters/UserRoleHeader'
+//  - name: operation
+//    in: path
+//    type: string
+//    required: true
+//  - name: wait
+//    in: query
+//    type: boolean
+//    required: false
+//    description: wait up to 1 minute until namespace is deleted
+// responses:
+//  '200':
+//    description: namespace deletion progress
+//    schema:
+//      $ref: '#/definitions/NamespaceDeletion'
+//  default:
+//    $ref: '#/responses/error'
+func GetNamespaceDeletion(ctx *gin.Context) {
+	operation := ctx.Param(operationParam)
+	log.WithFields(log.Fields{
+		"Operation": operation,
+	}).Debug("Get namespace deletion Call")
+
+	kube := ctx.MustGet(m.KubeClient).(*kubernetes.Kube)
+
+	wait, cherryErr := parseWaitQuery(ctx)
+	if cherryErr != nil {
+		gonic.Gonic(cherryErr, ctx)
+		return
+	}
+
+	nsName, uid, err := model.ParseNamespaceDeletionID(operation)
+	if err != nil {
+		gonic.Gonic(kubeerrors.ErrRequestValidationFailed().AddDetailsErr(err), ctx)
+		return
+	}
+
+	ns := api_core.Namespace{
+		ObjectMeta: api_meta.ObjectMeta{Name: nsName, UID: types.UID(uid)},
+	}
+	deletions, _, err := deleteNamespacesProgress(ctx, kube, []api_core.Namespace{ns}, wait)
+	if err != nil {
+		gonic.Gonic(model.ParseKubernetesResourceError(err, kubeerrors.ErrUnableGetResource()), ctx)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, deletions[0])
+}
+
+func parseWaitQuery(ctx *gin.Context) (bool, *cherry.Err) {
+	value, ok := ctx.GetQuery(waitQuery)
+	if !ok {
+		return false, nil
+	}
+	wait, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, kubeerrors.ErrRequestValidationFailed().AddDetailF("invalid %v query: %v", waitQuery, value)
+	}
+	return wait, nil
+}
+
+// deleteNamespacesProgress returns deletion progress of namespaces.
+// If wait is true, it polls progress until all namespaces are deleted, timeout expires or request is cancelled.
+// Progress which can't be got is reported as terminating, last error of getting progress is returned.
+func deleteNamespacesProgress(ctx *gin.Context, kube *kubernetes.Kube, namespaces []api_core.Namespace, wait bool) ([]model.NamespaceDeletion, bool, error) {
+	deletions := make([]model.NamespaceDeletion, len(namespaces))
+	update := func() (bool, error) {
+		done := true
+		var lastErr error
+		for i := range namespaces {
+			if deletions[i].Phase == model.NamespaceDeleted {
+				continue
+			}
+			id := model.NamespaceDeletionID(&namespaces[i])
+			deletion, err := getNamespaceDeletion(kube, id, namespaces[i].Name, string(namespaces[i].UID))
+			if err != nil {
+				lastErr = err
+				deletion = model.NamespaceDeletion{
+					OperationID: id,
+					Namespace:   namespaces[i].Name,
+					Phase:       model.NamespaceTerminating,
+				}
+			}
+			deletions[i] = deletion
+			done = done && deletion.Phase == model.NamespaceDeleted
+		}
+		return done, lastErr
+	}
+
+	done, err := update()
+	if !wait {
+		return deletions, done, err
+	}
+	timeout := time.After(namespaceDeletionTimeout)
+	for !done && err == nil {
+		select {
+		case <-ctx.Request.Context().Done():
+			return deletions, false, nil
+		case <-timeout:
+			return deletions, false, nil
+		case <-time.After(namespaceDeletionPollInterval):
+			done, err = update()
+		}
+	}
+	return deletions, done, err
+}
+
+// getNamespaceDeletion returns namespace deletion progress. Namespace with another UID is a new one, so old namespace is deleted.
+func getNamespaceDeletion(kube *kubernetes.Kube, operationID, nsName, uid string) (model.NamespaceDeletion, error) {
+	ns, err := kube.GetNamespace(nsName)
+	switch {
+	case api_errors.IsNotFound(err):
+		return model.DeletedNamespace(operationID, nsName), nil
+	case err != nil:
+		return model.NamespaceDeletion{}, err
+	case string(ns.UID) != uid:
+		return model.DeletedNamespace(operationID, nsName), nil
+	}
+
+	objects, err := kube.GetNamespaceObjects(nsName, "", true)
+	if err != nil {
+		return model.NamespaceDeletion{}, err
+	}
+	pods, err := kube.GetPodList(nsName, "")
+	if err != nil {
+		return model.NamespaceDeletion{}, err
+	}
+	return model.ParseNamespaceDeletion(ns, (*model.NamespaceObjects)(objects), pods), nil
 }
@@ -1,7 +1,9 @@
 package handlers
 
 import (
+	"io"
 	"net/http"
+	"path"
 	"sync"
 	"time"
 
@@ -12,10 +14,13 @@ import (
 	"git.containerum.net/ch/kube-api/pkg/utils/wsutils"
 
 	"git.containerum.net/ch/kube-api/pkg/kubeerrors"
+	"github.com/containerum/cherry"
 	"github.com/containerum/cherry/adaptors/gonic"
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 	"github.com/gorilla/websocket"
 	log "github.com/sirupsen/logrus"
+	utilexec "k8s.io/client-go/util/exec"
 )
 
 const (
@@ -28,6 +33,9 @@ const (
 
 	ttyQuery         = "tty"
 	interactiveQuery = "interactive"
+	timeoutQuery     = "timeout"
+	portQuery        = "port"
+	pathQuery        = "path"
 
 	tailDefault = 100
 	tailMax     = 1000
@@ -35,6 +43,13 @@ const (
 	wsBufferSize = 1024
 	wsTimeout    = 5 * time.Second
 	wsPingPeriod = time.Second
+
+	execOutputMax      = 1 << 20 // 1 MiB per stream
+	execTimeoutDefault = 30 * time.Second
+	execTimeoutMax     = 10 * time.Minute
+
+	filesSizeMax = 100 << 20 // 100 MiB for download and upload
+	filesTimeout = 10 * time.Minute
 )
 
 var wsupgrader = websocket.Upgrader{
@@ -66,7 +81,7 @@ var wsupgrader = websocket.Upgrader{
 //  '200':
 //    description: pod list
 //    schema:
-//      $ref: '#/definitions/PodsList'
+//      $ref: '#/definitions/PodWithRefsList'
 //  default:
 //    $ref: '#/responses/error'
 func GetPodList(ctx *gin.Context) {
@@ -117,7 +132,7 @@ func GetPodList(ctx *gin.Context) {
 //  '200':
 //    description: pod
 //    schema:
-//      $ref: '#/definitions/Pod'
+//      $ref: '#/definitions/PodWithRefs'
 //  default:
 //    $ref: '#/responses/error'
 func GetPod(ctx *gin.Context) {
@@ -156,6 +171,7 @@ func GetPod(ctx *gin.Context) {
 //  - $ref: '#/parameters/UserIDHeader'
 //  - $ref: '#/parameters/UserRoleHeader'
 //  - $ref: '#/parameters/UserNamespaceHeader'
+//  - $ref: '#/parameters/DryRunQuery'
 //  - name: namespace
 //    in: path
 //    type: string
@@ -333,6 +349,516 @@ func Exec(ctx *gin.Context) {
 	go execToClient(conn, pipes, closeAll)
 }
 
+// swagger:operation GET /namespaces/{namespace}/pods/{pod}/attach Pod Attach
+// Attach to main process of pod container over websocket.
+// Uses same ExecFromClient and ExecToClient protobuf messages as exec.
+//
+// ---
+// x-method-visibility: public
+// parameters:
+//  - $ref: '#/parameters/UserIDHeader'
+//  - $ref: '#/parameters/UserRoleHeader'
+//  - $ref: '#/parameters/UserNamespaceHeader'
+//  - $ref: '#/parameters/UpgradeHeader'
+//  - $ref: '#/parameters/ConnectionHeader'
+//  - $ref: '#/parameters/SecWebSocketKeyHeader'
+//  - $ref: '#/parameters/SecWebsocketVersionHeader'
+//  - name: namespace
+//    in: path
+//    type: string
+//    required: true
+//  - name: pod
+//    in: path
+//    type: string
+//    required: true
+//  - name: container
+//    in: query
+//    type: string
+//    required: false
+//  - name: interactive
+//    in: query
+//    type: string
+//    description: pass stdin to process, if container is configured to accept it
+//    required: false
+//  - name: tty
+//    in: query
+//    type: string
+//    description: use tty, if container is configured with it
+//    required: false
+// responses:
+//  '101':
+//    description: attach stream
+//  default:
+//    $ref: '#/responses/error'
+func Attach(ctx *gin.Context) {
+	log.WithFields(log.Fields{
+		"Namespace":   ctx.Param(namespaceParam),
+		"Pod":         ctx.Param(podParam),
+		"Container":   ctx.Query(containerQuery),
+		"Interactive": ctx.Query(interactiveQuery),
+		"TTY":         ctx.Query(ttyQuery),
+	}).Debug("Attach Call")
+
+	kube := ctx.MustGet(m.KubeClient).(*kubernetes.Kube)
+
+	conn, err := wsupgrader.Upgrade(ctx.Writer, ctx.Request, nil)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	opts, pipes, tsQueue := makeAttachOptions(ctx)
+
+	stop := make(chan struct{})
+	var closeOnce sync.Once
+	closeAll := func() {
+		closeOnce.Do(func() {
+			close(stop)
+			if pipes.StdinPipe != nil {
+				pipes.StdinPipe.Close()
+			}
+			pipes.StderrPipe.Close()
+			pipes.StdoutPipe.Close()
+		})
+	}
+	opts.Stop = stop
+
+	closeWd := watchdog.New(wsTimeout, closeAll)
+	defer closeWd.Stop()
+	conn.SetPongHandler(func(appData string) error {
+		conn.SetWriteDeadline(time.Now().Add(wsTimeout))
+		conn.SetReadDeadline(time.Now().Add(wsTimeout))
+		closeWd.Kick()
+		return nil
+	})
+	conn.SetWriteDeadline(time.Now().Add(wsTimeout))
+	conn.SetReadDeadline(time.Now().Add(wsTimeout))
+
+	toClientDone := make(chan struct{})
+	go execFromClient(conn, tsQueue, pipes, closeAll)
+	go func() {
+		execToClient(conn, pipes, closeAll)
+		close(toClientDone)
+	}()
+
+	err = kube.Attach(ctx.Param(namespaceParam), ctx.Param(podParam), opts)
+	select {
+	case <-stop:
+		// stream was interrupted by client
+		err = nil
+	default:
+	}
+
+	// let remaining output to be sent
+	opts.Stdout.(io.Closer).Close()
+	opts.Stderr.(io.Closer).Close()
+	<-toClientDone
+	closeAll()
+
+	if err != nil {
+		ctx.Error(err)
+		if cherryErr, isCherryErr := err.(*cherry.Err); isCherryErr {
+			wsutils.CloseWithCherry(conn, cherryErr)
+			return
+		}
+		wsutils.CloseWithCherry(conn, model.ParseKubernetesResourceError(err, kubeerrors.ErrExecFailure().AddDetailsErr(err)))
+		return
+	}
+	conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	conn.Close()
+}
+
+// swagger:operation POST /namespaces/{namespace}/pods/{pod}/exec Pod ExecPodCommand
+// Execute command in pod container and return its output.
+// If command runs longer than timeout, its output stream is closed and timeout error is returned.
+// Command is not killed by timeout, it keeps running in container until it exits.
+//
+// ---
+// x-method-visibility: public
+// parameters:
+//  - $ref: '#/parameters/UserIDHeader'
+//  - $ref: '#/parameters/UserRoleHeader'
+//  - $ref: '#/parameters/UserNamespaceHeader'
+//  - name: namespace
+//    in: path
+//    type: string
+//    required: true
+//  - name: pod
+//    in: path
+//    type: string
+//    required: true
+//  - name: timeout
+//    in: query
+//    type: integer
+//    description: command timeout in seconds, 30 by default, 600 at most
+//    required: false
+//  - name: body
+//    in: body
+//    schema:
+//      $ref: '#/definitions/ExecRequest'
+// responses:
+//  '200':
+//    description: command result
+//    schema:
+//      $ref: '#/definitions/ExecResult'
+//  default:
+//    $ref: '#/responses/error'
+func ExecPodCommand(ctx *gin.Context) {
+	namespace := ctx.Param(namespaceParam)
+	podP := ctx.Param(podParam)
+	log.WithFields(log.Fields{
+		"Namespace": namespace,
+		"Pod":       podP,
+		"Timeout":   ctx.Query(timeoutQuery),
+	}).Debug("Exec pod command Call")
+
+	kube := ctx.MustGet(m.KubeClient).(*kubernetes.Kube)
+
+	var execReq model.ExecRequest
+	if err := ctx.ShouldBindWith(&execReq, binding.JSON); err != nil {
+		ctx.Error(err)
+		gonic.Gonic(kubeerrors.ErrRequestValidationFailed(), ctx)
+		return
+	}
+
+	if errs := execReq.Validate(); errs != nil {
+		gonic.Gonic(kubeerrors.ErrRequestValidationFailed().AddDetailsErr(errs...), ctx)
+		return
+	}
+	timeout, cherryErr := parseExecTimeout(ctx)
+	if cherryErr != nil {
+		gonic.Gonic(cherryErr, ctx)
+		return
+	}
+
+	_, err := kube.GetNamespace(namespace)
+	if err != nil {
+		gonic.Gonic(model.ParseKubernetesResourceError(err, kubeerrors.ErrExecFailure()), ctx)
+		return
+	}
+
+	stdout := newCappedBuffer(execOutputMax)
+	stderr := newCappedBuffer(execOutputMax)
+
+	start := time.Now()
+	err = kube.Exec(namespace, podP, &kubernetes.ExecOptions{
+		Container: execReq.Container,
+		Command:   execReq.Command,
+		Stdout:    stdout,
+		Stderr:    stderr,
+		Timeout:   timeout,
+	})
+	duration := time.Since(start)
+
+	exitCode := 0
+	if exitErr, isExitErr := err.(utilexec.ExitError); isExitErr && exitErr.Exited() {
+		exitCode = exitErr.ExitStatus()
+	} else if cherryErr, isCherryErr := err.(*cherry.Err); isCherryErr {
+		gonic.Gonic(cherryErr, ctx)
+		return
+	} else if err != nil {
+		ctx.Error(err)
+		gonic.Gonic(model.ParseKubernetesResourceError(err, kubeerrors.ErrExecFailure().AddDetailsErr(err)), ctx)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.ExecResult{
+		Stdout:          stdout.String(),
+		Stderr:          stderr.String(),
+		StdoutTruncated: stdout.Truncated(),
+		StderrTruncated: stderr.Truncated(),
+		ExitCode:        exitCode,
+		Duration:        int64(duration / time.Millisecond),
+	})
+}
+
+// swagger:operation GET /namespaces/{namespace}/pods/{pod}/portforward Pod PortForward
+// Forward pod ports over websocket.
+// Data is exchanged with binary PortForwardFrame protobuf messages, each frame is bound to one of requested ports.
+//
+// ---
+// x-method-visibility: public
+// parameters:
+//  - $ref: '#/parameters/UserIDHeader'
+//  - $ref: '#/parameters/UserRoleHeader'
+//  - $ref: '#/parameters/UserNamespaceHeader'
+//  - $ref: '#/parameters/UpgradeHeader'
+//  - $ref: '#/parameters/ConnectionHeader'
+//  - $ref: '#/parameters/SecWebSocketKeyHeader'
+//  - $ref: '#/parameters/SecWebsocketVersionHeader'
+//  - name: namespace
+//    in: path
+//    type: string
+//    required: true
+//  - name: pod
+//    in: path
+//    type: string
+//    required: true
+//  - name: port
+//    in: query
+//    type: array
+//    items:
+//      type: integer
+//    collectionFormat: csv
+//    description: pod ports to forward, must be declared in pod containers
+//    required: true
+// responses:
+//  '101':
+//    description: port forward stream
+//  default:
+//    $ref: '#/responses/error'
+func PortForward(ctx *gin.Context) {
+	namespace := ctx.Param(namespaceParam)
+	podP := ctx.Param(podParam)
+	log.WithFields(log.Fields{
+		"Namespace": namespace,
+		"Pod":       podP,
+		"Ports":     ctx.QueryArray(portQuery),
+	}).Debug("Port forward Call")
+
+	kube := ctx.MustGet(m.KubeClient).(*kubernetes.Kube)
+
+	ports, err := makePortForwardPorts(ctx)
+	if err != nil {
+		gonic.Gonic(kubeerrors.ErrRequestValidationFailed().AddDetailsErr(err), ctx)
+		return
+	}
+
+	pod, err := kube.GetPod(namespace, podP)
+	if err != nil {
+		gonic.Gonic(model.ParseKubernetesResourceError(err, kubeerrors.ErrUnablePortForward()), ctx)
+		return
+	}
+
+	if errs := model.ValidatePortForward(pod, ports); errs != nil {
+		gonic.Gonic(kubeerrors.ErrRequestValidationFailed().AddDetailsErr(errs...), ctx)
+		return
+	}
+
+	fwd, err := kube.PortForward(namespace, podP, ports)
+	if err != nil {
+		if cherryErr, isCherryErr := err.(*cherry.Err); isCherryErr {
+			gonic.Gonic(cherryErr, ctx)
+			return
+		}
+		ctx.Error(err)
+		gonic.Gonic(model.ParseKubernetesResourceError(err, kubeerrors.ErrUnablePortForward().AddDetailsErr(err)), ctx)
+		return
+	}
+
+	conn, err := wsupgrader.Upgrade(ctx.Writer, ctx.Request, nil)
+	if err != nil {
+		fwd.Close()
+		ctx.Error(err)
+		return
+	}
+
+	newPortForwardSession(conn, fwd).run()
+}
+
+// swagger:operation GET /namespaces/{namespace}/pods/{pod}/files Pod DownloadPodFiles
+// Download file or directory from pod container as tar archive.
+// If archive exceeds size limit or copying times out after response is started,
+// connection is closed without finishing response.
+//
+// ---
+// x-method-visibility: public
+// produces:
+//  - application/x-tar
+// parameters:
+//  - $ref: '#/parameters/UserIDHeader'
+//  - $ref: '#/parameters/UserRoleHeader'
+//  - $ref: '#/parameters/UserNamespaceHeader'
+//  - name: namespace
+//    in: path
+//    type: string
+//    required: true
+//  - name: pod
+//    in: path
+//    type: string
+//    required: true
+//  - name: path
+//    in: query
+//    type: string
+//    description: absolute path to file or directory in container
+//    required: true
+//  - name: container
+//    in: query
+//    type: string
+//    required: false
+// responses:
+//  '200':
+//    description: tar archive
+//  default:
+//    $ref: '#/responses/error'
+func DownloadPodFiles(ctx *gin.Context) {
+	namespace := ctx.Param(namespaceParam)
+	podP := ctx.Param(podParam)
+	log.WithFields(log.Fields{
+		"Namespace": namespace,
+		"Pod":       podP,
+		"Path":      ctx.Query(pathQuery),
+		"Container": ctx.Query(containerQuery),
+	}).Debug("Download pod files Call")
+
+	kube := ctx.MustGet(m.KubeClient).(*kubernetes.Kube)
+
+	srcPath := ctx.Query(pathQuery)
+	if errs := model.ValidateContainerPath(srcPath); errs != nil {
+		gonic.Gonic(kubeerrors.ErrRequestValidationFailed().AddDetailsErr(errs...), ctx)
+		return
+	}
+
+	_, err := kube.GetNamespace(namespace)
+	if err != nil {
+		gonic.Gonic(model.ParseKubernetesResourceError(err, kubeerrors.ErrUnableCopyFiles()), ctx)
+		return
+	}
+
+	out := &tarResponseWriter{
+		ctx:      ctx,
+		filename: archiveName(srcPath),
+		limit:    filesSizeMax,
+		stop:     make(chan struct{}),
+	}
+	stderr := newCappedBuffer(execOutputMax)
+
+	err = kube.DownloadFiles(namespace, podP, ctx.Query(containerQuery), srcPath, out, stderr, filesTimeout, out.stop)
+	if out.err != nil {
+		// remote command was stopped because of write failure
+		err = out.err
+	}
+	if err != nil {
+		ctx.Error(err)
+		if out.started {
+			// response is already sent, break it so archive isn't taken as complete
+			abortResponse(ctx)
+			return
+		}
+		gonic.Gonic(parseCopyFilesError(err, stderr), ctx)
+		return
+	}
+	if !out.started {
+		ctx.Status(http.StatusOK)
+	}
+}
+
+// swagger:operation PUT /namespaces/{namespace}/pods/{pod}/files Pod UploadPodFiles
+// Upload files to pod container.
+// Tar archive (Content-Type application/x-tar) is extracted to directory specified in path,
+// any other body is saved as single file with specified path.
+//
+// ---
+// x-method-visibility: public
+// consumes:
+//  - application/x-tar
+//  - application/octet-stream
+// parameters:
+//  - $ref: '#/parameters/UserIDHeader'
+//  - $ref: '#/parameters/UserRoleHeader'
+//  - $ref: '#/parameters/UserNamespaceHeader'
+//  - name: namespace
+//    in: path
+//    type: string
+//    required: true
+//  - name: pod
+//    in: path
+//    type: string
+//    required: true
+//  - name: path
+//    in: query
+//    type: string
+//    description: absolute path to directory for tar archive or to file otherwise
+//    required: true
+//  - name: container
+//    in: query
+//    type: string
+//    required: false
+//  - name: body
+//    in: body
+//    schema:
+//      type: string
+//      format: binary
+// responses:
+//  '200':
+//    description: files uploaded
+//  default:
+//    $ref: '#/responses/error'
+func UploadPodFiles(ctx *gin.Context) {
+	namespace := ctx.Param(namespaceParam)
+	podP := ctx.Param(podParam)
+	log.WithFields(log.Fields{
+		"Namespace":   namespace,
+		"Pod":         podP,
+		"Path":        ctx.Query(pathQuery),
+		"Container":   ctx.Query(containerQuery),
+		"ContentType": ctx.ContentType(),
+	}).Debug("Upload pod files Call")
+
+	kube := ctx.MustGet(m.KubeClient).(*kubernetes.Kube)
+
+	destPath := ctx.Query(pathQuery)
+	if errs := model.ValidateContainerPath(destPath); errs != nil {
+		gonic.Gonic(kubeerrors.ErrRequestValidationFailed().AddDetailsErr(errs...), ctx)
+		return
+	}
+	if ctx.Request.ContentLength > filesSizeMax {
+		gonic.Gonic(parseCopyFilesError(errFilesTooLarge, nil), ctx)
+		return
+	}
+
+	isTar := isTarContentType(ctx.ContentType())
+	destDir, fileName := path.Clean(destPath), ""
+	if !isTar {
+		destDir, fileName = path.Split(destDir)
+		if fileName == "" {
+			gonic.Gonic(kubeerrors.ErrRequestValidationFailed().AddDetailF("path %v is not a file path", destPath), ctx)
+			return
+		}
+	}
+
+	_, err := kube.GetNamespace(namespace)
+	if err != nil {
+		gonic.Gonic(model.ParseKubernetesResourceError(err, kubeerrors.ErrUnableCopyFiles()), ctx)
+		return
+	}
+
+	body := &limitedReader{rd: ctx.Request.Body, n: filesSizeMax}
+	archiveIn, archiveOut := io.Pipe()
+	archiveErr := make(chan error, 1)
+	go func() {
+		var err error
+		if isTar {
+			err = sanitizeTar(body, archiveOut)
+		} else {
+			err = singleFileTar(body, ctx.Request.ContentLength, fileName, archiveOut)
+		}
+		archiveOut.CloseWithError(err)
+		archiveErr <- err
+	}()
+
+	stderr := newCappedBuffer(execOutputMax)
+	err = kube.UploadFiles(namespace, podP, ctx.Query(containerQuery), destDir, archiveIn, stderr, filesTimeout)
+	// unblock archive writer if command exited before reading all data
+	archiveIn.Close()
+	if copyErr := <-archiveErr; copyErr != nil && copyErr != io.ErrClosedPipe {
+		ctx.Error(copyErr)
+		if copyErr == errFilesTooLarge {
+			gonic.Gonic(parseCopyFilesError(copyErr, stderr), ctx)
+		} else {
+			gonic.Gonic(kubeerrors.ErrRequestValidationFailed().AddDetailsErr(copyErr), ctx)
+		}
+		return
+	}
+	if err != nil {
+		ctx.Error(err)
+		gonic.Gonic(parseCopyFilesError(err, stderr), ctx)
+		return
+	}
+
+	ctx.Status(http.StatusOK)
+}
+
 // swagger:operation GET /namespaces/{namespace}/deployments/{deployment}/pods Pod GetDeploymentPodList
 // Get deployment pods list.
 //
@@ -354,7 +880,7 @@ func Exec(ctx *gin.Context) {
 //  '200':
 //    description: deployment pod list
 //    schema:
-//      $ref: '#/definitions/PodsList'
+//      $ref: '#/definitions/PodWithRefsList'
 //  default:
 //    $ref: '#/responses/error'
 func GetDeploymentPodList(ctx *gin.Context) {
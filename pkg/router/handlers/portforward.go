@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"git.containerum.net/ch/kube-api/pkg/kubernetes"
+	"git.containerum.net/ch/kube-api/pkg/utils/watchdog"
+	"git.containerum.net/ch/kube-api/pkg/utils/wsutils"
+	"git.containerum.net/ch/kube-api/proto"
+	"github.com/gin-gonic/gin"
+	"github.com/gogo/protobuf/proto"
+	"github.com/gorilla/websocket"
+	log "github.com/sirupsen/logrus"
+)
+
+func makePortForwardPorts(ctx *gin.Context) ([]int, error) {
+	var ports []int
+	for _, param := range ctx.QueryArray(portQuery) {
+		for _, p := range strings.Split(param, ",") {
+			port, err := strconv.Atoi(strings.TrimSpace(p))
+			if err != nil {
+				return nil, err
+			}
+			ports = append(ports, port)
+		}
+	}
+	return ports, nil
+}
+
+// portForwardSession multiplexes forwarded pod ports over single websocket connection
+type portForwardSession struct {
+	conn *websocket.Conn
+	fwd  *kubernetes.PortForwardConn
+
+	frames    chan *kubeProto.PortForwardFrame
+	done      chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+func newPortForwardSession(conn *websocket.Conn, fwd *kubernetes.PortForwardConn) *portForwardSession {
+	return &portForwardSession{
+		conn:   conn,
+		fwd:    fwd,
+		frames: make(chan *kubeProto.PortForwardFrame),
+		done:   make(chan struct{}),
+	}
+}
+
+// run blocks until client or pod closes connection
+func (s *portForwardSession) run() {
+	closeWd := watchdog.New(wsTimeout, s.close)
+	defer closeWd.Stop()
+	s.conn.SetPongHandler(func(appData string) error {
+		s.conn.SetReadDeadline(time.Now().Add(wsTimeout))
+		closeWd.Kick()
+		return nil
+	})
+	s.conn.SetReadDeadline(time.Now().Add(wsTimeout))
+
+	for port, stream := range s.fwd.Streams {
+		s.wg.Add(2)
+		go s.portToClient(port, stream)
+		go s.portErrorToClient(port, stream)
+	}
+	go s.fromClient()
+	go func() {
+		select {
+		case <-s.fwd.CloseNotify():
+			s.close()
+		case <-s.done:
+		}
+	}()
+
+	s.toClient()
+	s.wg.Wait()
+}
+
+func (s *portForwardSession) close() {
+	s.closeOnce.Do(func() {
+		close(s.done)
+		s.fwd.Close()
+		s.conn.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""),
+			time.Now().Add(wsTimeout))
+		s.conn.Close()
+	})
+}
+
+// send queues frame to client, returns false if session is closed
+func (s *portForwardSession) send(frame *kubeProto.PortForwardFrame) bool {
+	select {
+	case s.frames <- frame:
+		return true
+	case <-s.done:
+		return false
+	}
+}
+
+func (s *portForwardSession) toClient() {
+	defer s.close()
+
+	pingTimer := time.NewTicker(wsPingPeriod)
+	defer pingTimer.Stop()
+
+	for {
+		var err error
+		select {
+		case frame := <-s.frames:
+			rawMsg, marshalErr := proto.Marshal(frame)
+			if marshalErr != nil {
+				log.WithError(marshalErr).Errorf("port forward frame marshal failed")
+				return
+			}
+			s.conn.SetWriteDeadline(time.Now().Add(wsTimeout))
+			err = s.conn.WriteMessage(websocket.BinaryMessage, rawMsg)
+		case <-pingTimer.C:
+			s.conn.SetWriteDeadline(time.Now().Add(wsTimeout))
+			err = s.conn.WriteMessage(websocket.PingMessage, nil)
+		case <-s.done:
+			return
+		}
+
+		switch {
+		case err == nil,
+			wsutils.IsNetTemporary(err):
+			// pass
+		case err == websocket.ErrCloseSent,
+			wsutils.IsNetTimeout(err),
+			wsutils.IsBrokenPipe(err),
+			wsutils.IsClose(err):
+			return
+		default:
+			log.WithError(err).Errorf("port forward data send failed")
+			return
+		}
+	}
+}
+
+func (s *portForwardSession) fromClient() {
+	defer s.close()
+
+	for {
+		messageType, message, err := s.conn.ReadMessage()
+		switch {
+		case err == nil,
+			wsutils.IsNetTemporary(err):
+			// pass
+		case wsutils.IsNetTimeout(err),
+			wsutils.IsBrokenPipe(err),
+			wsutils.IsClose(err):
+			return
+		default:
+			select {
+			case <-s.done:
+			default:
+				log.WithError(err).Errorf("port forward data read failed")
+			}
+			return
+		}
+
+		if messageType != websocket.BinaryMessage {
+			continue
+		}
+
+		var frame kubeProto.PortForwardFrame
+		if err = proto.Unmarshal(message, &frame); err != nil {
+			log.WithError(err).Warnf("invalid port forward data from client")
+			continue
+		}
+
+		stream, ok := s.fwd.Streams[int(frame.GetPort())]
+		if !ok {
+			if !s.send(&kubeProto.PortForwardFrame{
+				Port:  frame.GetPort(),
+				Error: "port is not forwarded",
+			}) {
+				return
+			}
+			continue
+		}
+
+		if data := frame.GetData(); len(data) > 0 {
+			if _, err = stream.Data.Write(data); err != nil {
+				if !s.send(&kubeProto.PortForwardFrame{
+					Port:  frame.GetPort(),
+					Error: err.Error(),
+				}) {
+					return
+				}
+				continue
+			}
+		}
+		if frame.GetClose() {
+			// closes only our side of stream, pod can still send data
+			stream.Data.Close()
+		}
+	}
+}
+
+func (s *portForwardSession) portToClient(port int, stream *kubernetes.PortForwardStream) {
+	defer s.wg.Done()
+
+	var buf [wsBufferSize]byte
+	for {
+		n, err := stream.Data.Read(buf[:])
+		if n > 0 {
+			data := make([]byte, n)
+			copy(data, buf[:n])
+			if !s.send(&kubeProto.PortForwardFrame{
+				Port: uint32(port),
+				Data: data,
+			}) {
+				return
+			}
+		}
+		if err != nil {
+			s.send(&kubeProto.PortForwardFrame{
+				Port:  uint32(port),
+				Close: true,
+			})
+			return
+		}
+	}
+}
+
+func (s *portForwardSession) portErrorToClient(port int, stream *kubernetes.PortForwardStream) {
+	defer s.wg.Done()
+
+	message, err := ioutil.ReadAll(stream.Error)
+	if err != nil || len(message) == 0 {
+		return
+	}
+	s.send(&kubeProto.PortForwardFrame{
+		Port:  uint32(port),
+		Error: string(message),
+	})
+}
@@ -16,10 +16,15 @@ import (
 
 const (
 	secretParam = "secret"
+	typeQuery   = "type"
+
+	allSecretTypes = "all"
 )
 
 // swagger:operation GET /namespaces/{namespace}/secrets Secret GetSecretList
-// Get TLS secrets list.
+// Get secrets list.
+// Opaque and TLS secrets are returned by default, docker registry secrets are returned if docker query is set.
+// Type query selects secrets of type, "all" returns secrets of all types.
 //
 // ---
 // x-method-visibility: public
@@ -35,11 +40,15 @@ const (
 //    in: query
 //    type: string
 //    required: false
+//  - name: type
+//    in: query
+//    type: string
+//    required: false
 // responses:
 //  '200':
 //    description: secrets list
 //    schema:
-//      $ref: '#/definitions/SecretsList'
+//      $ref: '#/definitions/SecretWithTypeList'
 //  default:
 //    $ref: '#/responses/error'
 func GetSecretList(ctx *gin.Context) {
@@ -57,11 +66,17 @@ func GetSecretList(ctx *gin.Context) {
 	}
 
 	_, isDocker := ctx.GetQuery("docker")
+	secretType := ctx.Query(typeQuery)
 
 	var secrets *api_core.SecretList
-	if isDocker {
+	switch {
+	case secretType == allSecretTypes:
+		secrets, err = kube.GetSecretList(namespace, "")
+	case secretType != "":
+		secrets, err = kube.GetSecretList(namespace, api_core.SecretType(secretType))
+	case isDocker:
 		secrets, err = kube.GetDockerSecretList(namespace)
-	} else {
+	default:
 		secrets, err = kube.GetTLSSecretList(namespace)
 	}
 	if err != nil {
@@ -70,7 +85,7 @@ func GetSecretList(ctx *gin.Context) {
 	}
 
 	role := ctx.MustGet(m.UserRole).(string)
-	ret, err := model.ParseKubeSecretList(secrets, role == m.RoleUser)
+	ret, err := model.ParseKubeSecretWithTypeList(secrets, role == m.RoleUser)
 	if err != nil {
 		ctx.Error(err)
 		gonic.Gonic(kubeerrors.ErrUnableGetResourcesList(), ctx)
@@ -101,7 +116,7 @@ func GetSecretList(ctx *gin.Context) {
 //  '200':
 //    description: secret
 //    schema:
-//      $ref: '#/definitions/Secret'
+//      $ref: '#/definitions/SecretWithType'
 //  default:
 //    $ref: '#/responses/error'
 func GetSecret(ctx *gin.Context) {
@@ -127,7 +142,7 @@ func GetSecret(ctx *gin.Context) {
 	}
 
 	role := ctx.MustGet(m.UserRole).(string)
-	ret, err := model.ParseKubeSecret(secret, role == m.RoleUser)
+	ret, err := model.ParseKubeSecretWithType(secret, role == m.RoleUser)
 	if err != nil {
 		ctx.Error(err)
 		gonic.Gonic(kubeerrors.ErrUnableGetResource(), ctx)
@@ -137,8 +152,89 @@ func GetSecret(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, ret)
 }
 
+// swagger:operation POST /namespaces/{namespace}/secrets/opaque Secret CreateOpaqueSecret
+// Create opaque secret.
+// Binary values should be base64 encoded with encoding set to base64.
+//
+// ---
+// x-method-visibility: private
+// parameters:
+//  - $ref: '#/parameters/UserIDHeader'
+//  - $ref: '#/parameters/UserRoleHeader'
+//  - $ref: '#/parameters/UserNamespaceHeader'
+//  - $ref: '#/parameters/DryRunQuery'
+//  - name: namespace
+//    in: path
+//    type: string
+//    required: true
+//  - name: body
+//    in: body
+//    schema:
+//      $ref: '#/definitions/SecretWithType'
+// responses:
+//  '201':
+//    description: secret created
+//    schema:
+//      $ref: '#/definitions/SecretWithType'
+//  default:
+//    $ref: '#/responses/error'
+func CreateOpaqueSecret(ctx *gin.Context) {
+	namespace := ctx.Param(namespaceParam)
+	log.WithFields(log.Fields{
+		"Namespace": namespace,
+	}).Debug("Create opaque secret Call")
+
+	kube := ctx.MustGet(m.KubeClient).(*kubernetes.Kube)
+
+	var secretReq model.SecretWithType
+	if err := ctx.ShouldBindWith(&secretReq, binding.JSON); err != nil {
+		ctx.Error(err)
+		gonic.Gonic(kubeerrors.ErrRequestValidationFailed(), ctx)
+		return
+	}
+	if secretReq.Type != "" && secretReq.Type != string(api_core.SecretTypeOpaque) {
+		gonic.Gonic(kubeerrors.ErrRequestValidationFailed().AddDetailF("invalid secret type %v, expected %v",
+			secretReq.Type, api_core.SecretTypeOpaque), ctx)
+		return
+	}
+
+	ns, err := kube.GetNamespaceQuota(namespace)
+	if err != nil {
+		gonic.Gonic(model.ParseKubernetesResourceError(err, kubeerrors.ErrUnableCreateResource()), ctx)
+		return
+	}
+
+	decoded, errs := secretReq.DecodeData()
+	var newSecret *api_core.Secret
+	if decoded != nil {
+		var toKubeErrs []error
+		newSecret, toKubeErrs = decoded.ToKube(namespace, "", ns.Labels, api_core.SecretTypeOpaque)
+		errs = append(errs, toKubeErrs...)
+	}
+	if errs != nil {
+		gonic.Gonic(kubeerrors.ErrRequestValidationFailed().AddDetailsErr(errs...), ctx)
+		return
+	}
+
+	secretAfter, err := kube.CreateSecret(newSecret)
+	if err != nil {
+		gonic.Gonic(model.ParseKubernetesResourceError(err, kubeerrors.ErrUnableCreateResource()), ctx)
+		return
+	}
+
+	role := ctx.MustGet(m.UserRole).(string)
+	ret, err := model.ParseKubeSecretWithType(secretAfter, role == m.RoleUser)
+	if err != nil {
+		ctx.Error(err)
+	}
+
+	ctx.JSON(http.StatusCreated, ret)
+}
+
 // swagger:operation POST /namespaces/{namespace}/secrets/tls Secret CreateTLSSecret
-// Create secret.
+// Create TLS secret.
+// Secret data must contain PEM encoded certificate chain in tls.crt and matching private key in tls.key.
+// Expired certificates are rejected.
 //
 // ---
 // x-method-visibility: private
@@ -146,6 +242,7 @@ func GetSecret(ctx *gin.Context) {
 //  - $ref: '#/parameters/UserIDHeader'
 //  - $ref: '#/parameters/UserRoleHeader'
 //  - $ref: '#/parameters/UserNamespaceHeader'
+//  - $ref: '#/parameters/DryRunQuery'
 //  - name: namespace
 //    in: path
 //    type: string
@@ -158,7 +255,7 @@ func GetSecret(ctx *gin.Context) {
 //  '201':
 //    description: secret created
 //    schema:
-//      $ref: '#/definitions/Secret'
+//      $ref: '#/definitions/SecretWithType'
 //  default:
 //    $ref: '#/responses/error'
 func CreateTLSSecret(ctx *gin.Context) {
@@ -182,7 +279,7 @@ func CreateTLSSecret(ctx *gin.Context) {
 		return
 	}
 
-	newSecret, errs := secretReq.ToKube(namespace, ns.Labels, api_core.SecretTypeOpaque)
+	newSecret, errs := secretReq.ToKube(namespace, "", ns.Labels, api_core.SecretTypeTLS)
 	if errs != nil {
 		gonic.Gonic(kubeerrors.ErrRequestValidationFailed().AddDetailsErr(errs...), ctx)
 		return
@@ -195,7 +292,7 @@ func CreateTLSSecret(ctx *gin.Context) {
 	}
 
 	role := ctx.MustGet(m.UserRole).(string)
-	ret, err := model.ParseKubeSecret(secretAfter, role == m.RoleUser)
+	ret, err := model.ParseKubeSecretWithType(secretAfter, role == m.RoleUser)
 	if err != nil {
 		ctx.Error(err)
 	}
@@ -204,7 +301,8 @@ func CreateTLSSecret(ctx *gin.Context) {
 }
 
 // swagger:operation POST /namespaces/{namespace}/secrets/docker Secret CreateDockerSecret
-// Create secret.
+// Create docker registry secret.
+// Secret is created from .dockerconfigjson in data (plain or base64 encoded) or from registry credentials.
 //
 // ---
 // x-method-visibility: private
@@ -212,6 +310,7 @@ func CreateTLSSecret(ctx *gin.Context) {
 //  - $ref: '#/parameters/UserIDHeader'
 //  - $ref: '#/parameters/UserRoleHeader'
 //  - $ref: '#/parameters/UserNamespaceHeader'
+//  - $ref: '#/parameters/DryRunQuery'
 //  - name: namespace
 //    in: path
 //    type: string
@@ -219,12 +318,12 @@ func CreateTLSSecret(ctx *gin.Context) {
 //  - name: body
 //    in: body
 //    schema:
-//      $ref: '#/definitions/Secret'
+//      $ref: '#/definitions/DockerSecret'
 // responses:
 //  '201':
 //    description: secret created
 //    schema:
-//      $ref: '#/definitions/Secret'
+//      $ref: '#/definitions/SecretWithType'
 //  default:
 //    $ref: '#/responses/error'
 func CreateDockerSecret(ctx *gin.Context) {
@@ -235,7 +334,7 @@ func CreateDockerSecret(ctx *gin.Context) {
 
 	kube := ctx.MustGet(m.KubeClient).(*kubernetes.Kube)
 
-	var secretReq model.SecretKubeAPI
+	var secretReq model.DockerSecret
 	if err := ctx.ShouldBindWith(&secretReq, binding.JSON); err != nil {
 		ctx.Error(err)
 		gonic.Gonic(kubeerrors.ErrRequestValidationFailed(), ctx)
@@ -248,7 +347,13 @@ func CreateDockerSecret(ctx *gin.Context) {
 		return
 	}
 
-	newSecret, errs := secretReq.ToKube(namespace, ns.Labels, api_core.SecretTypeDockerConfigJson)
+	dockerSecret, errs := secretReq.MakeSecret()
+	if errs != nil {
+		gonic.Gonic(kubeerrors.ErrRequestValidationFailed().AddDetailsErr(errs...), ctx)
+		return
+	}
+
+	newSecret, errs := dockerSecret.ToKube(namespace, "", ns.Labels, api_core.SecretTypeDockerConfigJson)
 	if errs != nil {
 		gonic.Gonic(kubeerrors.ErrRequestValidationFailed().AddDetailsErr(errs...), ctx)
 		return
@@ -261,7 +366,7 @@ func CreateDockerSecret(ctx *gin.Context) {
 	}
 
 	role := ctx.MustGet(m.UserRole).(string)
-	ret, err := model.ParseKubeSecret(secretAfter, role == m.RoleUser)
+	ret, err := model.ParseKubeSecretWithType(secretAfter, role == m.RoleUser)
 	if err != nil {
 		ctx.Error(err)
 	}
@@ -271,6 +376,8 @@ func CreateDockerSecret(ctx *gin.Context) {
 
 // swagger:operation PUT /namespaces/{namespace}/secrets/{secret} Secret UpdateSecret
 // Update secret.
+// Secret type is not changed unless docker query is set.
+// Deployments using secret are restarted if rollout query is true.
 //
 // ---
 // x-method-visibility: private
@@ -278,6 +385,7 @@ func CreateDockerSecret(ctx *gin.Context) {
 //  - $ref: '#/parameters/UserIDHeader'
 //  - $ref: '#/parameters/UserRoleHeader'
 //  - $ref: '#/parameters/UserNamespaceHeader'
+//  - $ref: '#/parameters/DryRunQuery'
 //  - name: namespace
 //    in: path
 //    type: string
@@ -290,15 +398,24 @@ func CreateDockerSecret(ctx *gin.Context) {
 //    in: query
 //    type: string
 //    required: false
+//  - name: rollout
+//    in: query
+//    type: boolean
+//    description: restart deployments using secret, in dry run mode they are reported as skipped
+//    required: false
 //  - name: body
 //    in: body
 //    schema:
-//      $ref: '#/definitions/Secret'
+//      $ref: '#/definitions/SecretWithType'
 // responses:
 //  '202':
 //    description: secret updated
 //    schema:
-//      $ref: '#/definitions/Secret'
+//      $ref: '#/definitions/SecretWithRollout'
+//  '207':
+//    description: secret updated, rollout or restart of some deployments failed
+//    schema:
+//      $ref: '#/definitions/SecretWithRollout'
 //  default:
 //    $ref: '#/responses/error'
 func UpdateSecret(ctx *gin.Context) {
@@ -311,7 +428,13 @@ func UpdateSecret(ctx *gin.Context) {
 
 	kube := ctx.MustGet(m.KubeClient).(*kubernetes.Kube)
 
-	var secretReq model.SecretKubeAPI
+	rollout, cherr := parseRolloutQuery(ctx)
+	if cherr != nil {
+		gonic.Gonic(cherr, ctx)
+		return
+	}
+
+	var secretReq model.SecretWithType
 	if err := ctx.ShouldBindWith(&secretReq, binding.JSON); err != nil {
 		ctx.Error(err)
 		gonic.Gonic(kubeerrors.ErrRequestValidationFailed(), ctx)
@@ -333,14 +456,17 @@ func UpdateSecret(ctx *gin.Context) {
 	secretReq.Name = sct
 	secretReq.Owner = oldSecret.GetObjectMeta().GetLabels()[ownerQuery]
 
-	_, isDocker := ctx.GetQuery("docker")
+	secretType := oldSecret.Type
+	if _, isDocker := ctx.GetQuery("docker"); isDocker {
+		secretType = api_core.SecretTypeDockerConfigJson
+	}
 
+	decoded, errs := secretReq.DecodeData()
 	var newSecret *api_core.Secret
-	var errs []error
-	if isDocker {
-		newSecret, errs = secretReq.ToKube(namespace, ns.Labels, api_core.SecretTypeDockerConfigJson)
-	} else {
-		newSecret, errs = secretReq.ToKube(namespace, ns.Labels, api_core.SecretTypeOpaque)
+	if decoded != nil {
+		var toKubeErrs []error
+		newSecret, toKubeErrs = decoded.ToKube(namespace, model.ParseSolutionID(oldSecret), ns.Labels, secretType)
+		errs = append(errs, toKubeErrs...)
 	}
 	if errs != nil {
 		gonic.Gonic(kubeerrors.ErrRequestValidationFailed().AddDetailsErr(errs...), ctx)
@@ -353,13 +479,22 @@ func UpdateSecret(ctx *gin.Context) {
 		return
 	}
 
+	ret := model.SecretWithRollout{}
 	role := ctx.MustGet(m.UserRole).(string)
-	ret, err := model.ParseKubeSecret(secretAfter, role == m.RoleUser)
-	if err != nil {
+	if parsed, err := model.ParseKubeSecretWithType(secretAfter, role == m.RoleUser); err != nil {
 		ctx.Error(err)
+	} else {
+		ret.SecretWithType = *parsed
+	}
+
+	status := http.StatusAccepted
+	if rollout {
+		// secret is already updated, so rollout failure is reported with it
+		ret.Rollout, ret.RolloutError = rolloutDependentDeployments(kube, namespace, "Secret", sct)
+		status = rolloutStatus(ret.Rollout, ret.RolloutError)
 	}
 
-	ctx.JSON(http.StatusAccepted, ret)
+	ctx.JSON(status, ret)
 }
 
 // swagger:operation DELETE /namespaces/{namespace}/secrets/{secret} Secret DeleteSecret
@@ -371,6 +506,7 @@ func UpdateSecret(ctx *gin.Context) {
 //  - $ref: '#/parameters/UserIDHeader'
 //  - $ref: '#/parameters/UserRoleHeader'
 //  - $ref: '#/parameters/UserNamespaceHeader'
+//  - $ref: '#/parameters/DryRunQuery'
 //  - name: namespace
 //    in: path
 //    type: string
@@ -0,0 +1,303 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"git.containerum.net/ch/kube-api/pkg/kubeerrors"
+	"git.containerum.net/ch/kube-api/pkg/kubernetes"
+	"git.containerum.net/ch/kube-api/pkg/model"
+	m "git.containerum.net/ch/kube-api/pkg/router/midlleware"
+	"github.com/containerum/cherry"
+	"github.com/containerum/cherry/adaptors/gonic"
+	kube_types "github.com/containerum/kube-client/pkg/model"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	log "github.com/sirupsen/logrus"
+	api_apps "k8s.io/api/apps/v1"
+	api_core "k8s.io/api/core/v1"
+	api_extensions "k8s.io/api/extensions/v1beta1"
+	api_errors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// swagger:operation POST /namespaces/{namespace}/solutions/{solution} Solution CreateSolution
+// Create solution resources.
+// Volumes and config maps are created first, then deployments, services and ingresses.
+// If any resource can't be created, already created resources are deleted.
+//
+// ---
+// x-method-visibility: private
+// parameters:
+//  - $ref: '#/parameters/UserIDHeader'
+//  - $ref: '#/parameters/UserRoleHeader'
+//  - $ref: '#/parameters/UserNamespaceHeader'
+//  - $ref: '#/parameters/DryRunQuery'
+//  - name: namespace
+//    in: path
+//    type: string
+//    required: true
+//  - name: solution
+//    in: path
+//    type: string
+//    required: true
+//  - name: body
+//    in: body
+//    schema:
+//      $ref: '#/definitions/SolutionKubeAPI'
+// responses:
+//  '201':
+//    description: solution created
+//    schema:
+//      $ref: '#/definitions/RunSolutionResponse'
+//  default:
+//    description: solution is not created, created resources are deleted
+//    schema:
+//      $ref: '#/definitions/RunSolutionResponse'
+func CreateSolution(ctx *gin.Context) {
+	namespace := ctx.Param(namespaceParam)
+	solution := ctx.Param(solutionParam)
+	log.WithFields(log.Fields{
+		"Namespace": namespace,
+		"Solution":  solution,
+	}).Debug("Create solution Call")
+
+	kube := ctx.MustGet(m.KubeClient).(*kubernetes.Kube)
+
+	var solutionReq model.SolutionKubeAPI
+	if err := ctx.ShouldBindWith(&solutionReq, binding.JSON); err != nil {
+		ctx.Error(err)
+		gonic.Gonic(kubeerrors.ErrRequestValidationFailed(), ctx)
+		return
+	}
+
+	ns, err := kube.GetNamespace(namespace)
+	if err != nil {
+		gonic.Gonic(model.ParseKubernetesResourceError(err, kubeerrors.ErrUnableCreateResource()), ctx)
+		return
+	}
+
+	objects, errs := solutionReq.ToKube(namespace, solution, ns.Labels)
+	if ctx.MustGet(m.UserRole).(string) == m.RoleUser {
+		for i := range solutionReq.Ingresses {
+			ingress := model.IngressKubeAPI(solutionReq.Ingresses[i])
+			errs = append(errs, ingress.ValidateUserInput()...)
+		}
+	}
+	if errs != nil {
+		gonic.Gonic(kubeerrors.ErrRequestValidationFailed().AddDetailsErr(errs...), ctx)
+		return
+	}
+
+	// volumes of solution are not bound yet when deployments are created
+	// and other objects are not persisted in dry run mode
+	solutionObjects := make(map[string]bool)
+	for _, obj := range objects {
+		solutionObjects[objectKey(obj.Kind, obj.Name)] = true
+	}
+
+	var created []model.ManifestObject
+	for _, obj := range objects {
+		if err := createSolutionObject(kube, obj.Object, solutionObjects); err != nil {
+			log.WithFields(log.Fields{
+				"Namespace": namespace,
+				"Solution":  solution,
+				"Kind":      obj.Kind,
+				"Name":      obj.Name,
+			}).WithError(err).Warn("Unable to create solution resource, rolling back")
+
+			var rollbackErrs []string
+			if !kube.IsDryRun() {
+				// nothing is persisted in dry run mode
+				rollbackErrs = deleteSolutionObjects(kube, namespace, created)
+			}
+			ctx.JSON(err.StatusHTTP, kube_types.RunSolutionResponse{
+				// resources which weren't deleted remain created
+				Created:    len(rollbackErrs),
+				NotCreated: len(objects) - len(rollbackErrs),
+				Errors:     append([]string{fmt.Sprintf("%v %v: %v", obj.Kind, obj.Name, err)}, rollbackErrs...),
+			})
+			return
+		}
+		created = append(created, obj)
+	}
+
+	ctx.JSON(http.StatusCreated, kube_types.RunSolutionResponse{
+		Created: len(created),
+	})
+}
+
+func createSolutionObject(kube *kubernetes.Kube, obj runtime.Object, solutionObjects map[string]bool) *cherry.Err {
+	var err error
+	switch newObj := obj.(type) {
+	case *api_core.PersistentVolumeClaim:
+		_, err = kube.CreatePersistentVolumeClaim(newObj)
+	case *api_core.ConfigMap:
+		_, err = kube.CreateConfigMap(newObj)
+	case *api_core.Secret:
+		_, err = kube.CreateSecret(newObj)
+	case *api_apps.Deployment:
+		if err := checkDeploymentDependencies(kube, newObj, solutionObjects); err != nil {
+			return err
+		}
+		_, err = kube.CreateDeployment(newObj)
+	case *api_core.Service:
+		_, err = kube.CreateService(newObj)
+	case *api_extensions.Ingress:
+		_, err = kube.CreateIngress(newObj)
+	default:
+		return kubeerrors.ErrInternalError().AddDetailF("unsupported object %T", obj)
+	}
+	if err != nil {
+		return parseResourceError(err, kubeerrors.ErrUnableCreateResource())
+	}
+	return nil
+}
+
+// deleteSolutionObjects deletes created objects in reverse order and returns deletion errors
+func deleteSolutionObjects(kube *kubernetes.Kube, namespace string, objects []model.ManifestObject) []string {
+	var errs []string
+	for i := len(objects) - 1; i >= 0; i-- {
+		obj := objects[i]
+		var err error
+		switch obj.Object.(type) {
+		case *api_core.PersistentVolumeClaim:
+			err = kube.DeletePersistentVolumeClaim(namespace, obj.Name)
+		case *api_core.ConfigMap:
+			err = kube.DeleteConfigMap(namespace, obj.Name)
+		case *api_core.Secret:
+			err = kube.DeleteSecret(namespace, obj.Name)
+		case *api_apps.Deployment:
+			err = kube.DeleteDeployment(namespace, obj.Name)
+		case *api_core.Service:
+			err = kube.DeleteService(namespace, obj.Name)
+		case *api_extensions.Ingress:
+			err = kube.DeleteIngress(namespace, obj.Name)
+		}
+		if err != nil && !api_errors.IsNotFound(err) {
+			errs = append(errs, fmt.Sprintf("unable to delete %v %v: %v", obj.Kind, obj.Name, err))
+		}
+	}
+	return errs
+}
+
+// swagger:operation GET /namespaces/{namespace}/solutions/{solution} Solution GetSolution
+// Get all resources of solution grouped by kind.
+//
+// ---
+// x-method-visibility: public
+// parameters:
+//  - $ref: '#/parameters/UserIDHeader'
+//  - $ref: '#/parameters/UserRoleHeader'
+//  - $ref: '#/parameters/UserNamespaceHeader'
+//  - name: namespace
+//    in: path
+//    type: string
+//    required: true
+//  - name: solution
+//    in: path
+//    type: string
+//    required: true
+// responses:
+//  '200':
+//    description: solution resources
+//    schema:
+//      $ref: '#/definitions/SolutionKubeAPI'
+//  default:
+//    $ref: '#/responses/error'
+func GetSolution(ctx *gin.Context) {
+	namespace := ctx.Param(namespaceParam)
+	solution := ctx.Param(solutionParam)
+	log.WithFields(log.Fields{
+		"Namespace": namespace,
+		"Solution":  solution,
+	}).Debug("Get solution Call")
+
+	kube := ctx.MustGet(m.KubeClient).(*kubernetes.Kube)
+
+	if errs := model.ValidateSolutionID(solution); errs != nil {
+		gonic.Gonic(kubeerrors.ErrRequestValidationFailed().AddDetailsErr(errs...), ctx)
+		return
+	}
+
+	_, err := kube.GetNamespace(namespace)
+	if err != nil {
+		gonic.Gonic(model.ParseKubernetesResourceError(err, kubeerrors.ErrUnableGetResource()), ctx)
+		return
+	}
+
+	objects, err := kube.GetNamespaceObjects(namespace, solution, true)
+	if err != nil {
+		gonic.Gonic(model.ParseKubernetesResourceError(err, kubeerrors.ErrUnableGetResource()), ctx)
+		return
+	}
+
+	role := ctx.MustGet(m.UserRole).(string)
+	ret, err := model.ParseSolutionObjects((*model.NamespaceObjects)(objects), role == m.RoleUser)
+	if err != nil {
+		ctx.Error(err)
+		gonic.Gonic(kubeerrors.ErrUnableGetResource(), ctx)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, ret)
+}
+
+// swagger:operation DELETE /namespaces/{namespace}/solutions/{solution} Solution DeleteSolution
+// Delete all resources of solution.
+// Ingresses, services and deployments are deleted before secrets, config maps and volumes they use.
+//
+// ---
+// x-method-visibility: public
+// parameters:
+//  - $ref: '#/parameters/UserIDHeader'
+//  - $ref: '#/parameters/UserRoleHeader'
+//  - $ref: '#/parameters/UserNamespaceHeader'
+//  - $ref: '#/parameters/DryRunQuery'
+//  - name: namespace
+//    in: path
+//    type: string
+//    required: true
+//  - name: solution
+//    in: path
+//    type: string
+//    required: true
+// responses:
+//  '202':
+//    description: solution deleted
+//  default:
+//    $ref: '#/responses/error'
+func DeleteSolution(ctx *gin.Context) {
+	namespace := ctx.Param(namespaceParam)
+	solution := ctx.Param(solutionParam)
+	log.WithFields(log.Fields{
+		"Namespace": namespace,
+		"Solution":  solution,
+	}).Debug("Delete solution Call")
+
+	kube := ctx.MustGet(m.KubeClient).(*kubernetes.Kube)
+
+	if errs := model.ValidateSolutionID(solution); errs != nil {
+		gonic.Gonic(kubeerrors.ErrRequestValidationFailed().AddDetailsErr(errs...), ctx)
+		return
+	}
+
+	_, err := kube.GetNamespace(namespace)
+	if err != nil {
+		gonic.Gonic(model.ParseKubernetesResourceError(err, kubeerrors.ErrUnableDeleteResource()), ctx)
+		return
+	}
+
+	objects, err := kube.GetNamespaceObjects(namespace, solution, true)
+	if err != nil {
+		gonic.Gonic(model.ParseKubernetesResourceError(err, kubeerrors.ErrUnableDeleteResource()), ctx)
+		return
+	}
+
+	if errs := deleteSolutionObjects(kube, namespace, model.MakeSolutionObjects((*model.NamespaceObjects)(objects))); errs != nil {
+		gonic.Gonic(kubeerrors.ErrUnableDeleteResource().AddDetails(errs...), ctx)
+		return
+	}
+
+	ctx.Status(http.StatusAccepted)
+}
@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"net/http"
+
+	"git.containerum.net/ch/kube-api/pkg/kubeerrors"
+	"git.containerum.net/ch/kube-api/pkg/kubernetes"
+	"git.containerum.net/ch/kube-api/pkg/model"
+	m "git.containerum.net/ch/kube-api/pkg/router/midlleware"
+	"github.com/containerum/cherry"
+	"github.com/containerum/cherry/adaptors/gonic"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+	api_errors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// swagger:operation GET /namespaces/{namespace}/usage Namespace GetNamespaceUsage
+// Get namespace quota with current resources usage.
+//
+// ---
+// x-method-visibility: public
+// parameters:
+//  - $ref: '#/parameters/UserIDHeader'
+//  - $ref: '#/parameters/UserRoleHeader'
+//  - $ref: '#/parameters/UserNamespaceHeader'
+//  - name: namespace
+//    in: path
+//    type: string
+//    required: true
+// responses:
+//  '200':
+//    description: namespace usage
+//    schema:
+//      $ref: '#/definitions/NamespaceUsage'
+//  default:
+//    $ref: '#/responses/error'
+func GetNamespaceUsage(ctx *gin.Context) {
+	namespace := ctx.Param(namespaceParam)
+	log.WithFields(log.Fields{
+		"Namespace": namespace,
+	}).Debug("Get namespace usage Call")
+
+	kube := ctx.MustGet(m.KubeClient).(*kubernetes.Kube)
+
+	quota, err := kube.GetNamespaceQuota(namespace)
+	if err != nil {
+		gonic.Gonic(model.ParseKubernetesResourceError(err, kubeerrors.ErrUnableGetResource()), ctx)
+		return
+	}
+
+	metrics, err := kube.GetPodMetricsList(namespace)
+	if err != nil {
+		gonic.Gonic(parseMetricsError(err), ctx)
+		return
+	}
+
+	ret, err := model.ParseNamespaceUsage(quota, metrics)
+	if err != nil {
+		ctx.Error(err)
+		gonic.Gonic(kubeerrors.ErrUnableGetResource(), ctx)
+		return
+	}
+
+	role := ctx.MustGet(m.UserRole).(string)
+	if role == m.RoleUser {
+		nsList := ctx.MustGet(m.UserNamespaces).(*model.UserHeaderDataMap)
+		ret.NamespaceWithQuota = *model.ParseForUser(&ret.NamespaceWithQuota, *nsList)
+	}
+
+	ctx.JSON(http.StatusOK, ret)
+}
+
+// swagger:operation GET /namespaces/{namespace}/deployments/{deployment}/usage Deployment GetDeploymentUsage
+// Get current resources usage of deployment pods.
+//
+// ---
+// x-method-visibility: public
+// parameters:
+//  - $ref: '#/parameters/UserIDHeader'
+//  - $ref: '#/parameters/UserRoleHeader'
+//  - $ref: '#/parameters/UserNamespaceHeader'
+//  - name: namespace
+//    in: path
+//    type: string
+//    required: true
+//  - name: deployment
+//    in: path
+//    type: string
+//    required: true
+// responses:
+//  '200':
+//    description: deployment usage
+//    schema:
+//      $ref: '#/definitions/DeploymentUsage'
+//  default:
+//    $ref: '#/responses/error'
+func GetDeploymentUsage(ctx *gin.Context) {
+	namespace := ctx.Param(namespaceParam)
+	deployment := ctx.Param(deploymentParam)
+	log.WithFields(log.Fields{
+		"Namespace":  namespace,
+		"Deployment": deployment,
+	}).Debug("Get deployment usage Call")
+
+	kube := ctx.MustGet(m.KubeClient).(*kubernetes.Kube)
+
+	_, err := kube.GetDeployment(namespace, deployment)
+	if err != nil {
+		gonic.Gonic(model.ParseKubernetesResourceError(err, kubeerrors.ErrUnableGetResource()), ctx)
+		return
+	}
+
+	metrics, err := kube.GetDeploymentPodMetricsList(namespace, deployment)
+	if err != nil {
+		gonic.Gonic(parseMetricsError(err), ctx)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.ParseDeploymentUsage(deployment, metrics))
+}
+
+// swagger:operation GET /namespaces/{namespace}/pods/{pod}/usage Pod GetPodUsage
+// Get current resources usage of pod containers.
+//
+// ---
+// x-method-visibility: public
+// parameters:
+//  - $ref: '#/parameters/UserIDHeader'
+//  - $ref: '#/parameters/UserRoleHeader'
+//  - $ref: '#/parameters/UserNamespaceHeader'
+//  - name: namespace
+//    in: path
+//    type: string
+//    required: true
+//  - name: pod
+//    in: path
+//    type: string
+//    required: true
+// responses:
+//  '200':
+//    description: pod usage
+//    schema:
+//      $ref: '#/definitions/PodUsage'
+//  default:
+//    $ref: '#/responses/error'
+func GetPodUsage(ctx *gin.Context) {
+	namespace := ctx.Param(namespaceParam)
+	podP := ctx.Param(podParam)
+	log.WithFields(log.Fields{
+		"Namespace": namespace,
+		"Pod":       podP,
+	}).Debug("Get pod usage Call")
+
+	kube := ctx.MustGet(m.KubeClient).(*kubernetes.Kube)
+
+	_, err := kube.GetPod(namespace, podP)
+	if err != nil {
+		gonic.Gonic(model.ParseKubernetesResourceError(err, kubeerrors.ErrUnableGetResource()), ctx)
+		return
+	}
+
+	metrics, err := kube.GetPodMetrics(namespace, podP)
+	if err != nil {
+		if api_errors.IsNotFound(err) {
+			// pod exists, but metrics server hasn't collected its usage yet
+			gonic.Gonic(kubeerrors.ErrMetricsUnavailable().AddDetailF("usage of pod %v is not collected yet", podP), ctx)
+			return
+		}
+		gonic.Gonic(parseMetricsError(err), ctx)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.ParsePodMetrics(metrics))
+}
+
+func parseMetricsError(err error) *cherry.Err {
+	if cherryErr, isCherryErr := err.(*cherry.Err); isCherryErr {
+		return cherryErr
+	}
+	return model.ParseKubernetesResourceError(err, kubeerrors.ErrUnableGetResource().AddDetailsErr(err))
+}
@@ -47,8 +47,21 @@ func WriteAccess(ctx *gin.Context) {
 	CheckAccess(ctx, writeLevels)
 }
 
+// OperationAccess checks user access to namespace of namespace deletion operation
+func OperationAccess(ctx *gin.Context) {
+	ns, _, err := model.ParseNamespaceDeletionID(ctx.Param("operation"))
+	if err != nil {
+		gonic.Gonic(kubeerrors.ErrRequestValidationFailed().AddDetailsErr(err), ctx)
+		return
+	}
+	checkNamespaceAccess(ctx, ns, deleteLevels)
+}
+
 func CheckAccess(ctx *gin.Context, level []kubeModel.AccessLevel) {
-	ns := ctx.Param("namespace")
+	checkNamespaceAccess(ctx, ctx.Param("namespace"), level)
+}
+
+func checkNamespaceAccess(ctx *gin.Context, ns string, level []kubeModel.AccessLevel) {
 	if GetHeader(ctx, headers.UserRoleXHeader) == RoleUser {
 		var userNsData *kubeModel.UserHeaderData
 		nsList := ctx.MustGet(UserNamespaces).(*model.UserHeaderDataMap)
@@ -1,7 +1,11 @@
 package middleware
 
 import (
+	"strconv"
+
+	"git.containerum.net/ch/kube-api/pkg/kubeerrors"
 	"git.containerum.net/ch/kube-api/pkg/kubernetes"
+	"github.com/containerum/cherry/adaptors/gonic"
 	"github.com/gin-gonic/gin"
 )
 
@@ -18,3 +22,21 @@ func RegisterKubeClient(kube *kubernetes.Kube) gin.HandlerFunc {
 		c.Set(KubeClient, kube)
 	}
 }
+
+const dryRunQuery = "dryRun"
+
+// DryRun replaces kubernetes client with one which doesn't persist changes if "dryRun" query is true
+func DryRun(c *gin.Context) {
+	value := c.Query(dryRunQuery)
+	if value == "" {
+		return
+	}
+	dryRun, err := strconv.ParseBool(value)
+	if err != nil {
+		gonic.Gonic(kubeerrors.ErrRequestValidationFailed().AddDetailF("invalid %v query value: %v", dryRunQuery, value), c)
+		return
+	}
+	if dryRun {
+		c.Set(KubeClient, c.MustGet(KubeClient).(*kubernetes.Kube).DryRun())
+	}
+}
@@ -1,6 +1,7 @@
 package router
 
 import (
+	"expvar"
 	"net/http"
 
 	"git.containerum.net/ch/kube-api/pkg/kubeerrors"
@@ -25,6 +26,7 @@ func CreateRouter(kube *kubernetes.Kube, status *model.ServiceStatus, enableCORS
 	e := gin.New()
 	e.GET("/status", httputil.ServiceStatus(status))
 	initMiddlewares(e, kube)
+	e.GET("/debug/vars", httputil.RequireAdminRole(kubeerrors.ErrAdminRequired), gin.WrapH(expvar.Handler()))
 	initRoutes(e, status, enableCORS)
 	return e
 }
@@ -52,34 +54,45 @@ func initRoutes(e gin.IRouter, status *model.ServiceStatus, enableCORS bool) {
 		StaticFS("/", static.HTTP)
 
 	e.GET("/ingresses", h.GetSelectedIngresses)
+	e.GET("/certificates", h.GetCertificateExpiryList)
 	e.GET("/configmaps", h.GetSelectedConfigMaps)
 	e.GET("/storage", h.GetStorageList)
 
+	e.GET("/operations/:operation", m.OperationAccess, h.GetNamespaceDeletion)
+
 	namespace := e.Group("/namespaces")
 	{
 		namespace.GET("", h.GetNamespaceList)
 		namespace.GET("/:namespace", m.ReadAccess, h.GetNamespace)
-		namespace.POST("", h.CreateNamespace)
-		namespace.PUT("/:namespace", h.UpdateNamespace)
-		namespace.DELETE("/:namespace", h.DeleteNamespace)
-		namespace.DELETE("", h.DeleteUserNamespaces)
+		namespace.GET("/:namespace/usage", m.ReadAccess, h.GetNamespaceUsage)
+		namespace.GET("/:namespace/export", m.ReadAccess, h.ExportNamespace)
+		namespace.POST("/:namespace/apply", m.WriteAccess, m.DryRun, h.ApplyManifests)
+		namespace.POST("", m.DryRun, h.CreateNamespace)
+		namespace.PUT("/:namespace", m.DryRun, h.UpdateNamespace)
+		namespace.POST("/:namespace/repair", httputil.RequireAdminRole(kubeerrors.ErrAdminRequired), m.DryRun, h.RepairNamespace)
+		namespace.POST("/:namespace/clone", httputil.RequireAdminRole(kubeerrors.ErrAdminRequired), m.DryRun, h.CloneNamespace)
+		namespace.DELETE("/:namespace", m.DryRun, h.DeleteNamespace)
+		namespace.DELETE("", m.DryRun, h.DeleteUserNamespaces)
 
 		solutions := namespace.Group("/:namespace/solutions")
 		{
+			solutions.GET("/:solution", m.ReadAccess, h.GetSolution)
+			solutions.POST("/:solution", m.WriteAccess, m.DryRun, h.CreateSolution)
 			solutions.GET("/:solution/deployments", m.ReadAccess, h.GetDeploymentSolutionList)
 			solutions.GET("/:solution/services", m.ReadAccess, h.GetServiceSolutionList)
 
-			solutions.DELETE("/:solution/deployments", m.WriteAccess, h.DeleteDeploymentsSolution)
-			solutions.DELETE("/:solution/services", m.WriteAccess, h.DeleteServicesSolution)
+			solutions.DELETE("/:solution", m.WriteAccess, m.DryRun, h.DeleteSolution)
+			solutions.DELETE("/:solution/deployments", m.WriteAccess, m.DryRun, h.DeleteDeploymentsSolution)
+			solutions.DELETE("/:solution/services", m.WriteAccess, m.DryRun, h.DeleteServicesSolution)
 		}
 
 		service := namespace.Group("/:namespace/services")
 		{
 			service.GET("", m.ReadAccess, h.GetServiceList)
 			service.GET("/:service", m.ReadAccess, h.GetService)
-			service.POST("", h.CreateService)
-			service.PUT("/:service", h.UpdateService)
-			service.DELETE("/:service", h.DeleteService)
+			service.POST("", m.DryRun, h.CreateService)
+			service.PUT("/:service", m.DryRun, h.UpdateService)
+			service.DELETE("/:service", m.DryRun, h.DeleteService)
 		}
 
 		deployment := namespace.Group("/:namespace/deployments")
@@ -87,57 +100,62 @@ func initRoutes(e gin.IRouter, status *model.ServiceStatus, enableCORS bool) {
 			deployment.GET("", m.ReadAccess, h.GetDeploymentList)
 			deployment.GET("/:deployment", m.ReadAccess, h.GetDeployment)
 			deployment.GET("/:deployment/pods", m.ReadAccess, h.GetDeploymentPodList)
-			deployment.POST("", h.CreateDeployment)
-			deployment.PUT("/:deployment", h.UpdateDeployment)
-			deployment.PUT("/:deployment/replicas", h.UpdateDeploymentReplicas)
-			deployment.PUT("/:deployment/image", h.UpdateDeploymentImage)
-			deployment.DELETE("/:deployment", h.DeleteDeployment)
+			deployment.GET("/:deployment/usage", m.ReadAccess, h.GetDeploymentUsage)
+			deployment.POST("", m.DryRun, h.CreateDeployment)
+			deployment.PUT("/:deployment", m.DryRun, h.UpdateDeployment)
+			deployment.PUT("/:deployment/replicas", m.DryRun, h.UpdateDeploymentReplicas)
+			deployment.PUT("/:deployment/image", m.DryRun, h.UpdateDeploymentImage)
+			deployment.DELETE("/:deployment", m.DryRun, h.DeleteDeployment)
 		}
 
 		secret := namespace.Group("/:namespace/secrets")
 		{
 			secret.GET("", m.ReadAccess, h.GetSecretList)
 			secret.GET("/:secret", m.ReadAccess, h.GetSecret)
-			secret.POST("/tls", m.WriteAccess, h.CreateTLSSecret)
-			secret.POST("/docker", m.WriteAccess, h.CreateDockerSecret)
-			secret.PUT("/:secret", m.WriteAccess, h.UpdateSecret)
-			secret.DELETE("/:secret", m.DeleteAccess, h.DeleteSecret)
+			secret.POST("/opaque", m.WriteAccess, m.DryRun, h.CreateOpaqueSecret)
+			secret.POST("/tls", m.WriteAccess, m.DryRun, h.CreateTLSSecret)
+			secret.POST("/docker", m.WriteAccess, m.DryRun, h.CreateDockerSecret)
+			secret.PUT("/:secret", m.WriteAccess, m.DryRun, h.UpdateSecret)
+			secret.DELETE("/:secret", m.DeleteAccess, m.DryRun, h.DeleteSecret)
 		}
 
 		ingress := namespace.Group("/:namespace/ingresses")
 		{
 			ingress.GET("", m.ReadAccess, h.GetIngressList)
 			ingress.GET("/:ingress", m.ReadAccess, h.GetIngress)
-			ingress.POST("", h.CreateIngress)
-			ingress.PUT("/:ingress", h.UpdateIngress)
-			ingress.DELETE("/:ingress", h.DeleteIngress)
+			ingress.POST("", m.DryRun, h.CreateIngress)
+			ingress.PUT("/:ingress", m.DryRun, h.UpdateIngress)
+			ingress.DELETE("/:ingress", m.DryRun, h.DeleteIngress)
 		}
 
 		endpoint := namespace.Group("/:namespace/endpoints", httputil.RequireAdminRole(kubeerrors.ErrAdminRequired))
 		{
 			endpoint.GET("", h.GetEndpointList)
 			endpoint.GET("/:endpoint", h.GetEndpoint)
-			endpoint.POST("", h.CreateEndpoint)
-			endpoint.PUT("/:endpoint", h.UpdateEndpoint)
-			endpoint.DELETE("/:endpoint", h.DeleteEndpoint)
+			endpoint.POST("", m.DryRun, h.CreateEndpoint)
+			endpoint.PUT("/:endpoint", m.DryRun, h.UpdateEndpoint)
+			endpoint.DELETE("/:endpoint", m.DryRun, h.DeleteEndpoint)
 		}
 
 		configmap := namespace.Group("/:namespace/configmaps")
 		{
 			configmap.GET("", m.ReadAccess, h.GetConfigMapList)
 			configmap.GET("/:configmap", m.ReadAccess, h.GetConfigMap)
-			configmap.POST("", m.WriteAccess, h.CreateConfigMap)
-			configmap.PUT("/:configmap", m.WriteAccess, h.UpdateConfigMap)
-			configmap.DELETE("/:configmap", m.DeleteAccess, h.DeleteConfigMap)
+			configmap.GET("/:configmap/files", m.ReadAccess, h.DownloadConfigMapFiles)
+			configmap.POST("", m.WriteAccess, m.DryRun, h.CreateConfigMap)
+			configmap.POST("/files", m.WriteAccess, m.DryRun, h.CreateConfigMapFromFiles)
+			configmap.PUT("/:configmap", m.WriteAccess, m.DryRun, h.UpdateConfigMap)
+			configmap.PUT("/:configmap/files", m.WriteAccess, m.DryRun, h.UpdateConfigMapFromFiles)
+			configmap.DELETE("/:configmap", m.DeleteAccess, m.DryRun, h.DeleteConfigMap)
 		}
 
 		volume := namespace.Group("/:namespace/volumes")
 		{
 			volume.GET("", m.ReadAccess, h.GetVolumeList)
 			volume.GET("/:volume", m.ReadAccess, h.GetVolume)
-			volume.POST("", m.WriteAccess, h.CreateVolume)
-			volume.PUT("/:volume", m.WriteAccess, h.UpdateVolume)
-			volume.DELETE("/:volume", m.DeleteAccess, h.DeleteVolume)
+			volume.POST("", m.WriteAccess, m.DryRun, h.CreateVolume)
+			volume.PUT("/:volume", m.WriteAccess, m.DryRun, h.UpdateVolume)
+			volume.DELETE("/:volume", m.DeleteAccess, m.DryRun, h.DeleteVolume)
 		}
 
 		pod := namespace.Group("/:namespace/pods")
@@ -145,7 +163,13 @@ func initRoutes(e gin.IRouter, status *model.ServiceStatus, enableCORS bool) {
 			pod.GET("", m.ReadAccess, h.GetPodList)
 			pod.GET("/:pod", m.ReadAccess, h.GetPod)
 			pod.GET("/:pod/log", m.ReadAccess, h.GetPodLogs)
-			pod.DELETE("/:pod", m.DeleteAccess, h.DeletePod)
+			pod.GET("/:pod/usage", m.ReadAccess, h.GetPodUsage)
+			pod.POST("/:pod/exec", m.WriteAccess, h.ExecPodCommand)
+			pod.GET("/:pod/portforward", m.WriteAccess, h.PortForward)
+			pod.GET("/:pod/attach", m.WriteAccess, h.Attach)
+			pod.GET("/:pod/files", m.WriteAccess, h.DownloadPodFiles)
+			pod.PUT("/:pod/files", m.WriteAccess, h.UploadPodFiles)
+			pod.DELETE("/:pod", m.DeleteAccess, m.DryRun, h.DeletePod)
 		}
 	}
 }